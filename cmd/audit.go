@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+// runAudit audita a qualidade dos dados de uma coleção
+func runAudit(args []string) error {
+	fs := newFlagSet("audit [flags]", "Aponta duplicatas, pontos fora da UF, população ausente, grafia suspeita e capitais ausentes, sem alterar nada.")
+	var storage storageFlags
+	storage.register(fs)
+	collection := fs.String("collection", "", "Coleção auditada (padrão: coleção principal)")
+	format := fs.String("format", "markdown", "Formato do relatório: markdown ou json")
+	output := fs.String("output", "-", "Arquivo do relatório (- para stdout)")
+	km := fs.Float64("km", domain.DefaultAuditDuplicateKm, "Distância máxima entre localizações de mesmo nome e UF para apontar duplicata")
+	polygons := fs.Bool("polygons", false, "Conferir a UF pelos limites municipais importados com import limites (uma consulta por localização)")
+	samples := fs.Int("samples", domain.DefaultAuditSamples, "Problemas listados por verificação (-1 para todos)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Tempo máximo da auditoria (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	switch *format {
+	case "markdown", "md", "json":
+	default:
+		return usageErrorf("-format inválido: use markdown ou json")
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	log.Println("🔎 Auditando a qualidade dos dados...")
+	report, err := app.Auditoria.Audit(ctx, domain.AuditOptions{
+		Collection:          *collection,
+		DuplicateDistanceKm: *km,
+		Polygons:            *polygons,
+		MaxSamples:          *samples,
+	})
+	if err != nil {
+		return fmt.Errorf("erro na auditoria: %v", err)
+	}
+	if err := writeAudit(*output, *format, report); err != nil {
+		return fmt.Errorf("erro ao gravar auditoria: %v", err)
+	}
+	for _, check := range domain.AuditChecks {
+		log.Printf("   - %s: %d", check, report.Counts[check])
+	}
+	return nil
+}
+
+// writeAudit grava o relatório da auditoria em Markdown ou JSON em path ("-"
+// para stdout)
+func writeAudit(path, format string, report *domain.AuditReport) error {
+	if format == "json" {
+		return writeJSON(path, report)
+	}
+
+	if path == "-" {
+		return services.WriteAuditMarkdown(os.Stdout, report)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := services.WriteAuditMarkdown(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// configCommands são as operações do comando config
+var configCommands = []command{
+	{"print", "Mostrar a configuração efetiva, com a origem de cada valor", configPrint},
+}
+
+func runConfigCommand(args []string) error {
+	return dispatch("config", configCommands, args)
+}
+
+// configPrint mostra a configuração efetiva em YAML, com a origem de cada
+// chave em comentário e os segredos mascarados
+func configPrint(args []string) error {
+	fs := newFlagSet("config print",
+		"Mostra a configuração efetiva: os padrões, sobrepostos pelo arquivo (-config, GEO_CONFIG ou\n"+
+			"geolocation.yaml no diretório atual) e pelas variáveis de ambiente. As flags de cada comando\n"+
+			"sobrepõem esses valores apenas naquele comando. Senhas aparecem como ***.")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	// Sem a configuração carregada não há o que mostrar; já uma configuração
+	// inválida é mostrada, para ajudar a corrigi-la
+	if cfgErr != nil {
+		return usageErrorf("%v", cfgErr)
+	}
+
+	file := cfg.File()
+	if file == "" {
+		file = "nenhum"
+	}
+	fmt.Printf("# Arquivo de configuração: %s\n", file)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	section := ""
+	for _, s := range cfg.Settings() {
+		name, key, _ := strings.Cut(s.Key, ".")
+		if name != section {
+			w.Flush()
+			section = name
+			fmt.Printf("\n%s:\n", section)
+		}
+		origin := s.Origin
+		if !strings.HasPrefix(origin, "env ") {
+			origin += "; env " + s.Env
+		}
+		fmt.Fprintf(w, "  %s: %s\t# %s\n", key, s.Value, origin)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return checkConfig(cfg)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+// runConflate casa os registros das coleções de cada fonte e grava as
+// localizações canônicas
+func runConflate(args []string) error {
+	fs := newFlagSet("conflate [flags] nome=coleção...",
+		"Casa os registros das coleções de cada fonte, em ordem de prioridade, e grava uma localização\n"+
+			"canônica por localidade com a proveniência de cada campo.\n"+
+			"Ex: conflate ibge=municipios_ibge osm=localizacoes_osm geonames=localizacoes")
+	var storage storageFlags
+	storage.register(fs)
+	into := fs.String("into", "localizacoes_canonicas", "Coleção recriada com as localizações canônicas")
+	priority := fs.String("priority", "", "Prioridade das fontes por campo, ex: populacao=osm,ibge;localizacao=geonames")
+	km := fs.Float64("km", domain.DefaultConflationDistanceKm, "Distância máxima para casar registros de mesmo nome e UF")
+	dryRun := fs.Bool("dry-run", false, "Apenas casar os registros e gerar o relatório, sem gravar nada")
+	reportPath := fs.String("report", "", "Arquivo para gravar o relatório JSON da conflação (- para stdout)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Tempo máximo da conflação (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, -1); err != nil {
+		return err
+	}
+	opts, err := parseConflationOptions(strings.Join(args, ","), *priority)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	opts.Target = *into
+	opts.MaxDistanceKm = *km
+	opts.DryRun = *dryRun
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	log.Printf("🧬 Conflacionando %d fontes em %s...", len(opts.Sources), opts.Target)
+	if !opts.DryRun && isMainCollection(opts.Target) {
+		datasetModified(ctx, app)
+	}
+	report, err := app.Conflacao.Conflate(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("erro na conflação: %v", err)
+	}
+	if *reportPath != "" {
+		if err := writeJSON(*reportPath, report); err != nil {
+			return fmt.Errorf("erro ao gravar relatório: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseConflationOptions lê as fontes ("nome=coleção,...") e as prioridades
+// por campo ("campo=fonte,fonte;campo=fonte") das flags de conflação
+func parseConflationOptions(sources, priority string) (domain.ConflationOptions, error) {
+	var opts domain.ConflationOptions
+	for _, pair := range strings.Split(sources, ",") {
+		name, collection, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(collection) == "" {
+			return opts, fmt.Errorf("fonte inválida: %q (use nome=coleção)", pair)
+		}
+		opts.Sources = append(opts.Sources, domain.ConflationSource{
+			Nome:    strings.TrimSpace(name),
+			Colecao: strings.TrimSpace(collection),
+		})
+	}
+
+	if priority == "" {
+		return opts, nil
+	}
+	opts.FieldPriority = map[string][]string{}
+	for _, entry := range strings.Split(priority, ";") {
+		field, names, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(names) == "" {
+			return opts, fmt.Errorf("prioridade inválida em -priority: %q (use campo=fonte,fonte)", entry)
+		}
+		for _, name := range strings.Split(names, ",") {
+			opts.FieldPriority[strings.TrimSpace(field)] = append(opts.FieldPriority[strings.TrimSpace(field)], strings.TrimSpace(name))
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+// runExport grava a coleção, ou parte dela, em um arquivo
+func runExport(args []string) error {
+	fs := newFlagSet("export [flags]",
+		"Grava a coleção inteira ou as localizações que casam com os filtros, em ordem de _id.\n"+
+			"Formatos: geonames (TSV do dump, lido por import geonames), csv (lido por import csv),\n"+
+			"geojson (FeatureCollection), ndjson (uma Feature por linha, lido por import geojson) e\n"+
+			"snapshot (documentos completos, recarregados exatamente por import snapshot).")
+	var storage storageFlags
+	storage.register(fs)
+	collection := fs.String("collection", "", "Coleção exportada (padrão: coleção principal)")
+	format := fs.String("format", domain.ExportCSV, "Formato: "+strings.Join(domain.ExportFormats, ", "))
+	output := fs.String("output", "-", "Arquivo de saída (- para stdout)")
+	ufs := fs.String("uf", "", "UFs separadas por vírgula (sigla, nome ou código IBGE)")
+	featureCodes := fs.String("feature-code", "", "Códigos de feição do GeoNames separados por vírgula (ex: PPLC,PPLA); só casa registros importados do GeoNames")
+	minPopulation := fs.Int("min-population", 0, "População mínima")
+	maxPopulation := fs.Int("max-population", 0, "População máxima (0 = sem limite)")
+	bbox := fs.String("bbox", "", "Retângulo minLon,minLat,maxLon,maxLat (ex: -47.5,-24.1,-46.2,-23.2)")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Tempo máximo da exportação (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	opts := domain.ExportOptions{Collection: *collection, Format: *format}
+	if !validExportFormat(*format) {
+		return usageErrorf("-format inválido: use %s", strings.Join(domain.ExportFormats, ", "))
+	}
+	for _, value := range splitList(*ufs) {
+		state, ok := domain.ResolveState(value)
+		if !ok {
+			return usageErrorf("UF inválida %q: use a sigla (SP), o nome (São Paulo) ou o código IBGE (35)", value)
+		}
+		opts.Filter.Estados = append(opts.Filter.Estados, state.UF)
+	}
+	for _, code := range splitList(*featureCodes) {
+		opts.Filter.FeatureCodes = append(opts.Filter.FeatureCodes, strings.ToUpper(code))
+	}
+	if *minPopulation < 0 || *maxPopulation < 0 {
+		return usageErrorf("-min-population e -max-population não podem ser negativos")
+	}
+	if *maxPopulation > 0 && *minPopulation > *maxPopulation {
+		return usageErrorf("-min-population maior que -max-population")
+	}
+	opts.Filter.MinPopulacao, opts.Filter.MaxPopulacao = *minPopulation, *maxPopulation
+	if *bbox != "" {
+		box, err := parseBBox(*bbox)
+		if err != nil {
+			return usageErrorf("-bbox inválido: %v", err)
+		}
+		opts.Filter.BBox = &box
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	var exported int64
+	err = writeOutput(*output, func(w io.Writer) error {
+		var err error
+		exported, err = app.Exportacao.Export(ctx, w, opts)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("erro na exportação: %v", err)
+	}
+	log.Printf("📦 %d localizações exportadas em %s", exported, *format)
+	return nil
+}
+
+func validExportFormat(format string) bool {
+	for _, f := range domain.ExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList separa uma lista de valores por vírgula, ignorando os vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseBBox lê "minLon,minLat,maxLon,maxLat", a ordem do bbox do GeoJSON
+func parseBBox(value string) (domain.BBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return domain.BBox{}, fmt.Errorf("use minLon,minLat,maxLon,maxLat")
+	}
+
+	var coords [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return domain.BBox{}, fmt.Errorf("coordenada %q não é um número", part)
+		}
+		coords[i] = v
+	}
+
+	box := domain.BBox{MinLon: coords[0], MinLat: coords[1], MaxLon: coords[2], MaxLat: coords[3]}
+	if box.MinLon < -180 || box.MaxLon > 180 || box.MinLat < -90 || box.MaxLat > 90 {
+		return domain.BBox{}, fmt.Errorf("coordenadas fora do intervalo válido")
+	}
+	if box.MinLon >= box.MaxLon || box.MinLat >= box.MaxLat {
+		return domain.BBox{}, fmt.Errorf("o mínimo deve ser menor que o máximo")
+	}
+	return box, nil
+}
+
+// writeOutput chama write com stdout ("-") ou com um arquivo temporário ao lado
+// de path, renomeado para path apenas se write terminar sem erro; uma
+// exportação interrompida não deixa um arquivo pela metade
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
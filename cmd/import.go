@@ -0,0 +1,735 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/bootstrap"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/infrastructure/download"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/kml"
+)
+
+// importSources são as fontes aceitas pelo comando import
+var importSources = []command{
+	{"geonames", "GeoNames: baixa o BR.zip ou importa um BR.txt/BR.zip local", importGeoNames},
+	{"sample", "Dados de exemplo (30 principais cidades)", importSample},
+	{"csv", "CSV genérico de pontos com o mapeamento de -map", importCSV},
+	{"geojson", "Pontos em GeoJSON (FeatureCollection ou NDJSON)", importGeoJSON},
+	{"kml", "Placemarks em KML ou KMZ", importKML},
+	{"gpx", "Waypoints em GPX", importGPX},
+	{"shapefile", "Shapefile de pontos ou polígonos (.shp ou .zip)", importShapefile},
+	{"osm", "Localidades place=* de um extrato PBF do OpenStreetMap", importOSM},
+	{"snapshot", "Snapshot gravado por export -format=snapshot (recria a coleção)", importSnapshot},
+	{"ibge", "Vincular os códigos IBGE da DTB às localizações importadas", importIBGE},
+	{"cep", "CEPs do GeoNames (postal_codes BR.zip ou BR.txt)", importCEP},
+	{"limites", "Malha municipal do IBGE (GeoJSON, .shp ou .zip)", importLimites},
+}
+
+func runImportCommand(args []string) error {
+	return dispatch("import", importSources, args)
+}
+
+// importFlags são as flags comuns às fontes do import. As de execução
+// (lotes, quarentena, relatório, simulação...) só existem nas fontes que
+// passam pelo pipeline de importação.
+type importFlags struct {
+	storageFlags
+	timeout    time.Duration
+	batchSize  int
+	workers    int
+	resume     bool
+	quarantine string
+	report     string
+	dryRun     bool
+	diff       bool
+	collection string
+}
+
+func (f *importFlags) register(fs *flag.FlagSet, pipeline bool) {
+	f.storageFlags.register(fs)
+	fs.DurationVar(&f.timeout, "timeout", cfg.Import.Timeout, "Tempo máximo da importação (0 = sem limite)")
+	if !pipeline {
+		return
+	}
+	fs.IntVar(&f.batchSize, "batch-size", cfg.Import.BatchSize, "Registros por escrita em lote")
+	fs.IntVar(&f.workers, "workers", cfg.Import.Workers, "Escritas em lote concorrentes")
+	fs.BoolVar(&f.resume, "resume", false, "Continuar a última importação interrompida do mesmo arquivo a partir do último lote confirmado")
+	fs.StringVar(&f.quarantine, "quarantine", "", "Arquivo para gravar as linhas rejeitadas com o motivo (.tsv ou .ndjson)")
+	fs.StringVar(&f.report, "report", "", "Arquivo para gravar o relatório JSON da importação (- para stdout)")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "Apenas ler e validar o arquivo, sem gravar nada no banco")
+	fs.BoolVar(&f.diff, "diff", false, "Com -dry-run, comparar o arquivo com a coleção atual (novos, removidos, movidos, renomeados, população)")
+	fs.StringVar(&f.collection, "collection", "", "Coleção de destino (padrão: coleção principal)")
+}
+
+// options valida as flags e monta as opções da importação de source
+func (f *importFlags) options(source string) (domain.ImportOptions, error) {
+	if f.diff && !f.dryRun {
+		return domain.ImportOptions{}, usageErrorf("-diff só pode ser usado junto com -dry-run")
+	}
+	return domain.ImportOptions{
+		BatchSize:  f.batchSize,
+		Workers:    f.workers,
+		Resume:     f.resume,
+		DryRun:     f.dryRun,
+		Diff:       f.diff,
+		Collection: f.collection,
+		SourceName: source,
+	}, nil
+}
+
+// importFunc importa os dados lidos de r
+type importFunc func(ctx context.Context, app *bootstrap.Application, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error)
+
+// runFile abre path e, só então, conecta ao banco e executa a importação com
+// a quarentena e o relatório das flags. what nomeia a fonte nas mensagens de
+// erro (ex: "CSV"). Sem open, run recebe um reader nil e lê o arquivo pelo
+// caminho.
+func (f *importFlags) runFile(path, what string, opts domain.ImportOptions, open func(string) (io.ReadCloser, error), createIndexes bool, run importFunc) error {
+	var r io.Reader
+	if open != nil {
+		file, err := open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	app, closeDB, err := f.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(f.timeout)
+	defer cancel()
+
+	if !opts.DryRun && isMainCollection(opts.Collection) {
+		datasetModified(ctx, app)
+	}
+	err = runImport(opts, f.quarantine, f.report, func(opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return run(ctx, app, r, opts)
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao importar %s: %v", what, err)
+	}
+
+	if createIndexes && !opts.DryRun {
+		createIndexesLogged(ctx, app)
+	}
+	return nil
+}
+
+func openFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+	return file, nil
+}
+
+// createIndexesLogged cria os índices da coleção de localizações
+func createIndexesLogged(ctx context.Context, app *bootstrap.Application) {
+	log.Println("🔧 Criando índices...")
+	app.Service.CreateGeoIndex(ctx)
+	app.Service.CreateTextIndex(ctx)
+	app.Service.CreateIBGEIndex(ctx)
+	app.Service.CreateEstadoIndex(ctx)
+}
+
+// importGeoNames importa o BR.txt do GeoNames, de um arquivo local (.txt ou
+// .zip) ou baixando o BR.zip
+func importGeoNames(args []string) error {
+	fs := newFlagSet("import geonames [flags] [arquivo]",
+		"Sem arquivo, baixa o BR.zip do GeoNames, limpa a coleção e importa todos os dados (~5570 municípios);\n"+
+			"se o BR.zip não mudou desde a última importação, nada é feito. Com -collection, só essa coleção é\n"+
+			"limpa e o download é sempre importado. Com arquivo (BR.txt ou BR.zip),\n"+
+			"importa o arquivo local sem limpar a coleção.")
+	var f importFlags
+	f.register(fs, true)
+	tmpDir := fs.String("tmp-dir", cfg.Import.TmpDir, "Diretório para arquivos temporários de download (use um diretório persistente para retomar downloads e pular dados inalterados)")
+	sourceURL := fs.String("source-url", cfg.Import.GeoNamesURL, "URL do BR.zip (http(s):// ou file:// para um espelho local)")
+	sha256 := fs.String("sha256", "", "SHA-256 esperado do BR.zip (verificado após o download)")
+	size := fs.Int64("size", 0, "Tamanho esperado do BR.zip em bytes (verificado após o download)")
+	force := fs.Bool("force", false, "Baixar e importar novamente mesmo se o BR.zip não mudou")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		var downloadFlags []string
+		fs.Visit(func(fl *flag.Flag) {
+			switch fl.Name {
+			case "tmp-dir", "source-url", "sha256", "size", "force":
+				downloadFlags = append(downloadFlags, "-"+fl.Name)
+			}
+		})
+		if len(downloadFlags) > 0 {
+			return usageErrorf("%s: apenas para o download do BR.zip, não para um arquivo local", strings.Join(downloadFlags, ", "))
+		}
+
+		path := args[0]
+		opts, err := f.options(path)
+		if err != nil {
+			return err
+		}
+		log.Printf("📂 Importando arquivo: %s", path)
+		if opts.SourceSHA256, err = utils.FileSHA256(path); err != nil {
+			return err
+		}
+		err = f.runFile(path, "arquivo", opts, utils.OpenDataset, true, func(ctx context.Context, app *bootstrap.Application, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+			return app.Service.ImportData(ctx, r, opts)
+		})
+		if err == nil && !opts.DryRun {
+			log.Println("✅ Importação concluída com sucesso!")
+		}
+		return err
+	}
+
+	importOpts, err := f.options("")
+	if err != nil {
+		return err
+	}
+	app, closeDB, err := f.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(f.timeout)
+	defer cancel()
+
+	log.Println("🔄 Iniciando importação completa do GeoNames...")
+	downloadOpts := download.Options{
+		URL:            *sourceURL,
+		Dest:           filepath.Join(*tmpDir, "geonames-BR.zip"),
+		ExpectedSHA256: *sha256,
+		ExpectedSize:   *size,
+		Force:          *force,
+		Progress:       download.LogProgress("BR.zip"),
+	}
+	if err := importGeoNamesZip(ctx, app, downloadOpts, importOpts, f.quarantine, f.report); err != nil {
+		return err
+	}
+	if f.dryRun {
+		return nil
+	}
+
+	createIndexesLogged(ctx, app)
+	log.Println("✅ Importação completa concluída com sucesso!")
+	return nil
+}
+
+// importSample importa as 30 principais cidades dos dados de exemplo
+func importSample(args []string) error {
+	fs := newFlagSet("import sample [flags]", "Importa os dados de exemplo (30 principais cidades), útil para testes sem download.")
+	var f importFlags
+	f.register(fs, false)
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	app, closeDB, err := f.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(f.timeout)
+	defer cancel()
+
+	log.Println("📂 Importando dados de exemplo (30 principais cidades)")
+	datasetModified(ctx, app)
+	if err := app.Service.ImportBrazilianCitiesExampleTest(ctx); err != nil {
+		return fmt.Errorf("erro ao importar dados: %v", err)
+	}
+
+	createIndexesLogged(ctx, app)
+	log.Println("✅ Importação concluída com sucesso!")
+	return nil
+}
+
+// importCSV importa um CSV genérico de pontos
+func importCSV(args []string) error {
+	fs := newFlagSet("import csv [flags] <arquivo>", "Importa um CSV genérico de pontos (ex: cidade;uf;lat;lng) com o mapeamento de colunas de -map.")
+	var f importFlags
+	f.register(fs, true)
+	mapFlag := fs.String("map", "", "Colunas de cada campo, ex: municipio=cidade,estado=uf,populacao=pop,codigo_ibge=ibge,lat=lat,lon=lng")
+	delimiterFlag := fs.String("delimiter", "", "Separador de colunas (padrão: detectar entre ; , e tab; use tab para tabulação)")
+	noHeader := fs.Bool("no-header", false, "O CSV não tem header; no -map as colunas são indicadas pela posição (1, 2, ...)")
+	decimalComma := fs.Bool("decimal-comma", false, "Coordenadas com vírgula decimal (-23,5505)")
+	encoding := fs.String("encoding", domain.EncodingAuto, "Codificação: auto, utf-8 ou latin1")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	mapping, err := parseColumnMapping(*mapFlag)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	delimiter, err := parseDelimiter(*delimiterFlag)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	opts, err := f.options(args[0])
+	if err != nil {
+		return err
+	}
+
+	csvOpts := domain.CSVOptions{
+		Delimiter:    delimiter,
+		NoHeader:     *noHeader,
+		DecimalComma: *decimalComma,
+		Encoding:     *encoding,
+		Mapping:      mapping,
+	}
+	log.Printf("📄 Importando CSV: %s", args[0])
+	return f.runFile(args[0], "CSV", opts, utils.OpenDataset, false, func(ctx context.Context, app *bootstrap.Application, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportCSV(ctx, r, csvOpts, opts)
+	})
+}
+
+// mappedImport monta uma fonte de pontos com mapeamento de propriedades
+// (GeoJSON, KML, GPX)
+func mappedImport(synopsis, description, what, emoji string, open func(string) (io.ReadCloser, error),
+	run func(ctx context.Context, app *bootstrap.Application, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error)) func([]string) error {
+	return func(args []string) error {
+		fs := newFlagSet(synopsis, description)
+		var f importFlags
+		f.register(fs, true)
+		mapFlag := fs.String("map", "", "Propriedades de cada campo, ex: municipio=nome,estado=uf,populacao=pop,codigo_ibge=ibge")
+
+		args, err := parseFlags(fs, args)
+		if err != nil {
+			return err
+		}
+		if err := expectArgs(fs, args, 1, 1); err != nil {
+			return err
+		}
+		mapping, err := parseColumnMapping(*mapFlag)
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+		opts, err := f.options(args[0])
+		if err != nil {
+			return err
+		}
+
+		log.Printf("%s Importando %s: %s", emoji, what, args[0])
+		return f.runFile(args[0], what, opts, open, false, func(ctx context.Context, app *bootstrap.Application, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+			return run(ctx, app, r, mapping, opts)
+		})
+	}
+}
+
+var importGeoJSON = mappedImport("import geojson [flags] <arquivo>",
+	"Importa pontos de uma FeatureCollection GeoJSON ou de NDJSON (uma Feature por linha) com o mapeamento de -map.",
+	"GeoJSON", "🧭", openFile,
+	func(ctx context.Context, app *bootstrap.Application, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportGeoJSON(ctx, r, mapping, opts)
+	})
+
+var importKML = mappedImport("import kml [flags] <arquivo>",
+	"Importa os placemarks de ponto de um KML ou KMZ com o mapeamento de -map (padrão: nome do placemark).",
+	"KML", "🗺️", openKML,
+	func(ctx context.Context, app *bootstrap.Application, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportKML(ctx, r, mapping, opts)
+	})
+
+var importGPX = mappedImport("import gpx [flags] <arquivo>",
+	"Importa os waypoints de um GPX com o mapeamento de -map (padrão: nome do waypoint).",
+	"GPX", "📍", openFile,
+	func(ctx context.Context, app *bootstrap.Application, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportGPX(ctx, r, mapping, opts)
+	})
+
+// openKML abre um .kml ou o documento principal de um .kmz
+func openKML(path string) (io.ReadCloser, error) {
+	if strings.EqualFold(filepath.Ext(path), ".kmz") {
+		return kml.OpenKMZ(path)
+	}
+	return openFile(path)
+}
+
+// importShapefile importa uma camada de pontos ou polígonos
+func importShapefile(args []string) error {
+	fs := newFlagSet("import shapefile [flags] <arquivo>", "Importa uma camada de pontos ou polígonos (.shp ou .zip) com o mapeamento de atributos de -map.")
+	var f importFlags
+	f.register(fs, true)
+	mapFlag := fs.String("map", "", "Atributos de cada campo, ex: municipio=NM_MUN,estado=SIGLA_UF,populacao=POP,codigo_ibge=CD_MUN")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	mapping, err := parseColumnMapping(*mapFlag)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	path := args[0]
+	opts, err := f.options(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+
+	log.Printf("🗺️ Importando shapefile: %s", path)
+	// O shapefile é lido pelo caminho (.shp com .dbf ou .zip), não por um reader
+	return f.runFile(path, "shapefile", opts, nil, true, func(ctx context.Context, app *bootstrap.Application, _ io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportShapefile(ctx, path, mapping, opts)
+	})
+}
+
+// importOSM importa as localidades de um extrato PBF do OpenStreetMap
+func importOSM(args []string) error {
+	fs := newFlagSet("import osm [flags] <arquivo>", "Importa os nós place=* de um extrato PBF do OpenStreetMap (ex: brazil-latest.osm.pbf).")
+	var f importFlags
+	f.register(fs, true)
+	placesFlag := fs.String("places", "", "Valores de place=* importados, separados por vírgula (padrão: city,town,village)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	opts, err := f.options(args[0])
+	if err != nil {
+		return err
+	}
+
+	var places []string
+	if *placesFlag != "" {
+		places = strings.Split(*placesFlag, ",")
+	}
+
+	log.Printf("🗺️ Importando extrato OSM: %s", args[0])
+	return f.runFile(args[0], "extrato OSM", opts, openFile, false, func(ctx context.Context, app *bootstrap.Application, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportOSM(ctx, r, places, opts)
+	})
+}
+
+// importSnapshot recarrega um snapshot gravado pelo export
+func importSnapshot(args []string) error {
+	fs := newFlagSet("import snapshot [flags] <arquivo>",
+		"Recria a coleção com os documentos de um snapshot (export -format=snapshot), inclusive os _id.\n"+
+			"O arquivo é conferido por inteiro antes de a coleção ser apagada.")
+	var f importFlags
+	f.register(fs, false)
+	collection := fs.String("collection", "", "Coleção recriada (padrão: coleção principal)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	file, err := openFile(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	app, closeDB, err := f.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(f.timeout)
+	defer cancel()
+
+	log.Printf("📦 Recarregando snapshot: %s", args[0])
+	header, err := app.Exportacao.RestoreSnapshot(ctx, file, *collection)
+	if err != nil {
+		return fmt.Errorf("erro ao importar snapshot: %v", err)
+	}
+	if isMainCollection(*collection) {
+		datasetModified(ctx, app)
+	}
+	log.Printf("✅ %d localizações recarregadas (snapshot de %s)", header.Total, header.ExportadoEm.Format(time.RFC3339))
+	return nil
+}
+
+// simpleImport monta uma fonte que lê apenas o arquivo, sem as flags do
+// pipeline de importação (IBGE, CEP, limites)
+func simpleImport(synopsis, description string, run func(ctx context.Context, app *bootstrap.Application, path string) error) func([]string) error {
+	return func(args []string) error {
+		fs := newFlagSet(synopsis, description)
+		var f importFlags
+		f.register(fs, false)
+
+		args, err := parseFlags(fs, args)
+		if err != nil {
+			return err
+		}
+		if err := expectArgs(fs, args, 1, 1); err != nil {
+			return err
+		}
+		if _, err := os.Stat(args[0]); err != nil {
+			return fmt.Errorf("erro ao abrir arquivo: %v", err)
+		}
+
+		app, closeDB, err := f.connect()
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		ctx, cancel := withTimeout(f.timeout)
+		defer cancel()
+		return run(ctx, app, args[0])
+	}
+}
+
+var importIBGE = simpleImport("import ibge [flags] <arquivo>",
+	"Vincula os códigos IBGE da tabela DTB (CSV) às localizações importadas.",
+	func(ctx context.Context, app *bootstrap.Application, path string) error {
+		log.Printf("🔗 Vinculando códigos IBGE de %s...", path)
+		datasetModified(ctx, app)
+		if err := app.Service.ImportIBGEMunicipios(ctx, path); err != nil {
+			return fmt.Errorf("erro ao importar códigos IBGE: %v", err)
+		}
+		return app.Service.CreateIBGEIndex(ctx)
+	})
+
+var importCEP = simpleImport("import cep [flags] <arquivo>",
+	"Importa o arquivo de CEPs do GeoNames (postal_codes/BR.zip ou BR.txt).",
+	func(ctx context.Context, app *bootstrap.Application, path string) error {
+		log.Printf("📮 Importando CEPs de %s...", path)
+		dataset, err := utils.OpenDataset(path)
+		if err != nil {
+			return fmt.Errorf("erro ao abrir arquivo: %v", err)
+		}
+		defer dataset.Close()
+		if err := app.CEP.ImportPostalCodes(ctx, dataset); err != nil {
+			return fmt.Errorf("erro ao importar CEPs: %v", err)
+		}
+		return nil
+	})
+
+var importLimites = simpleImport("import limites [flags] <arquivo>",
+	"Importa a malha municipal do IBGE (GeoJSON, .shp ou .zip com o shapefile), substituindo os limites existentes.",
+	func(ctx context.Context, app *bootstrap.Application, path string) error {
+		log.Printf("🗺️ Importando limites municipais de %s...", path)
+		if err := importBoundaries(ctx, app, path); err != nil {
+			return fmt.Errorf("erro ao importar limites: %v", err)
+		}
+		return nil
+	})
+
+// importGeoNamesZip baixa o BR.zip para opts.Dest e importa BR.txt lendo direto
+// do arquivo compactado, sem escrever nada no diretório de trabalho. Se a origem
+// não mudou desde a última importação, nada é alterado. O zip é removido ao
+// final; apenas os metadados do download ficam para a próxima requisição
+// condicional (ou o .part, para retomar um download interrompido).
+//
+// Com -collection, só a coleção informada é limpa e recebe os dados; a
+// principal e o snapshot ativo não mudam. Nesse caso e em modo de simulação o
+// download é sempre refeito e seus metadados descartados, já que eles
+// descrevem o que está na coleção principal: uma importação real seguinte
+// não é pulada.
+func importGeoNamesZip(ctx context.Context, app *bootstrap.Application, opts download.Options, importOpts domain.ImportOptions, quarantinePath, reportPath string) error {
+	mainCollection := isMainCollection(importOpts.Collection)
+	if importOpts.DryRun || !mainCollection {
+		opts.Force = true
+	}
+
+	log.Printf("📥 Baixando BR.zip de %s...", opts.URL)
+	result, err := download.Fetch(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("erro ao baixar arquivo: %w", err)
+	}
+
+	if result.NotModified {
+		log.Println("⏭️ BR.zip não mudou desde a última importação, nada a fazer (use -force para reimportar)")
+		return nil
+	}
+	defer os.Remove(result.Path)
+
+	if result.Resumed {
+		log.Println("↪️ Download retomado de onde parou")
+	}
+	log.Printf("✅ Download concluído! %d bytes, sha256 %s", result.Size, result.SHA256)
+
+	// Se a importação falhar, os metadados são descartados para que a próxima
+	// execução não considere os dados como já importados
+	imported := false
+	defer func() {
+		if !imported {
+			download.RemoveMetadata(opts.Dest)
+		}
+	}()
+
+	importOpts.SourceName = opts.URL
+	importOpts.SourceSHA256 = result.SHA256
+
+	// Ao retomar uma importação interrompida do mesmo arquivo a coleção é mantida
+	resumable := false
+	if importOpts.Resume && !importOpts.DryRun {
+		run, err := app.Service.FindResumableRun(ctx, result.SHA256)
+		if err != nil {
+			return err
+		}
+		resumable = run != nil
+	}
+
+	if importOpts.DryRun {
+		log.Println("🧪 Modo de simulação: a coleção não será alterada")
+	} else if mainCollection {
+		datasetModified(ctx, app)
+	}
+	if !importOpts.DryRun && !resumable {
+		log.Println("🧹 Limpando coleção antes da importação completa...")
+		if err := app.Service.ResetCollection(ctx, importOpts.Collection); err != nil {
+			return fmt.Errorf("erro ao limpar coleção: %w", err)
+		}
+	}
+
+	dataset, err := utils.OpenZipEntry(result.Path, "BR.txt")
+	if err != nil {
+		return err
+	}
+	defer dataset.Close()
+
+	log.Println("📂 Importando dados de BR.txt (~5570 municípios) aguarde...")
+	err = runImport(importOpts, quarantinePath, reportPath, func(opts domain.ImportOptions) (*domain.ImportReport, error) {
+		return app.Service.ImportData(ctx, dataset, opts)
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao importar dados: %w", err)
+	}
+
+	imported = !importOpts.DryRun && mainCollection
+	return nil
+}
+
+// runImport executa uma importação (ImportData, ImportShapefile...) gravando as
+// linhas rejeitadas em quarantinePath e o relatório JSON em reportPath ("-"
+// para stdout), quando informados. O relatório é gravado também quando a
+// importação falha.
+func runImport(opts domain.ImportOptions, quarantinePath, reportPath string, run func(domain.ImportOptions) (*domain.ImportReport, error)) (err error) {
+	if quarantinePath != "" {
+		quarantine, err := services.NewQuarantineFile(quarantinePath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := quarantine.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		opts.Quarantine = quarantine.Write
+	}
+
+	report, err := run(opts)
+
+	if reportPath != "" && report != nil {
+		if writeErr := writeJSON(reportPath, report); writeErr != nil {
+			log.Printf("⚠️ Erro ao gravar relatório: %v", writeErr)
+			if err == nil {
+				err = writeErr
+			}
+		}
+	}
+
+	return err
+}
+
+// importBoundaries importa a malha municipal de um GeoJSON ou de um shapefile
+// (.shp ou .zip), conforme a extensão do arquivo
+func importBoundaries(ctx context.Context, app *bootstrap.Application, path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".shp", ".zip":
+		return app.Limites.ImportBoundariesShapefile(ctx, path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+	defer file.Close()
+	return app.Limites.ImportBoundaries(ctx, file)
+}
+
+// parseColumnMapping lê o mapeamento de colunas de -map no formato
+// campo=COLUNA separado por vírgulas
+func parseColumnMapping(value string) (domain.ColumnMapping, error) {
+	var mapping domain.ColumnMapping
+	if strings.TrimSpace(value) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || column == "" {
+			return mapping, fmt.Errorf("mapeamento inválido %q: use campo=COLUNA", pair)
+		}
+
+		switch strings.ToLower(field) {
+		case "municipio":
+			mapping.Municipio = column
+		case "estado", "uf":
+			mapping.Estado = column
+		case "populacao":
+			mapping.Populacao = column
+		case "codigo_ibge", "ibge":
+			mapping.CodigoIBGE = column
+		case "lat", "latitude":
+			mapping.Latitude = column
+		case "lon", "lng", "longitude":
+			mapping.Longitude = column
+		default:
+			return mapping, fmt.Errorf("campo %q desconhecido no mapeamento (use municipio, estado, populacao, codigo_ibge, lat ou lon)", field)
+		}
+	}
+	return mapping, nil
+}
+
+// parseDelimiter lê o separador de -delimiter; vazio detecta pelo arquivo
+func parseDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "tab", "\\t":
+		return '\t', nil
+	}
+
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\n' || runes[0] == '\r' {
+		return 0, fmt.Errorf("separador inválido %q: use um único caractere ou tab", value)
+	}
+	return runes[0], nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// runIndexes cria os índices da coleção de localizações
+func runIndexes(args []string) error {
+	fs := newFlagSet("indexes [flags]", "Cria os índices geoespacial, de texto, de código IBGE e de UF da coleção de localizações.")
+	var storage storageFlags
+	storage.register(fs)
+	timeout := fs.Duration("timeout", 5*time.Minute, "Tempo máximo da criação dos índices (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	log.Println("🔧 Criando índices...")
+	if err := app.Service.CreateGeoIndex(ctx); err != nil {
+		return fmt.Errorf("erro ao criar índice geoespacial: %v", err)
+	}
+	if err := app.Service.CreateTextIndex(ctx); err != nil {
+		return fmt.Errorf("erro ao criar índice de texto: %v", err)
+	}
+	if err := app.Service.CreateIBGEIndex(ctx); err != nil {
+		return fmt.Errorf("erro ao criar índice do código IBGE: %v", err)
+	}
+	if err := app.Service.CreateEstadoIndex(ctx); err != nil {
+		return fmt.Errorf("erro ao criar índices de UF: %v", err)
+	}
+	log.Println("✅ Índices criados com sucesso!")
+	return nil
+}
@@ -2,167 +2,378 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Kaguyo/Geolocation-Brasil/internal/bootstrap"
-	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/config"
 )
 
+// programName é o nome do binário usado na ajuda
+const programName = "geolocation-api"
+
+// Códigos de saída dos subcomandos
 const (
-	DefaultMongoURI   = "mongodb://localhost:27017"
-	DefaultDBName     = "geolocalizacao_br"
-	DefaultCollection = "localizacoes"
-	DefaultPort       = "8080"
-	GeoNamesURL       = "http://download.geonames.org/export/dump/BR.zip"
+	exitOK       = 0
+	exitFailure  = 1 // erro durante a execução
+	exitUsage    = 2 // subcomando, flag ou argumento inválido
+	exitStorage  = 3 // falha ao conectar ao MongoDB
+	exitNotFound = 4 // consulta sem resultado ou snapshot inexistente
 )
 
-func main() {
-	importFlag := flag.Bool("import", false, "Importar dados de exemplo (30 principais cidades)")
-	importFileFlag := flag.String("file", "", "Arquivo CSV para importar (formato GeoNames)")
-	importAllFlag := flag.Bool("importall", false, "Baixar BR.zip do GeoNames, descompactar e importar todos os dados (~5570 municípios)")
-	serveFlag := flag.Bool("serve", false, "Iniciar servidor API")
-	portFlag := flag.String("port", DefaultPort, "Porta do servidor")
-	mongoURIFlag := flag.String("mongo-uri", DefaultMongoURI, "URI de conexão do MongoDB")
+// command é um subcomando (ou fonte do import) com sua ajuda
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "Iniciar o servidor da API", runServe},
+	{"import", "Importar localizações, códigos IBGE, CEPs ou limites municipais", runImportCommand},
+	{"conflate", "Conflacionar as coleções de várias fontes em uma coleção canônica", runConflate},
+	{"query", "Consultar por nome, proximidade ou coordenada, sem o servidor", runQuery},
+	{"export", "Exportar a coleção ou parte dela (GeoNames, CSV, GeoJSON, NDJSON, snapshot)", runExport},
+	{"snapshot", "Criar, listar, ativar e remover versões nomeadas da coleção", runSnapshotCommand},
+	{"stats", "Mostrar os totais importados por UF", runStats},
+	{"indexes", "Criar os índices da coleção de localizações", runIndexes},
+	{"audit", "Auditar a qualidade dos dados da coleção", runAudit},
+	{"config", "Mostrar a configuração efetiva (padrões, arquivo, ambiente)", runConfigCommand},
+}
+
+// cfg é a configuração carregada em run (padrões, arquivo e ambiente); as
+// flags de cada comando sobrepõem os seus valores
+var cfg *config.Config
 
-	flag.Parse()
+// cfgErr é o erro ao carregar a configuração. Ele só é informado quando um
+// comando usa a configuração (checkConfig), para que a ajuda funcione mesmo
+// com um arquivo ou variável de ambiente inválidos; até lá, cfg tem os padrões.
+var cfgErr error
 
-	// 🔹 Bootstrap da aplicação
-	app, err := bootstrap.Build(*mongoURIFlag, DefaultDBName, DefaultCollection)
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run executa o subcomando de args e retorna o código de saída
+func run(args []string) int {
+	configFile, args, err := extractConfigFlag(args)
 	if err != nil {
-		log.Fatalf("❌ Erro ao conectar ao MongoDB: %v", err)
+		return exitCode(err)
+	}
+	if cfg, cfgErr = config.Load(configFile, os.LookupEnv); cfgErr != nil {
+		cfg = config.Default()
+	}
+	if cfg.Logging.File != "" {
+		logFile, err := os.OpenFile(cfg.Logging.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return exitCode(fmt.Errorf("erro ao abrir arquivo de log: %v", err))
+		}
+		defer logFile.Close()
+		log.SetOutput(io.MultiWriter(os.Stderr, logFile))
 	}
 
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		app.DB.Close(ctx)
-	}()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
+	if len(args) == 0 {
+		printUsage(os.Stderr, "", commands)
+		return exitUsage
+	}
 
-	if *importAllFlag {
-		log.Println("🧹 Limpando coleção antes da importação completa...")
-		if err := app.Service.ResetCollection(ctx, DefaultCollection); err != nil {
-			log.Fatalf("Erro ao limpar coleção: %v", err)
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			return exitCode(dispatch("", commands, append(args[1:], "-h")))
 		}
+		printUsage(os.Stdout, "", commands)
+		return exitOK
+	}
 
-		log.Println("🔄 Iniciando importação completa do GeoNames...")
+	if strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "❌ As flags %s foram substituídas por subcomandos (ex: %s import geonames, %s serve)\n\n", args[0], programName, programName)
+		printUsage(os.Stderr, "", commands)
+		return exitUsage
+	}
 
-		zipFile := "BR.zip"
-		extractedFile := "BR.txt"
+	return exitCode(dispatch("", commands, args))
+}
 
-		// Download
-		log.Printf("📥 Baixando %s do GeoNames...", zipFile)
-		if err := utils.DownloadFile(GeoNamesURL, zipFile); err != nil {
-			log.Fatalf("❌ Erro ao baixar arquivo: %v", err)
+// extractConfigFlag retira de args a flag global -config <arquivo>, aceita em
+// qualquer posição antes de "--"
+func extractConfigFlag(args []string) (string, []string, error) {
+	path := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			rest = append(rest, arg)
+			continue
 		}
-		log.Println("✅ Download concluído!")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, usageErrorf("a flag -config precisa de um arquivo")
+			}
+			i++
+			value = args[i]
+		}
+		path = value
+	}
+	return path, rest, nil
+}
 
-		// Extract
-		log.Println("📦 Descompactando arquivo...")
-		if err := utils.UnzipFile(zipFile); err != nil {
-			log.Fatalf("❌ Erro ao descompactar: %v", err)
+// dispatch executa o comando de args[0] entre cmds; prefix é o comando pai
+// (ex: "import") usado nas mensagens
+func dispatch(prefix string, cmds []command, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		out := os.Stderr
+		if len(args) > 0 {
+			out = os.Stdout
+		}
+		printUsage(out, prefix, cmds)
+		if len(args) > 0 {
+			return flag.ErrHelp
 		}
-		log.Println("✅ Descompactação concluída!")
+		return &cliError{code: exitUsage, quiet: true}
+	}
 
-		// Import
-		log.Printf("📂 Importando dados de %s (~5570 municípios) aguarde...", extractedFile)
-		if err := app.Service.ImportData(ctx, extractedFile); err != nil {
-			log.Fatalf("❌ Erro ao importar dados: %v", err)
+	for _, cmd := range cmds {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
 		}
+	}
 
-		// Create indices
-		log.Println("🔧 Criando índices...")
-		app.Service.CreateGeoIndex(ctx)
-		app.Service.CreateTextIndex(ctx)
+	printUsage(os.Stderr, prefix, cmds)
+	return usageErrorf("comando desconhecido: %s", strings.TrimSpace(prefix+" "+args[0]))
+}
 
-		log.Println("✅ Importação completa concluída com sucesso!")
+// printUsage lista os comandos de cmds
+func printUsage(out *os.File, prefix string, cmds []command) {
+	// As fontes do import recebem um arquivo; os demais grupos, só flags
+	noun, title, operand := "comando", "Comandos:", ""
+	if prefix == "import" {
+		noun, title, operand = "fonte", "Fontes:", " [arquivo]"
+	}
 
-		if !*serveFlag {
-			return
-		}
+	if prefix == "" {
+		fmt.Fprintln(out, "🌎 API de Geolocalização - Brasil")
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Uso: %s <comando> [flags]\n", programName)
+	} else {
+		fmt.Fprintf(out, "Uso: %s %s <%s> [flags]%s\n", programName, prefix, noun, operand)
 	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, title)
+	for _, cmd := range cmds {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out)
+	if prefix == "" {
+		fmt.Fprintf(out, "Use \"%s help <comando>\" para as flags de cada comando.\n", programName)
+	} else {
+		fmt.Fprintf(out, "Use \"%s help %s <%s>\" para as flags de cada %s.\n", programName, prefix, noun, noun)
+	}
+	fmt.Fprintln(out)
+	if prefix == "" {
+		fmt.Fprintf(out, "Configuração: -config <arquivo> (ou %s, ou %s no diretório atual), variáveis GEO_*\n", config.ConfigEnv, config.DefaultFiles[0])
+		fmt.Fprintf(out, "e flags; veja \"%s config print\".\n", programName)
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "Códigos de saída: %d sucesso, %d erro, %d uso inválido, %d falha ao conectar ao MongoDB, %d não encontrado\n", exitOK, exitFailure, exitUsage, exitStorage, exitNotFound)
+}
 
-	if *importFlag {
-		log.Println("🔄 Iniciando importação de dados...")
+// cliError é um erro com o código de saída do processo. Com quiet, a mensagem
+// já foi exibida (ex: flag inválida, que o pacote flag reporta).
+type cliError struct {
+	code  int
+	err   error
+	quiet bool
+}
 
-		if *importFileFlag != "" {
-			log.Printf("📂 Importando arquivo: %s", *importFileFlag)
-			if err := app.Service.ImportData(ctx, *importFileFlag); err != nil {
-				log.Fatalf("❌ Erro ao importar arquivo: %v", err)
-			}
-		} else {
-			log.Println("📂 Importando dados de exemplo (30 principais cidades)")
-			if err := app.Service.ImportBrazilianCitiesExampleTest(ctx); err != nil {
-				log.Fatalf("❌ Erro ao importar dados: %v", err)
-			}
-		}
+func (e *cliError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("código de saída %d", e.code)
+	}
+	return e.err.Error()
+}
 
-		log.Println("🔧 Criando índices...")
-		app.Service.CreateGeoIndex(ctx)
-		app.Service.CreateTextIndex(ctx)
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// usageErrorf é um erro de uso (flag ou argumento inválido)
+func usageErrorf(format string, args ...interface{}) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
 
-		log.Println("✅ Importação concluída com sucesso!")
+// exitCode exibe err e retorna o código de saída correspondente
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
 
-		if !*serveFlag {
-			return
+	var cliErr *cliError
+	if errors.As(err, &cliErr) {
+		if cliErr.err != nil && !cliErr.quiet {
+			log.Printf("❌ %v", cliErr.err)
 		}
+		return cliErr.code
 	}
 
-	if *serveFlag {
+	log.Printf("❌ %v", err)
+	return exitFailure
+}
 
-		// Only create indices if they don't exist (server should run with pre-imported data)
-		app.Service.CreateGeoIndex(ctx)
-		app.Service.CreateTextIndex(ctx)
+// newFlagSet cria as flags de um subcomando com a ajuda "Uso: ..."
+func newFlagSet(synopsis, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(synopsis, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Uso: %s %s\n\n%s\n", programName, synopsis, description)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "Flags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
 
-		srv := &http.Server{
-			Addr:         ":" + *portFlag,
-			Handler:      app.Router,
-			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
-			IdleTimeout:  60 * time.Second,
+// parseFlags lê as flags de args, que podem vir antes ou depois dos
+// argumentos, e retorna os argumentos. Números negativos (ex: -23.55) são
+// argumentos, e tudo após "--" também.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			positional = append(positional, args[1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" || isNumber(arg) {
+			positional = append(positional, arg)
+			args = args[1:]
+			continue
 		}
 
-		done := make(chan os.Signal, 1)
-		signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		// Uma flag por vez, com o valor separado quando ela não é booleana
+		n := 1
+		name := strings.TrimLeft(arg, "-")
+		if !strings.Contains(name, "=") && len(args) > 1 {
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) {
+				n = 2
+			}
+		}
+		if err := fs.Parse(args[:n]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &cliError{code: exitUsage, err: err, quiet: true}
+		}
+		args = args[n:]
+	}
+	return positional, nil
+}
 
-		go func() {
-			log.Printf("🚀 Servidor iniciado na porta %s", *portFlag)
-			log.Printf("📍 Endpoints disponíveis:")
-			log.Printf("   GET /health")
-			log.Printf("   GET /location/{municipio}?estado=XX")
-			log.Printf("   GET /nearby?lat=XX&lon=YY&distance=50")
-			log.Println()
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
 
-			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				log.Fatalf("❌ Erro ao iniciar servidor: %v", err)
-			}
-		}()
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
 
-		<-done
-		log.Println("🛑 Encerrando servidor...")
+// expectArgs valida o número de argumentos de um subcomando
+func expectArgs(fs *flag.FlagSet, args []string, min, max int) error {
+	if len(args) >= min && (max < 0 || len(args) <= max) {
+		return nil
+	}
+	fs.Usage()
+	if len(args) < min {
+		return usageErrorf("argumentos insuficientes (uso: %s %s)", programName, fs.Name())
+	}
+	return usageErrorf("argumentos inesperados: %s", strings.Join(args[max:], " "))
+}
 
-		ctxShutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// storageFlags são as flags de conexão dos subcomandos que usam o MongoDB,
+// com os padrões da configuração
+type storageFlags struct {
+	storage config.StorageConfig
+}
+
+func (s *storageFlags) register(fs *flag.FlagSet) {
+	s.storage = cfg.Storage
+	fs.StringVar(&s.storage.MongoURI, "mongo-uri", s.storage.MongoURI, "URI de conexão do MongoDB")
+	fs.StringVar(&s.storage.Database, "database", s.storage.Database, "Banco de dados do MongoDB")
+	// A ajuda não deve expor a senha da URI configurada
+	fs.Lookup("mongo-uri").DefValue = config.RedactURI(s.storage.MongoURI)
+}
+
+// checkConfig confere a configuração efetiva de um comando (cfg com as flags
+// dele aplicadas), incluindo o erro ao carregá-la, se houve
+func checkConfig(c *config.Config) error {
+	if cfgErr != nil {
+		return usageErrorf("%v", cfgErr)
+	}
+	if err := c.Validate(); err != nil {
+		return usageErrorf("%v", err)
+	}
+	return nil
+}
+
+// connect monta a aplicação; só é chamado depois que as flags e os arquivos de
+// entrada foram validados. O retorno close encerra a conexão.
+func (s *storageFlags) connect() (*bootstrap.Application, func(), error) {
+	effective := *cfg
+	effective.Storage = s.storage
+	if err := checkConfig(&effective); err != nil {
+		return nil, nil, err
+	}
+
+	app, err := bootstrap.Build(&effective)
+	if err != nil {
+		return nil, nil, &cliError{code: exitStorage, err: fmt.Errorf("erro ao conectar ao MongoDB: %v", err)}
+	}
+
+	return app, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
+		app.DB.Close(ctx)
+	}, nil
+}
 
-		if err := srv.Shutdown(ctxShutdown); err != nil {
-			log.Fatalf("❌ Erro ao encerrar servidor: %v", err)
-		}
+// withTimeout retorna o contexto das operações; 0 = sem limite
+func withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
 
-		log.Println("✅ Servidor encerrado com sucesso!")
-		return
+// writeJSON grava v como JSON indentado em path ("-" para stdout)
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
 	}
+	data = append(data, '\n')
 
-	if !*importFlag && !*importAllFlag && !*serveFlag {
-		log.Println("🌎 API de Geolocalização - Brasil")
-		log.Println("🟡 Inicializado em modo de teste. Use as flags para importar dados ou iniciar o servidor.")
-		flag.PrintDefaults()
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
 	}
+	return os.WriteFile(path, data, 0644)
 }
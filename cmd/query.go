@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/bootstrap"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Tipos de consulta do comando query
+const (
+	queryName    = "name"
+	queryNear    = "near"
+	queryReverse = "reverse"
+	queryBatch   = "batch"
+)
+
+// queryParams são as flags de uma consulta. Em query batch, as informadas no
+// comando são o padrão de cada linha.
+type queryParams struct {
+	uf    string
+	km    float64
+	limit int
+	mode  string
+}
+
+func (p *queryParams) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.uf, "uf", p.uf, "UF do município em name (sigla, nome ou código IBGE)")
+	fs.Float64Var(&p.km, "km", p.km, "Raio da busca em near, em km")
+	fs.IntVar(&p.limit, "limit", p.limit, "Máximo de resultados em near (0 = todos)")
+	fs.StringVar(&p.mode, "modo", p.mode, "Método em reverse: auto, poligono ou proximidade")
+}
+
+// queryFlagKinds indica a consulta em que cada flag de queryParams se aplica
+var queryFlagKinds = map[string]string{
+	"uf":    queryName,
+	"km":    queryNear,
+	"limit": queryNear,
+	"modo":  queryReverse,
+}
+
+// query é uma consulta validada
+type query struct {
+	// text é a consulta como informada, repetida na saída de query batch
+	text     string
+	kind     string
+	params   queryParams
+	name     string
+	lat, lon float64
+}
+
+// queryResult é uma linha da saída do query
+type queryResult struct {
+	Consulta    string   `json:"consulta,omitempty"`
+	Municipio   string   `json:"municipio,omitempty"`
+	Estado      string   `json:"estado,omitempty"`
+	CodigoIBGE  string   `json:"codigo_ibge,omitempty"`
+	Latitude    float64  `json:"latitude,omitempty"`
+	Longitude   float64  `json:"longitude,omitempty"`
+	Populacao   int      `json:"populacao,omitempty"`
+	DistanciaKm *float64 `json:"distancia_km,omitempty"`
+	Metodo      string   `json:"metodo,omitempty"`
+	Erro        string   `json:"erro,omitempty"`
+}
+
+// runQuery consulta a base sem passar pela API
+func runQuery(args []string) error {
+	fs := newFlagSet("query <name|near|reverse|batch> [flags] [argumentos]",
+		"Consulta a base direto no MongoDB, sem o servidor:\n\n"+
+			"  query name <município> [-uf SP]\n"+
+			"  query near <lat> <lon> [-km 50] [-limit 10]\n"+
+			"  query reverse <lat> <lon> [-modo auto|poligono|proximidade]\n"+
+			"  query batch < consultas.txt\n\n"+
+			"Em batch, cada linha da entrada padrão é uma consulta (ex: near -23.55 -46.63 -km 30);\n"+
+			"linhas vazias ou iniciadas por # são ignoradas, e as flags do comando valem como padrão.\n"+
+			"Sem resultado, name, near e reverse terminam com o código 4.")
+	var storage storageFlags
+	storage.register(fs)
+	params := queryParams{km: 50, mode: domain.ReverseAuto}
+	params.register(fs)
+	format := fs.String("format", "table", "Formato da saída: table, json ou csv")
+	timeout := fs.Duration("timeout", 30*time.Second, "Tempo máximo de cada consulta (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, -1); err != nil {
+		return err
+	}
+	switch *format {
+	case "table", "json", "csv":
+	default:
+		return usageErrorf("-format inválido: use table, json ou csv")
+	}
+
+	var queries []query
+	batch := args[0] == queryBatch
+	if batch {
+		if err := expectArgs(fs, args, 1, 1); err != nil {
+			return err
+		}
+		if queries, err = readQueries(os.Stdin, params); err != nil {
+			return err
+		}
+	} else {
+		q, err := parseQuery(args[0], args[1:], params, visited(fs))
+		if err != nil {
+			fs.Usage()
+			return usageErrorf("%v", err)
+		}
+		queries = []query{q}
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var results []queryResult
+	for _, q := range queries {
+		ctx, cancel := withTimeout(*timeout)
+		rows, err := q.run(ctx, app)
+		cancel()
+
+		if err != nil {
+			if !batch {
+				return fmt.Errorf("erro na consulta: %v", err)
+			}
+			rows = []queryResult{{Erro: err.Error()}}
+		} else if len(rows) == 0 {
+			if !batch {
+				return &cliError{code: exitNotFound, err: fmt.Errorf("nenhum resultado para: %s", q.text)}
+			}
+			rows = []queryResult{{Erro: "nenhum resultado"}}
+		}
+
+		if batch {
+			for i := range rows {
+				rows[i].Consulta = q.text
+			}
+		}
+		results = append(results, rows...)
+	}
+
+	return writeQueryResults(os.Stdout, *format, results)
+}
+
+// readQueries lê as consultas de query batch, uma por linha, validando todas
+// antes de consultar
+func readQueries(r io.Reader, defaults queryParams) ([]query, error) {
+	var queries []query
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitQueryLine(line)
+		if err != nil {
+			return nil, usageErrorf("linha %d: %v", n, err)
+		}
+
+		fs := flag.NewFlagSet("query", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		params := defaults
+		params.register(fs)
+		args, err := parseFlags(fs, fields)
+		if err != nil {
+			return nil, usageErrorf("linha %d: %v", n, err)
+		}
+		if len(args) == 0 {
+			return nil, usageErrorf("linha %d: consulta vazia", n)
+		}
+
+		q, err := parseQuery(args[0], args[1:], params, visited(fs))
+		if err != nil {
+			return nil, usageErrorf("linha %d: %v", n, err)
+		}
+		q.text = line
+		queries = append(queries, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler consultas: %v", err)
+	}
+	if len(queries) == 0 {
+		return nil, usageErrorf("nenhuma consulta na entrada padrão")
+	}
+	return queries, nil
+}
+
+// splitQueryLine separa uma linha de query batch em argumentos, respeitando
+// aspas simples e duplas (ex: name "São José" -uf SC)
+func splitQueryLine(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quote rune
+	inField := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				field.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("aspas sem fechamento")
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// parseQuery valida uma consulta; set são as flags informadas nela
+func parseQuery(kind string, args []string, params queryParams, set map[string]bool) (query, error) {
+	q := query{text: strings.TrimSpace(kind + " " + strings.Join(args, " ")), kind: kind, params: params}
+	switch kind {
+	case queryName, queryNear, queryReverse:
+	default:
+		return q, fmt.Errorf("consulta desconhecida %q: use name, near, reverse ou batch", kind)
+	}
+
+	for name := range set {
+		if k, ok := queryFlagKinds[name]; ok && k != kind {
+			return q, fmt.Errorf("-%s não se aplica a %s", name, kind)
+		}
+	}
+
+	switch kind {
+	case queryName:
+		name := strings.TrimSpace(strings.Join(args, " "))
+		if name == "" {
+			return q, fmt.Errorf("use: name <município> [-uf XX]")
+		}
+		q.name = utils.NormalizeMunicipio(name)
+		if params.uf != "" {
+			state, ok := domain.ResolveState(params.uf)
+			if !ok {
+				return q, fmt.Errorf("UF inválida %q: use a sigla (SP), o nome (São Paulo) ou o código IBGE (35)", params.uf)
+			}
+			q.params.uf = state.UF
+		}
+
+	case queryNear, queryReverse:
+		if len(args) != 2 {
+			return q, fmt.Errorf("use: %s <lat> <lon>", kind)
+		}
+		lat, err := strconv.ParseFloat(args[0], 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return q, fmt.Errorf("latitude inválida: %s", args[0])
+		}
+		lon, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || lon < -180 || lon > 180 {
+			return q, fmt.Errorf("longitude inválida: %s", args[1])
+		}
+		q.lat, q.lon = lat, lon
+
+		if kind == queryNear && params.km <= 0 {
+			return q, fmt.Errorf("-km deve ser maior que zero")
+		}
+		if kind == queryNear && params.limit < 0 {
+			return q, fmt.Errorf("-limit não pode ser negativo")
+		}
+		if kind == queryReverse {
+			switch params.mode {
+			case domain.ReverseAuto, domain.ReverseByPolygon, domain.ReverseByProximity:
+			default:
+				return q, fmt.Errorf("-modo inválido: use auto, poligono ou proximidade")
+			}
+		}
+	}
+	return q, nil
+}
+
+// run executa a consulta pelo IImportService (e, em reverse, pelos limites)
+func (q query) run(ctx context.Context, app *bootstrap.Application) ([]queryResult, error) {
+	switch q.kind {
+	case queryName:
+		location, err := app.Service.GetLocationByName(ctx, q.name, q.params.uf)
+		if err != nil || location == nil {
+			return nil, err
+		}
+		return []queryResult{toQueryResult(*location)}, nil
+
+	case queryNear:
+		locations, err := app.Service.GetLocationsInKilometersRange(ctx, q.lon, q.lat, q.params.km)
+		if err != nil {
+			return nil, err
+		}
+		var results []queryResult
+		for _, location := range *locations {
+			if q.params.limit > 0 && len(results) == q.params.limit {
+				break
+			}
+			result := toQueryResult(location)
+			distance := utils.HaversineKm(q.lat, q.lon, result.Latitude, result.Longitude)
+			result.DistanciaKm = &distance
+			results = append(results, result)
+		}
+		return results, nil
+
+	case queryReverse:
+		location, method, distance, err := services.LocateMunicipio(ctx, &app.Service, app.Limites, q.lon, q.lat, q.params.mode)
+		if err != nil || location == nil {
+			return nil, err
+		}
+		result := toQueryResult(*location)
+		result.Metodo = method
+		result.DistanciaKm = distance
+		return []queryResult{result}, nil
+	}
+	return nil, fmt.Errorf("consulta desconhecida %q", q.kind)
+}
+
+func toQueryResult(location domain.Location) queryResult {
+	return queryResult{
+		Municipio:  location.Municipio,
+		Estado:     location.Estado,
+		CodigoIBGE: location.CodigoIBGE,
+		Latitude:   location.Localizacao.Coordinates[1],
+		Longitude:  location.Localizacao.Coordinates[0],
+		Populacao:  location.Populacao,
+	}
+}
+
+// queryColumn é uma coluna das saídas table e csv; as opcionais só aparecem
+// quando algum resultado tem valor
+type queryColumn struct {
+	header   string
+	key      string
+	optional bool
+	value    func(queryResult) string
+}
+
+var queryColumns = []queryColumn{
+	{"Consulta", "consulta", true, func(r queryResult) string { return r.Consulta }},
+	{"Município", "municipio", false, func(r queryResult) string { return r.Municipio }},
+	{"UF", "estado", false, func(r queryResult) string { return r.Estado }},
+	{"Código IBGE", "codigo_ibge", true, func(r queryResult) string { return r.CodigoIBGE }},
+	{"Latitude", "latitude", false, func(r queryResult) string { return formatCoordinate(r, r.Latitude) }},
+	{"Longitude", "longitude", false, func(r queryResult) string { return formatCoordinate(r, r.Longitude) }},
+	{"População", "populacao", true, func(r queryResult) string {
+		if r.Populacao == 0 {
+			return ""
+		}
+		return strconv.Itoa(r.Populacao)
+	}},
+	{"Distância (km)", "distancia_km", true, func(r queryResult) string {
+		if r.DistanciaKm == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*r.DistanciaKm, 'f', 2, 64)
+	}},
+	{"Método", "metodo", true, func(r queryResult) string { return r.Metodo }},
+	{"Erro", "erro", true, func(r queryResult) string { return r.Erro }},
+}
+
+// formatCoordinate omite as coordenadas de linhas sem localização (erros e
+// municípios encontrados só pelo limite)
+func formatCoordinate(r queryResult, v float64) string {
+	if r.Latitude == 0 && r.Longitude == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeQueryResults imprime os resultados em table, json ou csv
+func writeQueryResults(w io.Writer, format string, results []queryResult) error {
+	if format == "json" {
+		if results == nil {
+			results = []queryResult{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	var columns []queryColumn
+	for _, column := range queryColumns {
+		keep := !column.optional
+		for _, r := range results {
+			if keep {
+				break
+			}
+			keep = column.value(r) != ""
+		}
+		if keep {
+			columns = append(columns, column)
+		}
+	}
+
+	record := make([]string, len(columns))
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		for i, column := range columns {
+			record[i] = column.key
+		}
+		cw.Write(record)
+		for _, r := range results {
+			for i, column := range columns {
+				record[i] = column.value(r)
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, column := range columns {
+		record[i] = column.header
+	}
+	fmt.Fprintln(tw, strings.Join(record, "\t"))
+	for _, r := range results {
+		for i, column := range columns {
+			record[i] = column.value(r)
+		}
+		fmt.Fprintln(tw, strings.Join(record, "\t"))
+	}
+	return tw.Flush()
+}
+
+// visited retorna as flags informadas em fs
+func visited(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
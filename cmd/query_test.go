@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+func TestSplitQueryLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "near -23.55 -46.63 -km 30", want: []string{"near", "-23.55", "-46.63", "-km", "30"}},
+		{line: "  name\tCampinas  ", want: []string{"name", "Campinas"}},
+		{line: `name "São José" -uf SC`, want: []string{"name", "São José", "-uf", "SC"}},
+		{line: `name 'São José dos Campos'`, want: []string{"name", "São José dos Campos"}},
+		{line: `name "Olho D'Água"`, want: []string{"name", "Olho D'Água"}},
+		{line: `name São" "Paulo`, want: []string{"name", "São Paulo"}},
+		{line: `name ""`, want: []string{"name", ""}},
+		{line: "", want: nil},
+		{line: `name "São José`, wantErr: true},
+		{line: `name 'Itu`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := splitQueryLine(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("esperado erro, obtido %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("obtido %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	defaults := queryParams{km: 50, mode: domain.ReverseAuto}
+	with := func(change func(*queryParams)) queryParams {
+		p := defaults
+		change(&p)
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		kind    string
+		args    []string
+		params  queryParams
+		set     map[string]bool
+		wantErr string
+		check   func(t *testing.T, q query)
+	}{
+		{
+			name: "name com uf pelo nome", kind: queryName, args: []string{"São", "Paulo"},
+			params: with(func(p *queryParams) { p.uf = "são paulo" }), set: map[string]bool{"uf": true},
+			check: func(t *testing.T, q query) {
+				if q.name != utils.NormalizeMunicipio("São Paulo") || q.params.uf != "SP" {
+					t.Errorf("name %q, uf %q", q.name, q.params.uf)
+				}
+			},
+		},
+		{name: "name sem município", kind: queryName, params: defaults, wantErr: "use: name"},
+		{
+			name: "name com uf inválida", kind: queryName, args: []string{"Campinas"},
+			params: with(func(p *queryParams) { p.uf = "XX" }), set: map[string]bool{"uf": true}, wantErr: "UF inválida",
+		},
+		{
+			name: "-km em name", kind: queryName, args: []string{"Campinas"},
+			params: defaults, set: map[string]bool{"km": true}, wantErr: "-km não se aplica a name",
+		},
+		{
+			name: "-uf em near", kind: queryNear, args: []string{"-23.55", "-46.63"},
+			params: defaults, set: map[string]bool{"uf": true}, wantErr: "-uf não se aplica a near",
+		},
+		{
+			name: "-limit em reverse", kind: queryReverse, args: []string{"-23.55", "-46.63"},
+			params: defaults, set: map[string]bool{"limit": true}, wantErr: "-limit não se aplica a reverse",
+		},
+		{
+			name: "-modo em near", kind: queryNear, args: []string{"-23.55", "-46.63"},
+			params: defaults, set: map[string]bool{"modo": true}, wantErr: "-modo não se aplica a near",
+		},
+		{
+			name: "near", kind: queryNear, args: []string{"-23.55", "-46.63"}, params: defaults,
+			check: func(t *testing.T, q query) {
+				if q.lat != -23.55 || q.lon != -46.63 || q.params.km != 50 {
+					t.Errorf("lat %v, lon %v, km %v", q.lat, q.lon, q.params.km)
+				}
+			},
+		},
+		{name: "near nos limites", kind: queryNear, args: []string{"90", "-180"}, params: defaults},
+		{name: "latitude acima de 90", kind: queryNear, args: []string{"90.1", "0"}, params: defaults, wantErr: "latitude inválida"},
+		{name: "latitude abaixo de -90", kind: queryReverse, args: []string{"-91", "0"}, params: defaults, wantErr: "latitude inválida"},
+		{name: "longitude acima de 180", kind: queryNear, args: []string{"0", "180.5"}, params: defaults, wantErr: "longitude inválida"},
+		{name: "longitude abaixo de -180", kind: queryReverse, args: []string{"0", "-181"}, params: defaults, wantErr: "longitude inválida"},
+		{name: "latitude não numérica", kind: queryNear, args: []string{"abc", "0"}, params: defaults, wantErr: "latitude inválida"},
+		{name: "near sem longitude", kind: queryNear, args: []string{"-23.55"}, params: defaults, wantErr: "use: near <lat> <lon>"},
+		{
+			name: "near com km zero", kind: queryNear, args: []string{"0", "0"},
+			params: with(func(p *queryParams) { p.km = 0 }), set: map[string]bool{"km": true}, wantErr: "-km deve ser maior que zero",
+		},
+		{
+			name: "near com limit negativo", kind: queryNear, args: []string{"0", "0"},
+			params: with(func(p *queryParams) { p.limit = -1 }), set: map[string]bool{"limit": true}, wantErr: "-limit não pode ser negativo",
+		},
+		{
+			name: "reverse com modo inválido", kind: queryReverse, args: []string{"0", "0"},
+			params: with(func(p *queryParams) { p.mode = "gps" }), set: map[string]bool{"modo": true}, wantErr: "-modo inválido",
+		},
+		{name: "consulta desconhecida", kind: "perto", args: []string{"0", "0"}, params: defaults, wantErr: "consulta desconhecida"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseQuery(tt.kind, tt.args, tt.params, tt.set)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("erro %v, esperado %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, q)
+			}
+		})
+	}
+}
+
+func TestReadQueries(t *testing.T) {
+	defaults := queryParams{uf: "SP", km: 50, mode: domain.ReverseAuto}
+
+	t.Run("padrões por linha", func(t *testing.T) {
+		input := strings.Join([]string{
+			"# comentário",
+			"",
+			`name "São José" -uf SC`,
+			"name Campinas",
+			"near -23.55 -46.63 -km 30",
+			"near -23.55 -46.63",
+			"reverse -23.55 -46.63 -modo poligono",
+		}, "\n")
+
+		queries, err := readQueries(strings.NewReader(input), defaults)
+		if err != nil {
+			t.Fatalf("erro inesperado: %v", err)
+		}
+		if len(queries) != 5 {
+			t.Fatalf("%d consultas, esperado 5", len(queries))
+		}
+
+		// As flags de uma linha não passam para as seguintes
+		if queries[0].params.uf != "SC" || queries[1].params.uf != "SP" {
+			t.Errorf("uf %q e %q, esperado SC e SP", queries[0].params.uf, queries[1].params.uf)
+		}
+		if queries[2].params.km != 30 || queries[3].params.km != 50 {
+			t.Errorf("km %v e %v, esperado 30 e 50", queries[2].params.km, queries[3].params.km)
+		}
+		if queries[4].params.mode != domain.ReverseByPolygon {
+			t.Errorf("modo %q, esperado %q", queries[4].params.mode, domain.ReverseByPolygon)
+		}
+		if queries[0].text != `name "São José" -uf SC` {
+			t.Errorf("texto %q, esperado a linha original", queries[0].text)
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "aspas sem fechamento", input: "name Campinas\nname \"São José", wantErr: "linha 2: aspas sem fechamento"},
+		{name: "flag de outra consulta", input: "name Campinas -km 10", wantErr: "linha 1: -km não se aplica a name"},
+		{name: "flag desconhecida", input: "near 0 0 -raio 10", wantErr: "linha 1:"},
+		{name: "coordenada fora do intervalo", input: "# x\nreverse 95 0", wantErr: "linha 2: latitude inválida"},
+		{name: "só flags", input: "-km 10", wantErr: "linha 1: consulta vazia"},
+		{name: "batch dentro de batch", input: "batch", wantErr: "linha 1: consulta desconhecida"},
+		{name: "entrada vazia", input: "# nada\n\n", wantErr: "nenhuma consulta"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readQueries(strings.NewReader(tt.input), defaults)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("erro %v, esperado %q", err, tt.wantErr)
+			}
+			var cliErr *cliError
+			if !errors.As(err, &cliErr) || cliErr.code != exitUsage {
+				t.Errorf("erro %v não é de uso", err)
+			}
+		})
+	}
+}
+
+func TestWriteQueryResults(t *testing.T) {
+	distance := 12.345
+	located := queryResult{Municipio: "Campinas", Estado: "SP", Latitude: -22.9, Longitude: -47.06}
+	full := queryResult{
+		Consulta: "near -23 -47", Municipio: "Campinas", Estado: "SP", CodigoIBGE: "3509502",
+		Latitude: -22.9, Longitude: -47.06, Populacao: 1139047, DistanciaKm: &distance, Metodo: "poligono",
+	}
+	failed := queryResult{Consulta: "name Xyz", Erro: "nenhum resultado"}
+
+	tests := []struct {
+		name    string
+		results []queryResult
+		header  []string
+		rows    [][]string
+	}{
+		{
+			name:    "sem colunas opcionais",
+			results: []queryResult{located},
+			header:  []string{"municipio", "estado", "latitude", "longitude"},
+			rows:    [][]string{{"Campinas", "SP", "-22.9", "-47.06"}},
+		},
+		{
+			name:    "todas as colunas",
+			results: []queryResult{full},
+			header:  []string{"consulta", "municipio", "estado", "codigo_ibge", "latitude", "longitude", "populacao", "distancia_km", "metodo"},
+			rows:    [][]string{{"near -23 -47", "Campinas", "SP", "3509502", "-22.9", "-47.06", "1139047", "12.35", "poligono"}},
+		},
+		{
+			name:    "erro sem coordenadas",
+			results: []queryResult{located, failed},
+			header:  []string{"consulta", "municipio", "estado", "latitude", "longitude", "erro"},
+			rows: [][]string{
+				{"", "Campinas", "SP", "-22.9", "-47.06", ""},
+				{"name Xyz", "", "", "", "", "nenhum resultado"},
+			},
+		},
+		{
+			name:   "sem resultados",
+			header: []string{"municipio", "estado", "latitude", "longitude"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/csv", func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeQueryResults(&buf, "csv", tt.results); err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("csv inválido: %v", err)
+			}
+			if !reflect.DeepEqual(records[0], tt.header) {
+				t.Errorf("cabeçalho %q, esperado %q", records[0], tt.header)
+			}
+			if len(records[1:]) != len(tt.rows) {
+				t.Fatalf("%d linhas, esperado %d", len(records[1:]), len(tt.rows))
+			}
+			for i, row := range tt.rows {
+				if !reflect.DeepEqual(records[i+1], row) {
+					t.Errorf("linha %d: %q, esperado %q", i+1, records[i+1], row)
+				}
+			}
+		})
+
+		t.Run(tt.name+"/table", func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeQueryResults(&buf, "table", tt.results); err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if len(lines) != len(tt.results)+1 {
+				t.Fatalf("%d linhas, esperado %d:\n%s", len(lines), len(tt.results)+1, buf.String())
+			}
+
+			// Cada coluna mantida aparece no cabeçalho; as omitidas não
+			var headers []string
+			for _, column := range queryColumns {
+				for _, key := range tt.header {
+					if column.key == key {
+						headers = append(headers, column.header)
+					}
+				}
+			}
+			if got := strings.Fields(lines[0]); strings.Join(got, " ") != strings.Join(headers, " ") {
+				t.Errorf("cabeçalho %q, esperado %q", lines[0], strings.Join(headers, "  "))
+			}
+		})
+	}
+}
+
+func TestWriteQueryResultsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeQueryResults(&buf, "json", nil); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("sem resultados: %q, esperado []", buf.String())
+	}
+
+	buf.Reset()
+	failed := queryResult{Consulta: "name Xyz", Erro: "nenhum resultado"}
+	if err := writeQueryResults(&buf, "json", []queryResult{failed}); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json inválido: %v", err)
+	}
+	want := map[string]interface{}{"consulta": "name Xyz", "erro": "nenhum resultado"}
+	if len(decoded) != 1 || !reflect.DeepEqual(decoded[0], want) {
+		t.Errorf("obtido %v, esperado %v", decoded, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runServe inicia o servidor da API até receber SIGINT ou SIGTERM
+func runServe(args []string) error {
+	fs := newFlagSet("serve [flags]", "Inicia o servidor da API com os dados já importados (veja o comando import).")
+	var storage storageFlags
+	storage.register(fs)
+	server := cfg.Server
+	fs.StringVar(&server.Port, "port", server.Port, "Porta do servidor")
+	fs.DurationVar(&server.ReadTimeout, "read-timeout", server.ReadTimeout, "Tempo máximo para ler cada requisição (0 = sem limite)")
+	fs.DurationVar(&server.WriteTimeout, "write-timeout", server.WriteTimeout, "Tempo máximo para escrever cada resposta (0 = sem limite)")
+	fs.DurationVar(&server.IdleTimeout, "idle-timeout", server.IdleTimeout, "Tempo máximo de uma conexão keep-alive ociosa (0 = usa -read-timeout)")
+	fs.DurationVar(&server.ShutdownTimeout, "shutdown-timeout", server.ShutdownTimeout, "Tempo para concluir as requisições em andamento ao encerrar")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+	effective := *cfg
+	effective.Server = server
+	if err := checkConfig(&effective); err != nil {
+		return err
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	// Only create indices if they don't exist (server should run with pre-imported data)
+	ctx := context.Background()
+	app.Service.CreateGeoIndex(ctx)
+	app.Service.CreateTextIndex(ctx)
+	app.Service.CreateIBGEIndex(ctx)
+	app.Service.CreateEstadoIndex(ctx)
+
+	srv := &http.Server{
+		Addr:         ":" + server.Port,
+		Handler:      app.Router,
+		ReadTimeout:  server.ReadTimeout,
+		WriteTimeout: server.WriteTimeout,
+		IdleTimeout:  server.IdleTimeout,
+	}
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Servidor iniciado na porta %s", server.Port)
+		log.Printf("📍 Endpoints disponíveis:")
+		log.Printf("   GET /health")
+		log.Printf("   GET /location/{municipio}?estado=XX")
+		log.Printf("   GET /nearby?lat=XX&lon=YY&distance=50&formato=json|kml|kmz|gpx")
+		log.Printf("   GET /bbox?minLat=XX&minLon=YY&maxLat=XX&maxLon=YY&limit=500&formato=json|kml|kmz|gpx")
+		log.Printf("   GET /ibge/{codigo}")
+		log.Printf("   GET /ibge/{codigo}/hierarquia")
+		log.Printf("   GET /hierarquia?lat=XX&lon=YY")
+		log.Printf("   GET /reverse?lat=XX&lon=YY&modo=auto")
+		log.Printf("   GET /cep/{cep}")
+		log.Printf("   GET /estados")
+		log.Printf("   GET /estados/{uf}")
+		log.Printf("   GET /estados/{uf}/municipios?page=1&limit=50&sort=populacao&order=desc&formato=json|kml|kmz|gpx")
+		log.Printf("   GET /regioes/{regiao}")
+		log.Printf("   GET /regioes/{nivel}/{codigo}")
+		log.Println()
+
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("erro ao iniciar servidor: %v", err)
+	case <-done:
+	}
+	log.Println("🛑 Encerrando servidor...")
+
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctxShutdown); err != nil {
+		return fmt.Errorf("erro ao encerrar servidor: %v", err)
+	}
+
+	log.Println("✅ Servidor encerrado com sucesso!")
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/bootstrap"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// snapshotCommands são as operações do comando snapshot
+var snapshotCommands = []command{
+	{"create", "Copiar a coleção principal para um snapshot nomeado (que passa a ser o ativo)", snapshotCreate},
+	{"list", "Listar os snapshots com versão, origem e contagens", snapshotList},
+	{"activate", "Voltar a coleção principal para um snapshot", snapshotActivate},
+	{"delete", "Remover um snapshot inativo", snapshotDelete},
+}
+
+func runSnapshotCommand(args []string) error {
+	return dispatch("snapshot", snapshotCommands, args)
+}
+
+// snapshotCreate copia a coleção principal para um novo snapshot
+func snapshotCreate(args []string) error {
+	fs := newFlagSet("snapshot create [flags] <nome>",
+		"Copia a coleção principal para um snapshot com o próximo número de versão, guardando a origem\n"+
+			"(checksum e data da última importação concluída, ou o arquivo de -source) e as contagens por UF.")
+	var storage storageFlags
+	storage.register(fs)
+	source := fs.String("source", "", "Arquivo importado, para registrar o nome e o SHA-256 (padrão: última importação concluída)")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Tempo máximo da cópia (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	opts := domain.SnapshotOptions{Nome: args[0]}
+	if *source != "" {
+		if opts.SourceSHA256, err = utils.FileSHA256(*source); err != nil {
+			return fmt.Errorf("erro ao calcular checksum de %s: %v", *source, err)
+		}
+		opts.Fonte = filepath.Base(*source)
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	log.Printf("📸 Criando snapshot %s...", opts.Nome)
+	if _, err := app.Snapshots.CreateSnapshot(ctx, opts); err != nil {
+		return fmt.Errorf("erro ao criar snapshot: %v", err)
+	}
+	return nil
+}
+
+// snapshotList lista os snapshots
+func snapshotList(args []string) error {
+	fs := newFlagSet("snapshot list [flags]", "Lista os snapshots da versão mais recente para a mais antiga; * marca o ativo.")
+	var storage storageFlags
+	storage.register(fs)
+	asJSON := fs.Bool("json", false, "Imprimir em JSON, com as contagens por UF")
+	timeout := fs.Duration("timeout", 30*time.Second, "Tempo máximo da consulta (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	versions, err := app.Snapshots.ListSnapshots(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao listar snapshots: %v", err)
+	}
+	if *asJSON {
+		return writeJSON("-", versions)
+	}
+	if len(versions) == 0 {
+		fmt.Println("Nenhum snapshot (crie um com snapshot create <nome>)")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tVersão\tNome\tLocalizações\tUFs\tFonte\tSHA-256\tImportado em\tCriado em")
+	for _, v := range versions {
+		active := ""
+		if v.Ativo {
+			active = "*"
+		}
+		sha := v.SourceSHA256
+		if len(sha) > 12 {
+			sha = sha[:12]
+		}
+		importedAt := ""
+		if v.ImportadoEm != nil {
+			importedAt = v.ImportadoEm.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", active, v.Versao, v.Nome, v.Total, len(v.PorEstado),
+			v.Fonte, sha, importedAt, v.CriadoEm.Local().Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// snapshotActivate volta a coleção principal para um snapshot
+func snapshotActivate(args []string) error {
+	fs := newFlagSet("snapshot activate [flags] <nome>",
+		"Substitui a coleção principal pela cópia do snapshot, de uma vez, e recria os índices. Um servidor\n"+
+			"em execução passa a informar a nova versão no header X-Dataset-Version em até 30 segundos.")
+	var storage storageFlags
+	storage.register(fs)
+	timeout := fs.Duration("timeout", 10*time.Minute, "Tempo máximo da cópia (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	log.Printf("⏪ Ativando snapshot %s...", args[0])
+	version, err := app.Snapshots.ActivateSnapshot(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("erro ao ativar snapshot: %v", err)
+	}
+	if version == nil {
+		return snapshotNotFound(args[0])
+	}
+	return nil
+}
+
+// snapshotDelete remove um snapshot inativo e a sua coleção
+func snapshotDelete(args []string) error {
+	fs := newFlagSet("snapshot delete [flags] <nome>", "Remove o snapshot e a sua cópia da coleção. O snapshot ativo não pode ser removido.")
+	var storage storageFlags
+	storage.register(fs)
+	timeout := fs.Duration("timeout", time.Minute, "Tempo máximo da remoção (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	version, err := app.Snapshots.DeleteSnapshot(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("erro ao remover snapshot: %v", err)
+	}
+	if version == nil {
+		return snapshotNotFound(args[0])
+	}
+	log.Printf("🗑️ Snapshot %s (versão %d) removido", version.Nome, version.Versao)
+	return nil
+}
+
+func snapshotNotFound(nome string) error {
+	return &cliError{code: exitNotFound, err: fmt.Errorf("snapshot %q não encontrado (veja snapshot list)", nome)}
+}
+
+// isMainCollection indica se collection (vazio = padrão) é a coleção
+// principal da configuração, versionada pelos snapshots
+func isMainCollection(collection string) bool {
+	return collection == "" || collection == cfg.Storage.Collection
+}
+
+// datasetModified avisa que a coleção principal foi alterada: o snapshot
+// ativo deixa de descrevê-la e a API para de informar a versão até o
+// próximo snapshot create
+func datasetModified(ctx context.Context, app *bootstrap.Application) {
+	version, err := app.Snapshots.MarkModified(ctx)
+	if err != nil {
+		log.Printf("⚠️ Erro ao desativar o snapshot ativo: %v", err)
+		return
+	}
+	if version != nil {
+		log.Printf("⚠️ A coleção principal mudou e não corresponde mais ao snapshot %s (versão %d); use snapshot create para versioná-la", version.Nome, version.Versao)
+	}
+}
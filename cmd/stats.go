@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runStats mostra os totais importados por UF
+func runStats(args []string) error {
+	fs := newFlagSet("stats [flags]", "Mostra, por UF, o número de municípios, a população deles e o número total de localizações importadas.")
+	var storage storageFlags
+	storage.register(fs)
+	asJSON := fs.Bool("json", false, "Imprimir em JSON")
+	timeout := fs.Duration("timeout", 30*time.Second, "Tempo máximo da consulta (0 = sem limite)")
+
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	app, closeDB, err := storage.connect()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+
+	states, err := app.Service.ListStates(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao listar estados: %v", err)
+	}
+	if *asJSON {
+		return writeJSON("-", states)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "UF\tRegião\tMunicípios\tPopulação\tLocalizações\t")
+	var municipios, populacao, localizacoes int64
+	for _, s := range states {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t\n", s.UF, s.Regiao, s.Municipios, s.Populacao, s.Localizacoes)
+		municipios += s.Municipios
+		populacao += s.Populacao
+		localizacoes += s.Localizacoes
+	}
+	fmt.Fprintf(w, "Total\t\t%d\t%d\t%d\t\n", municipios, populacao, localizacoes)
+	return w.Flush()
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/gpx"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/kml"
+)
+
+// Formatos aceitos em ?formato= nas listagens de localizações
+const (
+	FormatJSON = "json"
+	FormatKML  = "kml"
+	FormatKMZ  = "kmz"
+	FormatGPX  = "gpx"
+)
+
+// parseFormat lê o parâmetro formato da query string; vazio é JSON
+func parseFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("formato"); format {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatKML, FormatKMZ, FormatGPX:
+		return format, nil
+	default:
+		return "", fmt.Errorf("Formato inválido: use json, kml, kmz ou gpx")
+	}
+}
+
+// respondWithLocations envia as localizações como arquivo KML, KMZ ou GPX
+// (baixado como name.<formato>); em JSON, envia payload
+func respondWithLocations(w http.ResponseWriter, format, name string, locations []domain.Location, payload interface{}) {
+	var buf bytes.Buffer
+	var contentType string
+	var err error
+
+	switch format {
+	case FormatKML:
+		contentType = "application/vnd.google-earth.kml+xml"
+		err = kml.Write(&buf, name, toPlacemarks(locations))
+	case FormatKMZ:
+		contentType = "application/vnd.google-earth.kmz"
+		err = kml.WriteKMZ(&buf, name, toPlacemarks(locations))
+	case FormatGPX:
+		contentType = "application/gpx+xml"
+		err = gpx.Write(&buf, "Geolocation-Brasil", name, toWaypoints(locations))
+	default:
+		respondWithJSON(w, http.StatusOK, payload)
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao gerar %s: %v", format, err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao gerar arquivo")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// toPlacemarks converte as localizações em placemarks, com UF, código IBGE e
+// população em ExtendedData
+func toPlacemarks(locations []domain.Location) []kml.Placemark {
+	placemarks := make([]kml.Placemark, len(locations))
+	for i, loc := range locations {
+		data := map[string]string{"estado": loc.Estado}
+		if loc.CodigoIBGE != "" {
+			data["codigo_ibge"] = loc.CodigoIBGE
+		}
+		if loc.Populacao > 0 {
+			data["populacao"] = strconv.Itoa(loc.Populacao)
+		}
+
+		coordinates := loc.Localizacao.Coordinates
+		placemarks[i] = kml.Placemark{
+			Name:        loc.Municipio,
+			Data:        data,
+			Coordinates: &coordinates,
+		}
+	}
+	return placemarks
+}
+
+// toWaypoints converte as localizações em waypoints; a UF vai em type e o
+// código IBGE na descrição
+func toWaypoints(locations []domain.Location) []gpx.Waypoint {
+	waypoints := make([]gpx.Waypoint, len(locations))
+	for i, loc := range locations {
+		coordinates := loc.Localizacao.Coordinates
+		waypoints[i] = gpx.Waypoint{
+			Name:        loc.Municipio + " - " + loc.Estado,
+			Description: loc.CodigoIBGE,
+			Type:        loc.Estado,
+			Coordinates: &coordinates,
+		}
+	}
+	return waypoints
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -12,17 +13,23 @@ import (
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services"
 	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services/interfaces"
 	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
 )
 
 type API struct {
-	importService interfaces.IImportService
+	importService     interfaces.IImportService
+	postalCodeService interfaces.IPostalCodeService
+	boundaryService   interfaces.IBoundaryService
+	datasetService    interfaces.IDatasetService
 }
 
-// NewAPI cria uma nova instância da API
-func NewAPI(service interfaces.IImportService) *API {
-	return &API{importService: service}
+// NewAPI cria uma nova instância da API; datasetService pode ser nil para não
+// informar a versão dos dados
+func NewAPI(service interfaces.IImportService, postalCodeService interfaces.IPostalCodeService, boundaryService interfaces.IBoundaryService, datasetService interfaces.IDatasetService) *API {
+	return &API{importService: service, postalCodeService: postalCodeService, boundaryService: boundaryService, datasetService: datasetService}
 }
 
 // GetLocationByNameHandler busca localização por nome
@@ -48,8 +55,16 @@ func (api *API) GetLocationByNameHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	municipio = strings.Join(splittedMunicipio, " ") // Municipio normalizado: Xxxx Xx Xxxx
-	estado = strings.ToUpper(estado)                 // Estado normalizado: XX
-	log.Println(municipio)
+
+	// Estado aceito como sigla, nome ou código IBGE; normalizado para XX
+	if estado != "" {
+		state, ok := domain.ResolveState(estado)
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, "Estado inválido. Use a sigla (SP), o nome (São Paulo) ou o código IBGE (35)")
+			return
+		}
+		estado = state.UF
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -74,14 +89,34 @@ func (api *API) GetLocationByNameHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	response := domain.LocationResponse{
-		Municipio: location.Municipio,
-		Estado:    location.Estado,
-		Latitude:  location.Localizacao.Coordinates[1],
-		Longitude: location.Localizacao.Coordinates[0],
+	respondWithJSON(w, http.StatusOK, toLocationResponse(*location))
+}
+
+// GetLocationByCodigoIBGEHandler busca localização pelo código IBGE do município
+func (api *API) GetLocationByCodigoIBGEHandler(w http.ResponseWriter, r *http.Request) {
+	codigo := mux.Vars(r)["codigo"]
+
+	if len(codigo) != 7 || !isDigits(codigo) {
+		respondWithError(w, http.StatusBadRequest, "Código IBGE deve ter 7 dígitos")
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	location, err := api.importService.GetLocationByCodigoIBGE(ctx, codigo)
+	if err != nil {
+		log.Printf("Erro ao buscar código IBGE: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar localização")
+		return
+	}
+
+	if location == nil {
+		respondWithError(w, http.StatusNotFound, "Localização não encontrada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toLocationResponse(*location))
 }
 
 // GetNearbyLocationsHandler busca localizações próximas
@@ -90,6 +125,12 @@ func (api *API) GetNearbyLocationsHandler(w http.ResponseWriter, r *http.Request
 	lonStr := r.URL.Query().Get("lon")
 	distStr := r.URL.Query().Get("distance")
 
+	format, err := parseFormat(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if latStr == "" || lonStr == "" {
 		respondWithError(w, http.StatusBadRequest, "Parâmetros lat e lon são obrigatórios")
 		return
@@ -126,25 +167,510 @@ func (api *API) GetNearbyLocationsHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	responses := make([]domain.LocationResponse, len(*locations))
-	for i, loc := range *locations {
-		responses[i] = domain.LocationResponse{
-			Municipio: loc.Municipio,
-			Estado:    loc.Estado,
-			Latitude:  loc.Localizacao.Coordinates[1],
-			Longitude: loc.Localizacao.Coordinates[0],
+	respondWithLocations(w, format, "nearby", *locations, toLocationResponses(*locations))
+}
+
+// GetLocationsInBBoxHandler busca as localizações dentro de um retângulo
+// (minLat, minLon, maxLat, maxLon), até limit resultados
+func (api *API) GetLocationsInBBoxHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := parseFormat(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	var bbox domain.BBox
+	for _, p := range []struct {
+		name  string
+		value *float64
+		limit float64
+	}{
+		{"minLat", &bbox.MinLat, 90},
+		{"minLon", &bbox.MinLon, 180},
+		{"maxLat", &bbox.MaxLat, 90},
+		{"maxLon", &bbox.MaxLon, 180},
+	} {
+		v, err := strconv.ParseFloat(query.Get(p.name), 64)
+		if err != nil || v < -p.limit || v > p.limit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Parâmetro %s inválido", p.name))
+			return
 		}
+		*p.value = v
+	}
+	if bbox.MinLat >= bbox.MaxLat || bbox.MinLon >= bbox.MaxLon {
+		respondWithError(w, http.StatusBadRequest, "Retângulo inválido: minLat e minLon devem ser menores que maxLat e maxLon")
+		return
+	}
+
+	limit := domain.DefaultBBoxLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > domain.MaxBBoxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Limite inválido: use um valor entre 1 e %d", domain.MaxBBoxLimit))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	locations, err := api.importService.GetLocationsInBBox(ctx, bbox, limit)
+	if err != nil {
+		log.Printf("Erro ao buscar localizações no retângulo: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar localizações")
+		return
+	}
+
+	respondWithLocations(w, format, "bbox", locations, toLocationResponses(locations))
+}
+
+// GetLocationByCEPHandler busca município e coordenada aproximada de um CEP
+func (api *API) GetLocationByCEPHandler(w http.ResponseWriter, r *http.Request) {
+	cep, err := utils.NormalizeCEP(mux.Vars(r)["cep"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "CEP inválido: use o formato 01310-100 ou 01310100")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	postalCode, approximate, err := api.postalCodeService.GetByCEP(ctx, cep)
+	if err != nil {
+		log.Printf("Erro ao buscar CEP: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar CEP")
+		return
+	}
+
+	if postalCode == nil {
+		respondWithError(w, http.StatusNotFound, "CEP não encontrado")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, domain.CEPResponse{
+		CEP:        utils.FormatCEP(postalCode.CEP),
+		Localidade: postalCode.Localidade,
+		Municipio:  postalCode.Municipio,
+		Estado:     postalCode.Estado,
+		CodigoIBGE: postalCode.CodigoIBGE,
+		Latitude:   postalCode.Localizacao.Coordinates[1],
+		Longitude:  postalCode.Localizacao.Coordinates[0],
+		Aproximado: approximate,
+	})
+}
+
+// ListStatesHandler lista as UFs com região, capital e os totais dos dados importados
+func (api *API) ListStatesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	states, err := api.importService.ListStates(ctx)
+	if err != nil {
+		log.Printf("Erro ao listar estados: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao listar estados")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, states)
+}
+
+// GetStateHandler retorna uma UF, aceita como sigla, nome ou código IBGE
+func (api *API) GetStateHandler(w http.ResponseWriter, r *http.Request) {
+	state, ok := domain.ResolveState(mux.Vars(r)["uf"])
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Estado não encontrado")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	summary, err := api.importService.GetState(ctx, state.UF)
+	if err != nil {
+		log.Printf("Erro ao buscar estado: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar estado")
+		return
+	}
+
+	if summary == nil {
+		respondWithError(w, http.StatusNotFound, "Estado não encontrado")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, summary)
+}
+
+// ListMunicipiosByStateHandler lista os municípios de uma UF com paginação
+// (page, limit) e ordenação (sort=municipio|populacao, order=asc|desc)
+func (api *API) ListMunicipiosByStateHandler(w http.ResponseWriter, r *http.Request) {
+	state, ok := domain.ResolveState(mux.Vars(r)["uf"])
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Estado não encontrado")
+		return
+	}
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := parseFormat(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := api.importService.ListMunicipiosByEstado(ctx, state.UF, opts)
+	if err != nil {
+		log.Printf("Erro ao listar municípios: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao listar municípios")
+		return
+	}
+
+	respondWithLocations(w, format, "municipios-"+strings.ToLower(state.UF), page.Items, domain.MunicipiosResponse{
+		Estado:     state.UF,
+		Total:      page.Total,
+		Page:       opts.Page,
+		Limit:      opts.Limit,
+		Sort:       opts.Sort,
+		Order:      sortOrder(opts),
+		Municipios: toLocationResponses(page.Items),
+	})
+}
+
+// ListRegionMembersHandler lista os municípios de uma região do IBGE
+// (/regioes/{nivel}/{codigo}), com a mesma paginação de /estados/{uf}/municipios
+func (api *API) ListRegionMembersHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nivel, codigo := vars["nivel"], vars["codigo"]
+
+	if !domain.IsRegionLevel(nivel) {
+		respondWithError(w, http.StatusNotFound, "Nível inválido: use intermediaria, imediata, mesorregiao ou microrregiao")
+		return
+	}
+	if !isDigits(codigo) {
+		respondWithError(w, http.StatusBadRequest, "Código da região deve ser numérico")
+		return
+	}
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := api.importService.ListMunicipiosByRegion(ctx, nivel, codigo, opts)
+	if err != nil {
+		log.Printf("Erro ao listar municípios da região: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao listar municípios")
+		return
+	}
+
+	if page.Total == 0 {
+		respondWithError(w, http.StatusNotFound, "Região não encontrada")
+		return
+	}
+
+	nome := ""
+	for _, loc := range page.Items {
+		if loc.Hierarquia != nil {
+			nome = loc.Hierarquia.Region(nivel).Nome
+			break
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, domain.RegionMembersResponse{
+		Nivel:      nivel,
+		Codigo:     codigo,
+		Nome:       nome,
+		Total:      page.Total,
+		Page:       opts.Page,
+		Limit:      opts.Limit,
+		Sort:       opts.Sort,
+		Order:      sortOrder(opts),
+		Municipios: toLocationResponses(page.Items),
+	})
+}
+
+// GetHierarchyByCodigoIBGEHandler retorna a hierarquia de regiões de um município
+func (api *API) GetHierarchyByCodigoIBGEHandler(w http.ResponseWriter, r *http.Request) {
+	codigo := mux.Vars(r)["codigo"]
+
+	if len(codigo) != 7 || !isDigits(codigo) {
+		respondWithError(w, http.StatusBadRequest, "Código IBGE deve ter 7 dígitos")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	location, err := api.importService.GetLocationByCodigoIBGE(ctx, codigo)
+	if err != nil {
+		log.Printf("Erro ao buscar código IBGE: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar localização")
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, responses)
+	if location == nil {
+		respondWithError(w, http.StatusNotFound, "Localização não encontrada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toHierarchyResponse(*location))
+}
+
+// GetHierarchyByCoordinateHandler retorna a hierarquia do município que contém
+// a coordenada (?lat=XX&lon=YY), ou do município com a sede mais próxima
+// quando os limites municipais não foram importados
+func (api *API) GetHierarchyByCoordinateHandler(w http.ResponseWriter, r *http.Request) {
+	lat, lon, ok := parseCoordinate(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	location, _, distance, err := services.LocateMunicipio(ctx, api.importService, api.boundaryService, lon, lat, domain.ReverseAuto)
+	if err != nil {
+		log.Printf("Erro ao buscar município por coordenada: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar localização")
+		return
+	}
+
+	if location == nil {
+		respondWithError(w, http.StatusNotFound, "Nenhum município encontrado para a coordenada")
+		return
+	}
+
+	response := toHierarchyResponse(*location)
+	response.DistanciaKm = distance
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// ReverseGeocodeHandler retorna o município de uma coordenada
+// (?lat=XX&lon=YY&modo=auto|poligono|proximidade). Com poligono é o município
+// cujo limite contém o ponto; com proximidade, o de sede mais próxima.
+func (api *API) ReverseGeocodeHandler(w http.ResponseWriter, r *http.Request) {
+	lat, lon, ok := parseCoordinate(w, r)
+	if !ok {
+		return
+	}
+
+	mode := r.URL.Query().Get("modo")
+	switch mode {
+	case "":
+		mode = domain.ReverseAuto
+	case domain.ReverseAuto, domain.ReverseByPolygon, domain.ReverseByProximity:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Modo inválido: use auto, poligono ou proximidade")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	location, method, distance, err := services.LocateMunicipio(ctx, api.importService, api.boundaryService, lon, lat, mode)
+	if err != nil {
+		log.Printf("Erro na geocodificação reversa: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar localização")
+		return
+	}
+
+	if location == nil {
+		respondWithError(w, http.StatusNotFound, "Nenhum município encontrado para a coordenada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, domain.ReverseGeocodeResponse{
+		Municipio:   location.Municipio,
+		Estado:      location.Estado,
+		CodigoIBGE:  location.CodigoIBGE,
+		Metodo:      method,
+		DistanciaKm: distance,
+	})
+}
+
+// parseCoordinate lê lat e lon da query string, respondendo 400 se inválidos
+func parseCoordinate(w http.ResponseWriter, r *http.Request) (float64, float64, bool) {
+	lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		respondWithError(w, http.StatusBadRequest, "Latitude inválida")
+		return 0, 0, false
+	}
+
+	lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		respondWithError(w, http.StatusBadRequest, "Longitude inválida")
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
+
+// GetRegionHandler retorna os totais de uma região e das suas UFs
+func (api *API) GetRegionHandler(w http.ResponseWriter, r *http.Request) {
+	regiao, ok := domain.RegionByName(mux.Vars(r)["regiao"])
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Região não encontrada: use Norte, Nordeste, Centro-Oeste, Sudeste ou Sul")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	region, err := api.importService.GetRegion(ctx, regiao)
+	if err != nil {
+		log.Printf("Erro ao buscar região: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Erro ao buscar região")
+		return
+	}
+
+	if region == nil {
+		respondWithError(w, http.StatusNotFound, "Região não encontrada")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, region)
 }
 
 // HealthCheckHandler verifica se a API está funcionando
 func (api *API) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	respondWithJSON(w, http.StatusOK, map[string]string{
+	response := map[string]string{
 		"status":  "ok",
 		"message": "API de Geolocalização Brasil está funcionando!",
-	})
+	}
+	if version := api.activeDataset(r.Context()); version != nil {
+		response["dataset_version"] = strconv.Itoa(version.Versao)
+		response["dataset_name"] = version.Nome
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// activeDataset retorna o snapshot ativo, ou nil se nenhum está ativo
+func (api *API) activeDataset(ctx context.Context) *domain.DatasetVersion {
+	if api.datasetService == nil {
+		return nil
+	}
+	return api.datasetService.ActiveVersion(ctx)
+}
+
+// isDigits indica se s é não vazio e só tem dígitos; ao contrário de
+// strconv.Atoi, recusa sinais (+123456, -123456)
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// toLocationResponse converte a entidade para o formato de resposta da API
+func toLocationResponse(loc domain.Location) domain.LocationResponse {
+	return domain.LocationResponse{
+		Municipio:  loc.Municipio,
+		Estado:     loc.Estado,
+		Latitude:   loc.Localizacao.Coordinates[1],
+		Longitude:  loc.Localizacao.Coordinates[0],
+		CodigoIBGE: loc.CodigoIBGE,
+		Populacao:  loc.Populacao,
+	}
+}
+
+// toLocationResponses converte uma lista de entidades para o formato de resposta
+func toLocationResponses(locations []domain.Location) []domain.LocationResponse {
+	responses := make([]domain.LocationResponse, len(locations))
+	for i, loc := range locations {
+		responses[i] = toLocationResponse(loc)
+	}
+	return responses
+}
+
+// toHierarchyResponse monta a hierarquia completa: região e UF pelo registro de
+// estados, demais níveis pelo que foi importado da DTB
+func toHierarchyResponse(loc domain.Location) domain.HierarchyResponse {
+	response := domain.HierarchyResponse{
+		Municipio:  loc.Municipio,
+		CodigoIBGE: loc.CodigoIBGE,
+		Estado:     loc.Estado,
+	}
+
+	if state, ok := domain.StateByUF(loc.Estado); ok {
+		response.NomeEstado = state.Nome
+		response.Regiao = state.Regiao
+	}
+
+	if h := loc.Hierarquia; h != nil {
+		response.Intermediaria = regionOrNil(h.Intermediaria)
+		response.Imediata = regionOrNil(h.Imediata)
+		response.Mesorregiao = regionOrNil(h.Mesorregiao)
+		response.Microrregiao = regionOrNil(h.Microrregiao)
+	}
+
+	return response
+}
+
+func regionOrNil(region domain.IBGERegion) *domain.IBGERegion {
+	if region.Codigo == "" {
+		return nil
+	}
+	return &region
+}
+
+// parseListOptions lê page, limit, sort e order da query string
+func parseListOptions(r *http.Request) (domain.ListOptions, error) {
+	query := r.URL.Query()
+	opts := domain.ListOptions{Page: 1, Limit: domain.DefaultPageLimit, Sort: domain.SortByMunicipio}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		page, err := strconv.ParseInt(pageStr, 10, 64)
+		if err != nil || page < 1 {
+			return opts, fmt.Errorf("Página inválida")
+		}
+		opts.Page = page
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 || limit > domain.MaxPageLimit {
+			return opts, fmt.Errorf("Limite inválido: use um valor entre 1 e %d", domain.MaxPageLimit)
+		}
+		opts.Limit = limit
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", domain.SortByMunicipio:
+	case domain.SortByPopulacao:
+		opts.Sort = sort
+	default:
+		return opts, fmt.Errorf("Ordenação inválida: use municipio ou populacao")
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, fmt.Errorf("Ordem inválida: use asc ou desc")
+	}
+
+	return opts, nil
+}
+
+func sortOrder(opts domain.ListOptions) string {
+	if opts.Desc {
+		return "desc"
+	}
+	return "asc"
 }
 
 // respondWithJSON envia resposta JSON
@@ -169,18 +695,43 @@ func respondWithError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+// RouterOptions configura os middlewares da API
+type RouterOptions struct {
+	// AllowedOrigins são as origens liberadas pelo CORS ("*" = qualquer uma);
+	// vazio desliga os headers CORS
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// LogRequests registra cada requisição no log
+	LogRequests bool
+}
+
 // SetupRoutes configura as rotas da API
-func (api *API) SetupRoutes() *mux.Router {
+func (api *API) SetupRoutes(opts RouterOptions) *mux.Router {
 	router := mux.NewRouter()
 
 	// Middleware de logging
-	router.Use(loggingMiddleware)
-	router.Use(corsMiddleware)
+	if opts.LogRequests {
+		router.Use(loggingMiddleware)
+	}
+	router.Use(corsMiddleware(opts))
+	router.Use(api.datasetVersionMiddleware)
 
 	// Rotas
 	router.HandleFunc("/health", api.HealthCheckHandler).Methods("GET")
 	router.HandleFunc("/location/{municipio}", api.GetLocationByNameHandler).Methods("GET")
 	router.HandleFunc("/nearby", api.GetNearbyLocationsHandler).Methods("GET")
+	router.HandleFunc("/bbox", api.GetLocationsInBBoxHandler).Methods("GET")
+	router.HandleFunc("/ibge/{codigo}", api.GetLocationByCodigoIBGEHandler).Methods("GET")
+	router.HandleFunc("/ibge/{codigo}/hierarquia", api.GetHierarchyByCodigoIBGEHandler).Methods("GET")
+	router.HandleFunc("/hierarquia", api.GetHierarchyByCoordinateHandler).Methods("GET")
+	router.HandleFunc("/reverse", api.ReverseGeocodeHandler).Methods("GET")
+	router.HandleFunc("/cep/{cep}", api.GetLocationByCEPHandler).Methods("GET")
+	router.HandleFunc("/estados", api.ListStatesHandler).Methods("GET")
+	router.HandleFunc("/estados/{uf}", api.GetStateHandler).Methods("GET")
+	router.HandleFunc("/estados/{uf}/municipios", api.ListMunicipiosByStateHandler).Methods("GET")
+	router.HandleFunc("/regioes/{regiao}", api.GetRegionHandler).Methods("GET")
+	router.HandleFunc("/regioes/{nivel}/{codigo}", api.ListRegionMembersHandler).Methods("GET")
 
 	return router
 }
@@ -195,18 +746,64 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// corsMiddleware adiciona headers CORS
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// corsMiddleware adiciona headers CORS para as origens de opts. Com uma lista
+// de origens, a origem da requisição é devolvida quando liberada, com
+// Vary: Origin para que caches não misturem as respostas.
+func corsMiddleware(opts RouterOptions) mux.MiddlewareFunc {
+	anyOrigin := false
+	allowed := make(map[string]bool, len(opts.AllowedOrigins))
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
 		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := ""
+			if anyOrigin {
+				origin = "*"
+			} else if len(allowed) > 0 {
+				w.Header().Add("Vary", "Origin")
+				if requested := r.Header.Get("Origin"); allowed[requested] {
+					origin = requested
+				}
+			}
+
+			if origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Expose-Headers", DatasetVersionHeader+", "+DatasetNameHeader)
+			}
+
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
+// Headers com o snapshot dos dados que responderam a requisição; ausentes
+// quando nenhum snapshot está ativo (dados alterados depois do último)
+const (
+	DatasetVersionHeader = "X-Dataset-Version"
+	DatasetNameHeader    = "X-Dataset-Name"
+)
+
+// datasetVersionMiddleware informa a versão dos dados em todas as respostas
+func (api *API) datasetVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version := api.activeDataset(r.Context()); version != nil {
+			w.Header().Set(DatasetVersionHeader, strconv.Itoa(version.Versao))
+			w.Header().Set(DatasetNameHeader, version.Nome)
+		}
 		next.ServeHTTP(w, r)
 	})
 }
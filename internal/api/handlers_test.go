@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCodigoIBGEValidation(t *testing.T) {
+	// Os códigos inválidos são recusados antes de qualquer consulta, então a
+	// API não precisa de serviços
+	api := NewAPI(nil, nil, nil, nil)
+	handlers := map[string]http.HandlerFunc{
+		"municipios": api.GetLocationByCodigoIBGEHandler,
+		"hierarquia": api.GetHierarchyByCodigoIBGEHandler,
+	}
+
+	for name, handler := range handlers {
+		for _, codigo := range []string{"+123456", "-123456", "123456", "12345678", "35O3208", " 355030", "3550 08", ""} {
+			t.Run(name+"/"+codigo, func(t *testing.T) {
+				r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"codigo": codigo})
+				w := httptest.NewRecorder()
+				handler(w, r)
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("código %q: status %d, esperado 400", codigo, w.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestRegionCodeValidation(t *testing.T) {
+	api := NewAPI(nil, nil, nil, nil)
+	for _, codigo := range []string{"+3501", "-3501", "35a", ""} {
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"nivel": "imediata", "codigo": codigo})
+		w := httptest.NewRecorder()
+		api.ListRegionMembersHandler(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("código %q: status %d, esperado 400", codigo, w.Code)
+		}
+	}
+}
+
+func TestIsDigits(t *testing.T) {
+	tests := map[string]bool{"3550308": true, "0": true, "": false, "+1": false, "-1": false, "1.0": false, "١٢": false}
+	for s, want := range tests {
+		if got := isDigits(s); got != want {
+			t.Errorf("isDigits(%q) = %v, esperado %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Margem, em graus (~10 km), dos retângulos das UFs na auditoria
+const auditBBoxMargin = 0.1
+
+// Palavras que podem aparecer em minúsculas no meio de nomes de municípios
+var lowercaseConnectors = map[string]bool{
+	"d": true, "da": true, "das": true, "de": true, "del": true, "do": true, "dos": true, "e": true,
+}
+
+type AuditService struct {
+	repo       domainIF.IGeoRepository
+	boundaries domainIF.IBoundaryRepository
+}
+
+// NewAuditService cria o serviço; boundaries pode ser nil para auditar a UF
+// apenas pelos retângulos
+func NewAuditService(repo domainIF.IGeoRepository, boundaries domainIF.IBoundaryRepository) *AuditService {
+	return &AuditService{
+		repo:       repo,
+		boundaries: boundaries,
+	}
+}
+
+// auditReport acumula os problemas, listando no máximo maxSamples por verificação
+type auditReport struct {
+	*domain.AuditReport
+	maxSamples int
+}
+
+func (r *auditReport) add(issue domain.AuditIssue) {
+	r.Counts[issue.Check]++
+	if r.maxSamples < 0 || r.Counts[issue.Check] <= int64(r.maxSamples) {
+		r.Issues = append(r.Issues, issue)
+	}
+}
+
+func newAuditIssue(check string, location domain.Location, detail string) domain.AuditIssue {
+	return domain.AuditIssue{
+		Check:      check,
+		Municipio:  location.Municipio,
+		Estado:     location.Estado,
+		CodigoIBGE: location.CodigoIBGE,
+		GeoNameID:  location.GeoNameID,
+		Latitude:   location.Localizacao.Coordinates[1],
+		Longitude:  location.Localizacao.Coordinates[0],
+		Detail:     detail,
+	}
+}
+
+// Audit percorre a coleção e aponta duplicatas (mesmo nome normalizado e UF a
+// até opts.DuplicateDistanceKm), pontos fora da UF declarada, população
+// ausente, grafia suspeita do nome e capitais ausentes.
+//
+// A população ausente só é cobrada de municípios (com código IBGE) quando a
+// coleção tem códigos vinculados, já que vilas e distritos do GeoNames
+// raramente têm população.
+func (as *AuditService) Audit(ctx context.Context, opts domain.AuditOptions) (*domain.AuditReport, error) {
+	if opts.DuplicateDistanceKm <= 0 {
+		opts.DuplicateDistanceKm = domain.DefaultAuditDuplicateKm
+	}
+	if opts.MaxSamples == 0 {
+		opts.MaxSamples = domain.DefaultAuditSamples
+	}
+	if opts.Polygons && as.boundaries == nil {
+		return nil, fmt.Errorf("limites municipais indisponíveis para a auditoria por polígonos")
+	}
+
+	start := time.Now()
+	report := &auditReport{
+		AuditReport: &domain.AuditReport{
+			Collection:          opts.Collection,
+			GeneratedAt:         start.UTC(),
+			DuplicateDistanceKm: opts.DuplicateDistanceKm,
+			Polygons:            opts.Polygons,
+			Counts:              map[string]int64{},
+			Issues:              []domain.AuditIssue{},
+		},
+		maxSamples: opts.MaxSamples,
+	}
+	for _, check := range domain.AuditChecks {
+		report.Counts[check] = 0
+	}
+
+	repo := as.repo
+	if opts.Collection != "" {
+		repo = repo.WithCollection(opts.Collection)
+	}
+
+	byName := map[string][]domain.Location{}
+	capitals := map[string]bool{}
+	var withoutPopulation, municipiosWithoutPopulation []domain.Location
+	var withCode int64
+	var withoutBoundary []domain.AuditIssue
+	boundariesFound := false
+
+	err := repo.ForEachLocation(ctx, func(location domain.Location) error {
+		report.Locations++
+		key := location.Estado + "|" + utils.FoldKey(location.Municipio)
+		// Só o necessário para comparar as duplicatas
+		byName[key] = append(byName[key], domain.Location{
+			Municipio:   location.Municipio,
+			Estado:      location.Estado,
+			CodigoIBGE:  location.CodigoIBGE,
+			GeoNameID:   location.GeoNameID,
+			Localizacao: location.Localizacao,
+		})
+
+		if state, ok := domain.StateByUF(location.Estado); ok && utils.FoldKey(state.Capital) == utils.FoldKey(location.Municipio) {
+			capitals[state.UF] = true
+		}
+
+		if detail := outsideStateBBox(location); detail != "" {
+			report.add(newAuditIssue(domain.AuditOutsideState, location, detail))
+		} else if opts.Polygons {
+			lon, lat := location.Localizacao.Coordinates[0], location.Localizacao.Coordinates[1]
+			boundary, err := as.boundaries.FindContaining(ctx, lon, lat)
+			if err != nil {
+				return fmt.Errorf("erro ao buscar limite municipal: %v", err)
+			}
+			if boundary == nil {
+				withoutBoundary = append(withoutBoundary, newAuditIssue(domain.AuditOutsideState, location, "fora de todos os limites municipais"))
+			} else {
+				boundariesFound = true
+				if boundary.Estado != location.Estado {
+					report.add(newAuditIssue(domain.AuditOutsideState, location, fmt.Sprintf("dentro do limite de %s (%s)", boundary.Municipio, boundary.Estado)))
+				}
+			}
+		}
+
+		if location.CodigoIBGE != "" {
+			withCode++
+		}
+		if location.Populacao <= 0 {
+			if location.CodigoIBGE != "" {
+				municipiosWithoutPopulation = append(municipiosWithoutPopulation, location)
+			} else if withCode == 0 {
+				// Enquanto nenhum código apareceu, guarda os demais para o
+				// caso de a coleção não ter códigos vinculados
+				withoutPopulation = append(withoutPopulation, location)
+			}
+		}
+
+		if detail := suspiciousCasing(location.Municipio); detail != "" {
+			report.add(newAuditIssue(domain.AuditNameCasing, location, detail))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao auditar localizações: %v", err)
+	}
+
+	auditDuplicates(report, byName, opts.DuplicateDistanceKm)
+
+	if withCode > 0 {
+		for _, location := range municipiosWithoutPopulation {
+			report.add(newAuditIssue(domain.AuditMissingPopulation, location, ""))
+		}
+	} else {
+		for _, location := range withoutPopulation {
+			report.add(newAuditIssue(domain.AuditMissingPopulation, location, ""))
+		}
+		report.Notes = append(report.Notes, "nenhum código IBGE vinculado: a população foi cobrada de todas as localizações")
+	}
+
+	if opts.Polygons {
+		if boundariesFound {
+			for _, issue := range withoutBoundary {
+				report.add(issue)
+			}
+		} else {
+			report.Notes = append(report.Notes, "nenhum limite municipal encontrado: importe a malha com import limites para auditar por polígonos")
+		}
+	}
+
+	for _, state := range domain.States {
+		if !capitals[state.UF] {
+			report.add(domain.AuditIssue{
+				Check:     domain.AuditMissingCapital,
+				Municipio: state.Capital,
+				Estado:    state.UF,
+				Detail:    "capital de " + state.Nome + " não encontrada",
+			})
+		}
+	}
+
+	sortAuditIssues(report.Issues)
+	report.DurationSeconds = time.Since(start).Seconds()
+	return report.AuditReport, nil
+}
+
+// outsideStateBBox retorna o motivo se o ponto está fora dos retângulos da UF
+func outsideStateBBox(location domain.Location) string {
+	bboxes := domain.StateBBoxes(location.Estado)
+	if bboxes == nil {
+		return "UF desconhecida"
+	}
+
+	lon, lat := location.Localizacao.Coordinates[0], location.Localizacao.Coordinates[1]
+	for _, bbox := range bboxes {
+		if bbox.Contains(lon, lat, auditBBoxMargin) {
+			return ""
+		}
+	}
+	return "fora do retângulo de " + location.Estado
+}
+
+// auditDuplicates compara, dentro de cada grupo de mesmo nome e UF, os pares
+// de localizações a até maxKm
+func auditDuplicates(report *auditReport, byName map[string][]domain.Location, maxKm float64) {
+	keys := make([]string, 0, len(byName))
+	for key, group := range byName {
+		if len(group) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := byName[key]
+		for i := 0; i < len(group); i++ {
+			for j := i + 1; j < len(group); j++ {
+				a, b := group[i].Localizacao.Coordinates, group[j].Localizacao.Coordinates
+				distance := utils.HaversineKm(a[1], a[0], b[1], b[0])
+				if distance > maxKm {
+					continue
+				}
+
+				other := group[j].Municipio
+				if group[j].GeoNameID != 0 {
+					other += fmt.Sprintf(" (geonameid %d)", group[j].GeoNameID)
+				}
+				report.add(newAuditIssue(domain.AuditDuplicate, group[i], fmt.Sprintf("a %.1f km de %s", distance, other)))
+			}
+		}
+	}
+}
+
+// suspiciousCasing aponta nomes com espaços extras, palavras em caixa alta,
+// palavras iniciadas em minúscula que não são conectivos ("Campo grande") ou
+// maiúsculas no meio da palavra
+func suspiciousCasing(name string) string {
+	if name != strings.TrimSpace(name) || strings.Contains(name, "  ") {
+		return "espaços extras"
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '\'' || r == '’'
+	})
+	for _, word := range words {
+		runes := []rune(word)
+		upper := 0
+		for _, r := range runes {
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+
+		switch {
+		case len(runes) > 1 && upper == len(runes):
+			return fmt.Sprintf("%q em caixa alta", word)
+		case unicode.IsLower(runes[0]) && !lowercaseConnectors[word]:
+			return fmt.Sprintf("%q começa com minúscula", word)
+		case upper > 1 || (upper == 1 && !unicode.IsUpper(runes[0])):
+			return fmt.Sprintf("%q com maiúscula no meio da palavra", word)
+		}
+	}
+	return ""
+}
+
+// sortAuditIssues ordena pela ordem das verificações, UF e município
+func sortAuditIssues(issues []domain.AuditIssue) {
+	order := make(map[string]int, len(domain.AuditChecks))
+	for i, check := range domain.AuditChecks {
+		order[check] = i
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Check != b.Check {
+			return order[a.Check] < order[b.Check]
+		}
+		if a.Estado != b.Estado {
+			return a.Estado < b.Estado
+		}
+		return a.Municipio < b.Municipio
+	})
+}
+
+// WriteAuditMarkdown grava o relatório da auditoria em Markdown, com o resumo
+// e uma tabela por verificação
+func WriteAuditMarkdown(w io.Writer, report *domain.AuditReport) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Auditoria de qualidade dos dados\n\n")
+	if report.Collection != "" {
+		fmt.Fprintf(&b, "- Coleção: `%s`\n", report.Collection)
+	}
+	fmt.Fprintf(&b, "- Gerado em: %s\n", report.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Localizações: %d\n", report.Locations)
+	method := "retângulo da UF"
+	if report.Polygons {
+		method = "limite municipal"
+	}
+	fmt.Fprintf(&b, "- Duplicatas a até %.1f km; UF conferida pelo %s\n\n", report.DuplicateDistanceKm, method)
+
+	for _, note := range report.Notes {
+		fmt.Fprintf(&b, "> %s\n\n", note)
+	}
+
+	fmt.Fprintf(&b, "| Verificação | Problemas |\n|---|---:|\n")
+	for _, check := range domain.AuditChecks {
+		fmt.Fprintf(&b, "| %s | %d |\n", check, report.Counts[check])
+	}
+
+	for _, check := range domain.AuditChecks {
+		var issues []domain.AuditIssue
+		for _, issue := range report.Issues {
+			if issue.Check == check {
+				issues = append(issues, issue)
+			}
+		}
+		if len(issues) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n", check)
+		if total := report.Counts[check]; total > int64(len(issues)) {
+			fmt.Fprintf(&b, "_Mostrando %d de %d._\n\n", len(issues), total)
+		}
+		fmt.Fprintf(&b, "| Município | UF | Código IBGE | Latitude | Longitude | Detalhe |\n|---|---|---|---:|---:|---|\n")
+		for _, issue := range issues {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				markdownCell(issue.Municipio), issue.Estado, issue.CodigoIBGE,
+				markdownCoordinate(issue.Latitude), markdownCoordinate(issue.Longitude), markdownCell(issue.Detail))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
+
+func markdownCoordinate(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.4f", value)
+}
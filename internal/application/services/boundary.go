@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/shapefile"
+)
+
+// boundaryBatchSize é menor que o das localizações: cada limite pode ter
+// dezenas de milhares de vértices
+const boundaryBatchSize = 100
+
+// Nomes de propriedade (ou coluna do DBF) aceitos, após FoldKey, na malha municipal do IBGE
+// ("CD_MUN", "NM_MUN", "AREA_KM2") e em malhas mais antigas ("CD_GEOCMU",
+// "NM_MUNICIP"). A UF vem sempre do código do município.
+var (
+	boundaryCodeProperties = []string{"cd mun", "cd geocmu", "cd geocodm", "codigo ibge", "cod ibge", "codarea", "id"}
+	boundaryNameProperties = []string{"nm mun", "nm municip", "nome municipio", "municipio", "nome", "name"}
+	boundaryAreaProperties = []string{"area km2", "area"}
+)
+
+type BoundaryService struct {
+	repo domainIF.IBoundaryRepository
+}
+
+func NewBoundaryService(repo domainIF.IBoundaryRepository) *BoundaryService {
+	return &BoundaryService{
+		repo: repo,
+	}
+}
+
+// boundaryFeature é um município lido da malha, antes da validação
+type boundaryFeature struct {
+	codigo   string
+	nome     string
+	area     string
+	geometry domain.MultiPolygon
+	err      error // geometria inválida
+}
+
+// ImportBoundaries importa a malha municipal do IBGE em GeoJSON (FeatureCollection
+// de Polygon/MultiPolygon), substituindo os limites existentes
+func (bs *BoundaryService) ImportBoundaries(ctx context.Context, r io.Reader) error {
+	return bs.importBoundaries(ctx, func(add func(boundaryFeature) error) error {
+		return readGeoJSONFeatures(r, func(feature geoJSONFeature) error {
+			geometry, err := toMultiPolygon(feature.Geometry)
+			return add(boundaryFeature{
+				codigo:   geoJSONProperty(feature.Properties, boundaryCodeProperties),
+				nome:     geoJSONProperty(feature.Properties, boundaryNameProperties),
+				area:     geoJSONProperty(feature.Properties, boundaryAreaProperties),
+				geometry: geometry,
+				err:      err,
+			})
+		})
+	})
+}
+
+// ImportBoundariesShapefile importa a malha municipal do IBGE no formato em que
+// ela é distribuída (shapefile, .shp ou .zip), substituindo os limites existentes
+func (bs *BoundaryService) ImportBoundariesShapefile(ctx context.Context, path string) error {
+	reader, err := shapefile.Open(path)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir shapefile: %w", err)
+	}
+	defer reader.Close()
+
+	if !reader.ShapeType().IsPolygon() {
+		return fmt.Errorf("o shapefile de limites precisa ser uma camada de polígonos")
+	}
+
+	fields := reader.Fields()
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+	value := func(values []string, names []string) string {
+		if i := findColumn(header, names); i >= 0 {
+			return values[i]
+		}
+		return ""
+	}
+
+	return bs.importBoundaries(ctx, func(add func(boundaryFeature) error) error {
+		for {
+			record, err := reader.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if record.Deleted {
+				continue
+			}
+
+			values := record.Values(fields)
+			feature := boundaryFeature{
+				codigo: value(values, boundaryCodeProperties),
+				nome:   value(values, boundaryNameProperties),
+				area:   value(values, boundaryAreaProperties),
+			}
+			if polygon, ok := record.Shape.(shapefile.Polygon); ok {
+				feature.geometry, feature.err = cleanMultiPolygon(polygon.Polygons())
+			} else {
+				feature.err = fmt.Errorf("registro sem geometria")
+			}
+
+			if err := add(feature); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// importBoundaries substitui os limites pelos municípios que read entrega a
+// add. Eles são gravados em uma coleção de preparo, que só substitui a de
+// limites depois que a leitura termina sem erro com pelo menos um município:
+// uma malha truncada ou corrompida não apaga os limites em uso.
+func (bs *BoundaryService) importBoundaries(ctx context.Context, read func(add func(boundaryFeature) error) error) error {
+	staging := bs.repo.WithCollection(bs.repo.CollectionName() + "_staging")
+	if err := staging.DropCollection(ctx); err != nil {
+		return err
+	}
+	// Descarta o preparo em caso de erro; após a troca, ele já foi removido
+	swapped := false
+	defer func() {
+		if !swapped {
+			staging.DropCollection(context.Background())
+		}
+	}()
+	// Os índices no preparo recusam polígonos inválidos e códigos repetidos
+	if err := staging.CreateIndexes(ctx); err != nil {
+		return err
+	}
+
+	var batch []domain.Boundary
+	var rejectedByDB, duplicates []string
+	count := 0
+	rejectedCode := 0
+	rejectedGeometry := 0
+
+	flush := func() error {
+		rejected, duplicated, err := staging.InsertBoundaries(ctx, batch)
+		if err != nil {
+			return err
+		}
+		rejectedByDB = append(rejectedByDB, rejected...)
+		duplicates = append(duplicates, duplicated...)
+		count += len(batch) - len(rejected) - len(duplicated)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := read(func(feature boundaryFeature) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		codigo := feature.codigo
+		state, ok := domain.StateByCodigoIBGE(codigo)
+		if len(codigo) != 7 || !isDigits(codigo) || !ok {
+			rejectedCode++
+			return nil
+		}
+
+		if feature.err != nil {
+			log.Printf("⚠️ Limite %s ignorado: %v", codigo, feature.err)
+			rejectedGeometry++
+			return nil
+		}
+
+		boundary := domain.Boundary{
+			CodigoIBGE: codigo,
+			Municipio:  utils.NormalizeMunicipio(feature.nome),
+			Estado:     state.UF,
+			Geometria:  feature.geometry,
+		}
+		if area, err := strconv.ParseFloat(feature.area, 64); err == nil {
+			boundary.AreaKm2 = area
+		}
+
+		batch = append(batch, boundary)
+		if len(batch) >= boundaryBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("nenhum limite válido no arquivo; os limites existentes foram mantidos")
+	}
+
+	if err := staging.CopyTo(ctx, bs.repo.CollectionName()); err != nil {
+		return err
+	}
+	if err := bs.repo.CreateIndexes(ctx); err != nil {
+		return err
+	}
+	swapped = true
+	if err := staging.DropCollection(ctx); err != nil {
+		log.Printf("⚠️ Erro ao remover a coleção de preparo dos limites: %v", err)
+	}
+
+	log.Printf("✅ Importação de limites municipais concluída! Total: %d municípios", count)
+	log.Printf("   - Rejeitados por código IBGE inválido: %d", rejectedCode)
+	log.Printf("   - Rejeitados por geometria inválida: %d", rejectedGeometry)
+	if len(rejectedByDB) > 0 {
+		log.Printf("   - Recusados pelo índice 2dsphere (polígono inválido): %d %v", len(rejectedByDB), rejectedByDB)
+	}
+	if len(duplicates) > 0 {
+		log.Printf("   - Ignorados por código IBGE repetido no arquivo: %d %v", len(duplicates), duplicates)
+	}
+	return nil
+}
+
+// FindMunicipioContaining busca o município cujo limite contém o ponto
+func (bs *BoundaryService) FindMunicipioContaining(ctx context.Context, longitude, latitude float64) (*domain.Boundary, error) {
+	return bs.repo.FindContaining(ctx, longitude, latitude)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+)
+
+// fakeBoundaryStore guarda as coleções de limites em memória
+type fakeBoundaryStore struct {
+	collections map[string][]domain.Boundary
+}
+
+// fakeBoundaryRepository é uma coleção de fakeBoundaryStore
+type fakeBoundaryRepository struct {
+	store *fakeBoundaryStore
+	name  string
+}
+
+func newFakeBoundaryRepository(existing ...domain.Boundary) *fakeBoundaryRepository {
+	store := &fakeBoundaryStore{collections: map[string][]domain.Boundary{}}
+	if len(existing) > 0 {
+		store.collections["limites_municipais"] = existing
+	}
+	return &fakeBoundaryRepository{store: store, name: "limites_municipais"}
+}
+
+func (r *fakeBoundaryRepository) WithCollection(name string) domainIF.IBoundaryRepository {
+	return &fakeBoundaryRepository{store: r.store, name: name}
+}
+
+func (r *fakeBoundaryRepository) CollectionName() string { return r.name }
+
+func (r *fakeBoundaryRepository) CopyTo(ctx context.Context, target string) error {
+	r.store.collections[target] = append([]domain.Boundary(nil), r.store.collections[r.name]...)
+	return nil
+}
+
+func (r *fakeBoundaryRepository) CreateIndexes(ctx context.Context) error { return nil }
+
+func (r *fakeBoundaryRepository) InsertBoundaries(ctx context.Context, boundaries []domain.Boundary) ([]string, []string, error) {
+	var duplicates []string
+	for _, b := range boundaries {
+		exists := false
+		for _, current := range r.store.collections[r.name] {
+			exists = exists || current.CodigoIBGE == b.CodigoIBGE
+		}
+		if exists {
+			duplicates = append(duplicates, b.CodigoIBGE)
+			continue
+		}
+		r.store.collections[r.name] = append(r.store.collections[r.name], b)
+	}
+	return nil, duplicates, nil
+}
+
+func (r *fakeBoundaryRepository) FindContaining(ctx context.Context, longitude, latitude float64) (*domain.Boundary, error) {
+	return nil, nil
+}
+
+func (r *fakeBoundaryRepository) DropCollection(ctx context.Context) error {
+	delete(r.store.collections, r.name)
+	return nil
+}
+
+// boundaryFeatureJSON é um município com um quadrado como limite
+func boundaryFeatureJSON(codigo, nome string) string {
+	return `{"type":"Feature","properties":{"CD_MUN":"` + codigo + `","NM_MUN":"` + nome + `"},` +
+		`"geometry":{"type":"Polygon","coordinates":[[[-47,-23],[-46,-23],[-46,-22],[-47,-22],[-47,-23]]]}}`
+}
+
+func TestImportBoundariesReplacesCollection(t *testing.T) {
+	repo := newFakeBoundaryRepository(domain.Boundary{CodigoIBGE: "3509502", Municipio: "Antigo"})
+	data := `{"type":"FeatureCollection","features":[` +
+		boundaryFeatureJSON("3550308", "São Paulo") + "," +
+		boundaryFeatureJSON("3550308", "São Paulo") + "," +
+		boundaryFeatureJSON("3304557", "Rio de Janeiro") + `]}`
+
+	if err := NewBoundaryService(repo).ImportBoundaries(context.Background(), strings.NewReader(data)); err != nil {
+		t.Fatalf("ImportBoundaries: %v", err)
+	}
+
+	current := repo.store.collections["limites_municipais"]
+	if len(current) != 2 || current[0].CodigoIBGE != "3550308" || current[1].Estado != "RJ" {
+		t.Errorf("limites = %+v, esperado São Paulo e Rio de Janeiro", current)
+	}
+	if _, ok := repo.store.collections["limites_municipais_staging"]; ok {
+		t.Errorf("coleção de preparo não foi removida")
+	}
+}
+
+func TestImportBoundariesKeepsExistingOnFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "GeoJSON truncado",
+			data: `{"type":"FeatureCollection","features":[` + boundaryFeatureJSON("3550308", "São Paulo") + `,{"type":"Feat`,
+			want: "",
+		},
+		{
+			name: "nenhum município válido",
+			data: `{"type":"FeatureCollection","features":[` + boundaryFeatureJSON("99", "Inválido") + `]}`,
+			want: "nenhum limite válido",
+		},
+		{
+			name: "arquivo vazio",
+			data: ``,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := domain.Boundary{CodigoIBGE: "3509502", Municipio: "Campinas"}
+			repo := newFakeBoundaryRepository(existing)
+
+			err := NewBoundaryService(repo).ImportBoundaries(context.Background(), strings.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ImportBoundaries = %v, esperado erro com %q", err, tt.want)
+			}
+			if current := repo.store.collections["limites_municipais"]; len(current) != 1 || current[0].CodigoIBGE != existing.CodigoIBGE {
+				t.Errorf("limites em uso = %+v, esperado manter Campinas", current)
+			}
+			if _, ok := repo.store.collections["limites_municipais_staging"]; ok {
+				t.Errorf("coleção de preparo não foi removida")
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/config"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+type ConflationService struct {
+	repo domainIF.IGeoRepository
+}
+
+func NewConflationService(repo domainIF.IGeoRepository) *ConflationService {
+	return &ConflationService{
+		repo: repo,
+	}
+}
+
+// conflationRecord é um registro de uma fonte casado a uma localização canônica
+type conflationRecord struct {
+	source   int
+	location domain.Location
+	criterio string
+}
+
+// conflationCluster agrupa os registros (no máximo um por fonte) de uma mesma
+// localidade. O primeiro registro é a âncora usada no casamento por distância.
+type conflationCluster struct {
+	estado  string
+	codigo  string
+	records []conflationRecord
+}
+
+func (c *conflationCluster) has(source int) bool {
+	for _, r := range c.records {
+		if r.source == source {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *conflationCluster) anchor() [2]float64 {
+	return c.records[0].location.Localizacao.Coordinates
+}
+
+// Conflate casa os registros das coleções de cada fonte e grava uma localização
+// canônica por localidade em opts.Target. Um registro casa com uma localização
+// canônica pelo código IBGE ou, sem ele, pelo nome normalizado e UF dentro de
+// opts.MaxDistanceKm; cada localização tem no máximo um registro de cada fonte.
+//
+// Cada campo vem da primeira fonte, na ordem de opts.FieldPriority (ou de
+// opts.Sources), que o tenha preenchido. Proveniencia registra a fonte de cada
+// campo e Fontes os registros de origem, para auditoria.
+func (cs *ConflationService) Conflate(ctx context.Context, opts domain.ConflationOptions) (*domain.ConflationReport, error) {
+	if opts.MaxDistanceKm <= 0 {
+		opts.MaxDistanceKm = domain.DefaultConflationDistanceKm
+	}
+	priorities, err := conflationPriorities(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	report := &domain.ConflationReport{
+		Target:          opts.Target,
+		MaxDistanceKm:   opts.MaxDistanceKm,
+		RecordsBySource: map[string]int64{},
+		MatchedBy:       map[string]int64{},
+		SingleSource:    map[string]int64{},
+		FieldsBySource:  map[string]map[string]int64{},
+		DryRun:          opts.DryRun,
+	}
+
+	var clusters []*conflationCluster
+	byCode := map[string]*conflationCluster{}
+	byName := map[string][]*conflationCluster{}
+
+	for i, source := range opts.Sources {
+		log.Printf("🔗 Lendo %s (%s)...", source.Nome, source.Colecao)
+		err := cs.repo.WithCollection(source.Colecao).ForEachLocation(ctx, func(location domain.Location) error {
+			report.RecordsBySource[source.Nome]++
+
+			codigo := location.CodigoIBGE
+			nameKey := location.Estado + "|" + utils.FoldKey(location.Municipio)
+			record := conflationRecord{source: i, location: location}
+
+			cluster := byCode[codigo]
+			if codigo != "" && cluster != nil && !cluster.has(i) {
+				record.criterio = domain.MatchByCodigoIBGE
+			} else {
+				cluster = nearestCluster(byName[nameKey], location, i, opts.MaxDistanceKm)
+				if cluster != nil {
+					record.criterio = domain.MatchByNameState
+				}
+			}
+
+			if cluster == nil {
+				cluster = &conflationCluster{estado: location.Estado}
+				clusters = append(clusters, cluster)
+			} else {
+				report.MatchedBy[record.criterio]++
+			}
+			// Casado pelo código com outro nome, o registro também passa a
+			// achar a localização pelo nome dele
+			if !containsCluster(byName[nameKey], cluster) {
+				byName[nameKey] = append(byName[nameKey], cluster)
+			}
+
+			cluster.records = append(cluster.records, record)
+			if codigo != "" && cluster.codigo == "" {
+				cluster.codigo = codigo
+				if byCode[codigo] == nil {
+					byCode[codigo] = cluster
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %v", source.Colecao, err)
+		}
+	}
+
+	locations := make([]domain.Location, len(clusters))
+	for i, cluster := range clusters {
+		locations[i] = mergeCluster(cluster, opts.Sources, priorities, report)
+		if len(cluster.records) == 1 {
+			report.SingleSource[opts.Sources[cluster.records[0].source].Nome]++
+		}
+	}
+	report.Canonical = int64(len(locations))
+
+	if !opts.DryRun {
+		if err := cs.writeCanonical(ctx, opts.Target, locations); err != nil {
+			return nil, err
+		}
+		report.Inserted = report.Canonical
+	}
+
+	report.DurationSeconds = time.Since(start).Seconds()
+	log.Printf("✅ Conflação concluída: %d localizações canônicas de %d fontes", report.Canonical, len(opts.Sources))
+	for criterio, n := range report.MatchedBy {
+		log.Printf("   - Casados por %s: %d", criterio, n)
+	}
+	for fonte, n := range report.SingleSource {
+		log.Printf("   - Apenas em %s: %d", fonte, n)
+	}
+	return report, nil
+}
+
+// conflationPriorities valida as opções e monta, para cada campo, a ordem das
+// fontes (índices em opts.Sources)
+func conflationPriorities(opts domain.ConflationOptions) (map[string][]int, error) {
+	if len(opts.Sources) == 0 {
+		return nil, fmt.Errorf("nenhuma fonte informada para a conflação")
+	}
+	if opts.Target == "" {
+		return nil, fmt.Errorf("coleção de destino da conflação não informada")
+	}
+
+	index := map[string]int{}
+	collections := map[string]bool{}
+	for i, source := range opts.Sources {
+		if source.Nome == "" || source.Colecao == "" {
+			return nil, fmt.Errorf("fonte %d sem nome ou coleção", i+1)
+		}
+		if _, ok := index[source.Nome]; ok {
+			return nil, fmt.Errorf("fonte %q repetida", source.Nome)
+		}
+		if collections[source.Colecao] {
+			return nil, fmt.Errorf("coleção %q usada por mais de uma fonte", source.Colecao)
+		}
+		if source.Colecao == opts.Target {
+			return nil, fmt.Errorf("a coleção de destino %q não pode ser uma das fontes", opts.Target)
+		}
+		index[source.Nome] = i
+		collections[source.Colecao] = true
+	}
+
+	priorities := make(map[string][]int, len(domain.ConflationFields))
+	for _, field := range domain.ConflationFields {
+		seen := map[int]bool{}
+		for _, name := range opts.FieldPriority[field] {
+			i, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("prioridade de %s: fonte %q desconhecida", field, name)
+			}
+			if !seen[i] {
+				priorities[field] = append(priorities[field], i)
+				seen[i] = true
+			}
+		}
+		for i := range opts.Sources {
+			if !seen[i] {
+				priorities[field] = append(priorities[field], i)
+			}
+		}
+	}
+
+	for field := range opts.FieldPriority {
+		if _, ok := priorities[field]; !ok {
+			return nil, fmt.Errorf("campo %q não aceita prioridade; use %v", field, domain.ConflationFields)
+		}
+	}
+	return priorities, nil
+}
+
+// nearestCluster retorna, entre os candidatos de mesmo nome e UF, o mais
+// próximo dentro de maxKm que ainda não tem registro da fonte e cujo código
+// IBGE não conflita com o do registro
+func nearestCluster(candidates []*conflationCluster, location domain.Location, source int, maxKm float64) *conflationCluster {
+	var nearest *conflationCluster
+	best := math.Inf(1)
+	for _, c := range candidates {
+		if c.has(source) {
+			continue
+		}
+		if c.codigo != "" && location.CodigoIBGE != "" && c.codigo != location.CodigoIBGE {
+			continue
+		}
+
+		anchor := c.anchor()
+		distance := utils.HaversineKm(location.Localizacao.Coordinates[1], location.Localizacao.Coordinates[0], anchor[1], anchor[0])
+		if distance <= maxKm && distance < best {
+			nearest, best = c, distance
+		}
+	}
+	return nearest
+}
+
+func containsCluster(clusters []*conflationCluster, cluster *conflationCluster) bool {
+	for _, c := range clusters {
+		if c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeCluster monta a localização canônica escolhendo cada campo pela
+// prioridade das fontes
+func mergeCluster(cluster *conflationCluster, sources []domain.ConflationSource, priorities map[string][]int, report *domain.ConflationReport) domain.Location {
+	bySource := make(map[int]*domain.Location, len(cluster.records))
+	for i := range cluster.records {
+		bySource[cluster.records[i].source] = &cluster.records[i].location
+	}
+
+	canonical := domain.Location{Estado: cluster.estado, Proveniencia: map[string]string{}}
+	pick := func(field string, has func(*domain.Location) bool, set func(*domain.Location)) {
+		for _, i := range priorities[field] {
+			if loc := bySource[i]; loc != nil && has(loc) {
+				set(loc)
+				canonical.Proveniencia[field] = sources[i].Nome
+				if report.FieldsBySource[field] == nil {
+					report.FieldsBySource[field] = map[string]int64{}
+				}
+				report.FieldsBySource[field][sources[i].Nome]++
+				return
+			}
+		}
+	}
+
+	pick(domain.FieldMunicipio,
+		func(l *domain.Location) bool { return l.Municipio != "" },
+		func(l *domain.Location) { canonical.Municipio = l.Municipio })
+	pick(domain.FieldLocalizacao,
+		func(l *domain.Location) bool { return l.Localizacao.Coordinates != [2]float64{} },
+		func(l *domain.Location) { canonical.Localizacao = l.Localizacao })
+	pick(domain.FieldPopulacao,
+		func(l *domain.Location) bool { return l.Populacao > 0 },
+		func(l *domain.Location) { canonical.Populacao = l.Populacao })
+	pick(domain.FieldCodigoIBGE,
+		func(l *domain.Location) bool { return l.CodigoIBGE != "" },
+		func(l *domain.Location) { canonical.CodigoIBGE = l.CodigoIBGE })
+	pick(domain.FieldGeoNameID,
+		func(l *domain.Location) bool { return l.GeoNameID != 0 },
+		func(l *domain.Location) {
+			// A feição vem do mesmo registro do GeoNames
+			canonical.GeoNameID, canonical.FeatureClass, canonical.FeatureCode = l.GeoNameID, l.FeatureClass, l.FeatureCode
+		})
+	pick(domain.FieldHierarquia,
+		func(l *domain.Location) bool { return l.Hierarquia != nil && !l.Hierarquia.IsEmpty() },
+		func(l *domain.Location) { canonical.Hierarquia = l.Hierarquia })
+
+	point := canonical.Localizacao.Coordinates
+	canonical.Fontes = make([]domain.SourceRecord, len(cluster.records))
+	for i, r := range cluster.records {
+		coordinates := r.location.Localizacao.Coordinates
+		canonical.Fontes[i] = domain.SourceRecord{
+			Fonte:       sources[r.source].Nome,
+			Colecao:     sources[r.source].Colecao,
+			ID:          r.location.ID,
+			Criterio:    r.criterio,
+			DistanciaKm: math.Round(utils.HaversineKm(point[1], point[0], coordinates[1], coordinates[0])*1000) / 1000,
+		}
+	}
+	return canonical
+}
+
+// writeCanonical recria a coleção canônica com os índices das listagens
+func (cs *ConflationService) writeCanonical(ctx context.Context, target string, locations []domain.Location) error {
+	repo := cs.repo.WithCollection(target)
+	if err := repo.DropCollection(ctx, target); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(locations); start += config.DefaultBatchSize {
+		end := start + config.DefaultBatchSize
+		if end > len(locations) {
+			end = len(locations)
+		}
+		if err := repo.InsertLocations(ctx, locations[start:end]); err != nil {
+			return fmt.Errorf("erro ao gravar localizações canônicas: %v", err)
+		}
+	}
+
+	if err := repo.CreateGeoIndex(ctx); err != nil {
+		return err
+	}
+	if err := repo.CreateEstadoIndex(ctx); err != nil {
+		return err
+	}
+	return repo.CreateIBGEIndex(ctx)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Colunas de coordenadas procuradas quando o mapeamento não as indica
+var (
+	mappedLatitudeColumns  = []string{"lat", "latitude", "y"}
+	mappedLongitudeColumns = []string{"lon", "lng", "long", "longitude", "x"}
+)
+
+// ImportCSV importa um CSV genérico de pontos (lojas, clínicas, filiais...) com
+// o layout descrito em csvOpts, em vez das 19 colunas fixas do GeoNames. Cada
+// linha passa pelas mesmas validações de ImportData (UF conhecida, limites do
+// Brasil) e pelo mesmo pipeline, relatório e quarentena.
+//
+// Com opts.Collection os pontos vão para uma coleção própria, que recebe os
+// índices geoespacial e por estado. Não há checkpoint nem -resume; com
+// opts.DryRun o arquivo é apenas lido e validado.
+func (is *ImportService) ImportCSV(ctx context.Context, r io.Reader, csvOpts domain.CSVOptions, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	opts = withImportDefaults(opts)
+	report := newImportReport(opts)
+
+	if opts.Resume || opts.Diff {
+		err := fmt.Errorf("-resume e -diff não se aplicam a CSVs genéricos")
+		return report.finish(0, err), err
+	}
+
+	decode, err := csvDecoder(csvOpts.Encoding)
+	if err != nil {
+		return report.finish(0, err), err
+	}
+
+	buffered := bufio.NewReaderSize(r, 1<<16)
+	reader := csv.NewReader(buffered)
+	reader.Comma = csvOpts.Delimiter
+	if reader.Comma == 0 {
+		// Peek retorna o que houver se o arquivo for menor que o buffer
+		sample, _ := buffered.Peek(1 << 12)
+		reader.Comma = detectDelimiter(string(sample))
+	}
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1 // linhas curtas são rejeitadas na validação
+
+	// Sem header, a primeira linha é de dados e as colunas são numeradas
+	first, err := reader.Read()
+	if err != nil {
+		err = fmt.Errorf("erro ao ler header: %v", err)
+		return report.finish(0, err), err
+	}
+	decodeRecord(first, decode)
+	if len(first) > 0 {
+		first[0] = strings.TrimPrefix(first[0], "\ufeff") // BOM de planilhas exportadas em UTF-8
+	}
+
+	header := first
+	var pending []string
+	if csvOpts.NoHeader {
+		header = make([]string, len(first))
+		for i := range header {
+			header[i] = strconv.Itoa(i + 1)
+		}
+		pending = first
+	}
+
+	columns, err := resolveColumnMapping(header, csvOpts.Mapping)
+	if err != nil {
+		return report.finish(0, err), err
+	}
+	latitude, err := mappedColumn(header, csvOpts.Mapping.Latitude, mappedLatitudeColumns)
+	if err == nil && latitude < 0 {
+		err = fmt.Errorf("coluna de latitude não encontrada em %v; indique-a no mapeamento", header)
+	}
+	if err != nil {
+		return report.finish(0, err), err
+	}
+	longitude, err := mappedColumn(header, csvOpts.Mapping.Longitude, mappedLongitudeColumns)
+	if err == nil && longitude < 0 {
+		err = fmt.Errorf("coluna de longitude não encontrada em %v; indique-a no mapeamento", header)
+	}
+	if err != nil {
+		return report.finish(0, err), err
+	}
+	log.Printf("📄 CSV com separador %q; colunas: %v", reader.Comma, header)
+
+	repo := is.target(opts)
+	insert := func(context.Context, []domain.Location) error { return nil }
+	if !opts.DryRun {
+		if err := repo.CreateGeoIndex(ctx); err != nil {
+			return report.finish(0, err), err
+		}
+		if err := repo.CreateEstadoIndex(ctx); err != nil {
+			return report.finish(0, err), err
+		}
+		insert = repo.InsertLocations
+	}
+
+	var row int64
+	pipeline := newImportPipeline(ctx, opts)
+	pipeline.source(func() (pipelineRow, error) {
+		row++
+		if pending != nil {
+			record := pending
+			pending = nil
+			return pipelineRow{record: record, pos: position{row: row}}, nil
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			return pipelineRow{}, io.EOF
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return pipelineRow{}, err
+			}
+		}
+		decodeRecord(record, decode)
+		return pipelineRow{record: record, err: err, pos: position{row: row}}, nil
+	})
+	pipeline.parse(func(row pipelineRow) (domain.Location, bool) {
+		if row.err != nil {
+			return domain.Location{}, report.reject(opts, row, domain.RejectMalformed, row.err.Error(), "")
+		}
+
+		lat, latOK := parseCSVCoordinate(row.record, latitude, csvOpts.DecimalComma)
+		lon, lonOK := parseCSVCoordinate(row.record, longitude, csvOpts.DecimalComma)
+		if !latOK || !lonOK {
+			detail := fmt.Sprintf("lat=%q lon=%q", fieldValue(row.record, latitude), fieldValue(row.record, longitude))
+			return domain.Location{}, report.reject(opts, row, domain.RejectCoords, detail, "")
+		}
+
+		location, reason, detail := columns.location(row.record, lon, lat)
+		if reason != "" {
+			return location, report.reject(opts, row, reason, detail, location.Estado)
+		}
+
+		report.accept(location)
+		return location, true
+	})
+	pipeline.batch(0)
+	inserted, err := pipeline.write(insert, nil)
+	if err == nil {
+		err = report.quarantineErr
+	}
+	if opts.DryRun {
+		inserted = 0
+	}
+	if err != nil {
+		return report.finish(inserted, err), err
+	}
+
+	report.finish(inserted, nil)
+	report.log()
+	return report.ImportReport, nil
+}
+
+// csvDecoder retorna a conversão para UTF-8 de cada campo
+func csvDecoder(encoding string) (func([]byte) string, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", domain.EncodingAuto:
+		return utils.EnsureUTF8, nil
+	case domain.EncodingUTF8, "utf8":
+		return func(b []byte) string { return string(b) }, nil
+	case domain.EncodingLatin1, "latin-1", "iso-8859-1", "windows-1252", "cp1252":
+		return utils.Latin1ToUTF8, nil
+	}
+	return nil, fmt.Errorf("codificação %q não suportada (use %s, %s ou %s)", encoding, domain.EncodingAuto, domain.EncodingUTF8, domain.EncodingLatin1)
+}
+
+func decodeRecord(record []string, decode func([]byte) string) {
+	for i, field := range record {
+		record[i] = decode([]byte(field))
+	}
+}
+
+// parseCSVCoordinate lê a coordenada da coluna i, aceitando vírgula decimal
+// quando decimalComma é informado
+func parseCSVCoordinate(record []string, i int, decimalComma bool) (float64, bool) {
+	value := fieldValue(record, i)
+	if decimalComma {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	coordinate, err := strconv.ParseFloat(value, 64)
+	return coordinate, err == nil
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+)
+
+// datasetCacheTTL é por quanto tempo a API reaproveita o snapshot ativo antes
+// de consultá-lo de novo; uma ativação feita pela CLI aparece nas respostas em
+// até esse tempo
+const datasetCacheTTL = 30 * time.Second
+
+// snapshotNamePattern são os nomes aceitos para snapshots (ex: geonames-2024-05)
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+type DatasetService struct {
+	repo     domainIF.IGeoRepository
+	versions domainIF.IDatasetVersionRepository
+	runs     domainIF.IImportRunRepository
+
+	mu        sync.Mutex
+	active    *domain.DatasetVersion
+	checkedAt time.Time
+}
+
+// NewDatasetService cria o serviço; runs pode ser nil, e então os metadados
+// da importação só vêm de SnapshotOptions
+func NewDatasetService(repo domainIF.IGeoRepository, versions domainIF.IDatasetVersionRepository, runs domainIF.IImportRunRepository) *DatasetService {
+	return &DatasetService{
+		repo:     repo,
+		versions: versions,
+		runs:     runs,
+	}
+}
+
+// CreateSnapshot copia a coleção principal para uma coleção do snapshot, com
+// as contagens e os metadados da importação, e o marca como ativo: ele
+// descreve os dados que a API está servindo
+func (ds *DatasetService) CreateSnapshot(ctx context.Context, opts domain.SnapshotOptions) (*domain.DatasetVersion, error) {
+	if !snapshotNamePattern.MatchString(opts.Nome) {
+		return nil, fmt.Errorf("nome de snapshot inválido %q: use letras, números, '.', '_' e '-' (até 64)", opts.Nome)
+	}
+	existing, err := ds.versions.FindByName(ctx, opts.Nome)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("já existe um snapshot com o nome %q (versão %d)", opts.Nome, existing.Versao)
+	}
+
+	versao, err := ds.versions.NextVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version := &domain.DatasetVersion{
+		Nome:         opts.Nome,
+		Versao:       versao,
+		Colecao:      fmt.Sprintf("%s_snapshot_v%d", ds.repo.CollectionName(), versao),
+		Fonte:        opts.Fonte,
+		SourceSHA256: opts.SourceSHA256,
+		CriadoEm:     time.Now().UTC(),
+		PorEstado:    map[string]int64{},
+	}
+	if version.SourceSHA256 == "" && ds.runs != nil {
+		run, err := ds.runs.FindLastCompleted(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if run != nil {
+			version.Fonte, version.SourceSHA256, version.ImportadoEm = run.Source, run.SourceSHA256, run.FinishedAt
+		}
+	}
+
+	if err := ds.repo.CopyTo(ctx, version.Colecao); err != nil {
+		return nil, err
+	}
+	totals, err := ds.repo.WithCollection(version.Colecao).GetStateTotals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar localizações do snapshot: %v", err)
+	}
+	for _, t := range totals {
+		version.PorEstado[t.Estado] = t.Localizacoes
+		version.Total += t.Localizacoes
+	}
+
+	if err := ds.versions.CreateVersion(ctx, version); err != nil {
+		ds.repo.WithCollection(version.Colecao).DropCollection(ctx, version.Colecao)
+		return nil, err
+	}
+	if err := ds.versions.SetActive(ctx, version.Nome); err != nil {
+		return nil, err
+	}
+	version.Ativo = true
+	ds.forget()
+
+	log.Printf("✅ Snapshot %s (versão %d) criado com %d localizações", version.Nome, version.Versao, version.Total)
+	return version, nil
+}
+
+// ListSnapshots retorna os snapshots da versão mais recente para a mais antiga
+func (ds *DatasetService) ListSnapshots(ctx context.Context) ([]domain.DatasetVersion, error) {
+	return ds.versions.ListVersions(ctx)
+}
+
+// ActivateSnapshot substitui a coleção principal pela cópia do snapshot (a
+// troca é atômica: as consultas veem os dados antigos ou os novos, nunca uma
+// mistura) e recria os índices das consultas
+func (ds *DatasetService) ActivateSnapshot(ctx context.Context, nome string) (*domain.DatasetVersion, error) {
+	version, err := ds.versions.FindByName(ctx, nome)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, nil
+	}
+
+	if err := ds.repo.WithCollection(version.Colecao).CopyTo(ctx, ds.repo.CollectionName()); err != nil {
+		return nil, err
+	}
+	if err := ds.repo.CreateGeoIndex(ctx); err != nil {
+		return nil, err
+	}
+	if err := ds.repo.CreateTextIndex(ctx); err != nil {
+		return nil, err
+	}
+	if err := ds.repo.CreateIBGEIndex(ctx); err != nil {
+		return nil, err
+	}
+	if err := ds.repo.CreateEstadoIndex(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := ds.versions.SetActive(ctx, nome); err != nil {
+		return nil, err
+	}
+	ds.forget()
+
+	version.Ativo = true
+	log.Printf("✅ Snapshot %s (versão %d) ativado", version.Nome, version.Versao)
+	return version, nil
+}
+
+// DeleteSnapshot remove o snapshot e a sua coleção. O snapshot ativo não pode
+// ser removido: seria a única cópia para voltar aos dados em uso.
+func (ds *DatasetService) DeleteSnapshot(ctx context.Context, nome string) (*domain.DatasetVersion, error) {
+	version, err := ds.versions.FindByName(ctx, nome)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, nil
+	}
+	if version.Ativo {
+		return nil, fmt.Errorf("o snapshot %q está ativo: ative outro antes de removê-lo", nome)
+	}
+
+	if err := ds.repo.WithCollection(version.Colecao).DropCollection(ctx, version.Colecao); err != nil {
+		return nil, err
+	}
+	if err := ds.versions.DeleteVersion(ctx, nome); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// MarkModified desmarca o snapshot ativo depois de uma alteração na coleção
+// principal (importação), que deixa de corresponder a ele. Retorna o snapshot
+// desmarcado, ou nil se nenhum estava ativo.
+func (ds *DatasetService) MarkModified(ctx context.Context) (*domain.DatasetVersion, error) {
+	active, err := ds.versions.FindActive(ctx)
+	if err != nil || active == nil {
+		return nil, err
+	}
+	if err := ds.versions.ClearActive(ctx); err != nil {
+		return nil, err
+	}
+	ds.forget()
+	return active, nil
+}
+
+// ActiveVersion retorna o snapshot ativo (nil se nenhum), consultando o banco
+// no máximo uma vez a cada datasetCacheTTL. Se a consulta falhar, o último
+// valor conhecido continua valendo até a próxima tentativa.
+func (ds *DatasetService) ActiveVersion(ctx context.Context) *domain.DatasetVersion {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if !ds.checkedAt.IsZero() && time.Since(ds.checkedAt) < datasetCacheTTL {
+		return ds.active
+	}
+
+	ds.checkedAt = time.Now()
+	active, err := ds.versions.FindActive(ctx)
+	if err != nil {
+		log.Printf("⚠️ Erro ao consultar o snapshot ativo: %v", err)
+		return ds.active
+	}
+	ds.active = active
+	return ds.active
+}
+
+// forget descarta o snapshot ativo guardado por ActiveVersion
+func (ds *DatasetService) forget() {
+	ds.mu.Lock()
+	ds.checkedAt = time.Time{}
+	ds.mu.Unlock()
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+const (
+	// diffMovedThresholdKm é a distância a partir da qual um registro conta como movido
+	diffMovedThresholdKm = 1.0
+	// diffMaxSamples limita os exemplos guardados por tipo de mudança
+	diffMaxSamples = 20
+)
+
+// diffLocation guarda só os campos comparados, para manter a coleção inteira
+// em memória com pouco custo
+type diffLocation struct {
+	municipio string
+	estado    string
+	lon, lat  float64
+	populacao int
+	seen      bool
+}
+
+// datasetDiffer compara os lotes de um dry-run com a coleção atual. compare é
+// chamado pelos workers de escrita, por isso o lock.
+type datasetDiffer struct {
+	mu      sync.Mutex
+	current map[int64]*diffLocation
+	diff    domain.DatasetDiff
+	samples map[string]int
+}
+
+// newDatasetDiffer carrega a coleção atual indexada por geonameid
+func newDatasetDiffer(ctx context.Context, repo domainIF.IGeoRepository) (*datasetDiffer, error) {
+	d := &datasetDiffer{
+		current: make(map[int64]*diffLocation),
+		diff:    domain.DatasetDiff{MovedThresholdKm: diffMovedThresholdKm},
+		samples: make(map[string]int),
+	}
+
+	err := repo.ForEachLocation(ctx, func(location domain.Location) error {
+		if location.GeoNameID == 0 {
+			d.diff.CurrentWithoutID++
+			return nil
+		}
+		d.current[location.GeoNameID] = &diffLocation{
+			municipio: location.Municipio,
+			estado:    location.Estado,
+			lon:       location.Localizacao.Coordinates[0],
+			lat:       location.Localizacao.Coordinates[1],
+			populacao: location.Populacao,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar a coleção atual: %v", err)
+	}
+
+	log.Printf("🔍 Comparando com %d registros da coleção atual", len(d.current)+int(d.diff.CurrentWithoutID))
+	return d, nil
+}
+
+// compare tem a mesma assinatura de InsertLocations para ocupar o lugar da
+// escrita no pipeline; nada é gravado
+func (d *datasetDiffer) compare(ctx context.Context, locations []domain.Location) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, location := range locations {
+		lon, lat := location.Localizacao.Coordinates[0], location.Localizacao.Coordinates[1]
+
+		before, ok := d.current[location.GeoNameID]
+		if !ok {
+			d.diff.New++
+			d.sample(domain.DiffEntry{Change: domain.DiffNew, GeoNameID: location.GeoNameID,
+				Municipio: location.Municipio, Estado: location.Estado})
+			continue
+		}
+		before.seen = true
+
+		changed := false
+		if km := utils.HaversineKm(before.lat, before.lon, lat, lon); km > diffMovedThresholdKm {
+			changed = true
+			d.diff.Moved++
+			d.sample(domain.DiffEntry{Change: domain.DiffMoved, GeoNameID: location.GeoNameID,
+				Municipio: location.Municipio, Estado: location.Estado,
+				Before:     fmt.Sprintf("%.5f,%.5f", before.lat, before.lon),
+				After:      fmt.Sprintf("%.5f,%.5f", lat, lon),
+				DistanceKm: km})
+		}
+		if before.municipio != location.Municipio || before.estado != location.Estado {
+			changed = true
+			d.diff.Renamed++
+			d.sample(domain.DiffEntry{Change: domain.DiffRenamed, GeoNameID: location.GeoNameID,
+				Municipio: location.Municipio, Estado: location.Estado,
+				Before: before.municipio + "/" + before.estado,
+				After:  location.Municipio + "/" + location.Estado})
+		}
+		if before.populacao != location.Populacao {
+			changed = true
+			d.diff.PopulationChanged++
+			d.sample(domain.DiffEntry{Change: domain.DiffPopulationChanged, GeoNameID: location.GeoNameID,
+				Municipio: location.Municipio, Estado: location.Estado,
+				Before: fmt.Sprint(before.populacao),
+				After:  fmt.Sprint(location.Populacao)})
+		}
+		if !changed {
+			d.diff.Unchanged++
+		}
+	}
+
+	return nil
+}
+
+func (d *datasetDiffer) sample(entry domain.DiffEntry) {
+	if d.samples[entry.Change] >= diffMaxSamples {
+		return
+	}
+	d.samples[entry.Change]++
+	d.diff.Samples = append(d.diff.Samples, entry)
+}
+
+// finish conta como removidos os registros atuais que não apareceram no arquivo
+func (d *datasetDiffer) finish() *domain.DatasetDiff {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ids := make([]int64, 0)
+	for id, location := range d.current {
+		if !location.seen {
+			d.diff.Removed++
+			ids = append(ids, id)
+		}
+	}
+
+	// Ordena para que os exemplos sejam estáveis entre execuções
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		location := d.current[id]
+		d.sample(domain.DiffEntry{Change: domain.DiffRemoved, GeoNameID: id,
+			Municipio: location.municipio, Estado: location.estado})
+	}
+
+	diff := d.diff
+	return &diff
+}
+
+// logDiff registra o resumo do diff no formato do resumo da importação
+func logDiff(diff *domain.DatasetDiff) {
+	log.Printf("🔍 Diferenças em relação à coleção atual:")
+	log.Printf("   - Inalterados: %d", diff.Unchanged)
+	log.Printf("   - Novos: %d", diff.New)
+	log.Printf("   - Removidos: %d", diff.Removed)
+	log.Printf("   - Movidos mais de %.0f km: %d", diff.MovedThresholdKm, diff.Moved)
+	log.Printf("   - Renomeados: %d", diff.Renamed)
+	log.Printf("   - População alterada: %d", diff.PopulationChanged)
+	if diff.CurrentWithoutID > 0 {
+		log.Printf("   - Registros atuais sem geonameid (não comparáveis): %d", diff.CurrentWithoutID)
+	}
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/config"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Colunas do CSV exportado; os nomes são os padrão do import csv
+var exportCSVColumns = []string{"municipio", "estado", "latitude", "longitude", "populacao", "codigo_ibge", "geonameid", "feature_class", "feature_code"}
+
+type ExportService struct {
+	repo domainIF.IGeoRepository
+}
+
+func NewExportService(repo domainIF.IGeoRepository) *ExportService {
+	return &ExportService{
+		repo: repo,
+	}
+}
+
+func (es *ExportService) target(collection string) domainIF.IGeoRepository {
+	if collection == "" {
+		return es.repo
+	}
+	return es.repo.WithCollection(collection)
+}
+
+// locationWriter grava localizações em um formato de exportação
+type locationWriter interface {
+	begin() error
+	write(location domain.Location) error
+	end() error
+}
+
+// Export grava em w as localizações da coleção que casam com opts.Filter, em
+// ordem de _id, no formato opts.Format, e retorna quantas foram gravadas.
+//
+// No formato snapshot a primeira linha traz o total, conferido ao final: se a
+// coleção mudar durante a exportação, o snapshot é rejeitado em vez de ficar
+// inconsistente.
+func (es *ExportService) Export(ctx context.Context, w io.Writer, opts domain.ExportOptions) (int64, error) {
+	repo := es.target(opts.Collection)
+	buffered := bufio.NewWriter(w)
+
+	var writer locationWriter
+	var total int64
+	switch opts.Format {
+	case domain.ExportGeoNames:
+		writer = &geoNamesWriter{w: buffered}
+	case domain.ExportCSV:
+		writer = &csvLocationWriter{w: csv.NewWriter(buffered)}
+	case domain.ExportGeoJSON:
+		writer = &geoJSONWriter{w: buffered, collection: true}
+	case domain.ExportNDJSON:
+		writer = &geoJSONWriter{w: buffered}
+	case domain.ExportSnapshot:
+		var err error
+		if total, err = repo.CountLocations(ctx, opts.Filter); err != nil {
+			return 0, fmt.Errorf("erro ao contar localizações: %v", err)
+		}
+		header := domain.SnapshotHeader{
+			Formato:     domain.SnapshotFormat,
+			Versao:      domain.SnapshotVersion,
+			Colecao:     opts.Collection,
+			ExportadoEm: time.Now().UTC(),
+			Total:       total,
+		}
+		if !opts.Filter.IsEmpty() {
+			header.Filtro = &opts.Filter
+		}
+		writer = &snapshotWriter{w: buffered, header: header}
+	default:
+		return 0, fmt.Errorf("formato de exportação inválido: %s", opts.Format)
+	}
+
+	if err := writer.begin(); err != nil {
+		return 0, err
+	}
+	var written int64
+	err := repo.ForEachMatchingLocation(ctx, opts.Filter, func(location domain.Location) error {
+		written++
+		return writer.write(location)
+	})
+	if err != nil {
+		return written, fmt.Errorf("erro ao ler localizações: %v", err)
+	}
+	if opts.Format == domain.ExportSnapshot && written != total {
+		return written, fmt.Errorf("a coleção mudou durante a exportação (%d localizações contadas, %d lidas)", total, written)
+	}
+	if err := writer.end(); err != nil {
+		return written, err
+	}
+	return written, buffered.Flush()
+}
+
+// geoNamesWriter grava as 19 colunas do dump do GeoNames (BR.txt), legíveis
+// pelo import geonames. Colunas sem dado equivalente ficam vazias.
+type geoNamesWriter struct {
+	w *bufio.Writer
+}
+
+func (gw *geoNamesWriter) begin() error { return nil }
+
+func (gw *geoNamesWriter) write(location domain.Location) error {
+	state, _ := domain.StateByUF(location.Estado)
+	geonameID := ""
+	if location.GeoNameID != 0 {
+		geonameID = strconv.FormatInt(location.GeoNameID, 10)
+	}
+	fields := []string{
+		geonameID,
+		location.Municipio,
+		utils.ASCIIName(location.Municipio),
+		"", // alternatenames
+		formatCoordinate(location.Localizacao.Coordinates[1]),
+		formatCoordinate(location.Localizacao.Coordinates[0]),
+		location.FeatureClass,
+		location.FeatureCode,
+		"BR",
+		"", // cc2
+		state.GeoNamesAdmin1,
+		"", "", "", // admin2, admin3, admin4
+		strconv.Itoa(location.Populacao),
+		"", "", // elevation, dem
+		state.FusoHorario,
+		"", // modification date
+	}
+	for i, field := range fields {
+		fields[i] = strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, field)
+	}
+
+	_, err := gw.w.WriteString(strings.Join(fields, "\t") + "\n")
+	return err
+}
+
+func (gw *geoNamesWriter) end() error { return nil }
+
+// csvLocationWriter grava um CSV com header, legível pelo import csv sem -map
+type csvLocationWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvLocationWriter) begin() error {
+	return cw.w.Write(exportCSVColumns)
+}
+
+func (cw *csvLocationWriter) write(location domain.Location) error {
+	populacao, geonameID := "", ""
+	if location.Populacao != 0 {
+		populacao = strconv.Itoa(location.Populacao)
+	}
+	if location.GeoNameID != 0 {
+		geonameID = strconv.FormatInt(location.GeoNameID, 10)
+	}
+	return cw.w.Write([]string{
+		location.Municipio,
+		location.Estado,
+		formatCoordinate(location.Localizacao.Coordinates[1]),
+		formatCoordinate(location.Localizacao.Coordinates[0]),
+		populacao,
+		location.CodigoIBGE,
+		geonameID,
+		location.FeatureClass,
+		location.FeatureCode,
+	})
+}
+
+func (cw *csvLocationWriter) end() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// exportFeature é uma localização como Feature GeoJSON; as propriedades usam
+// os nomes padrão do import geojson
+type exportFeature struct {
+	Type       string                  `json:"type"`
+	Geometry   domain.GeoJSON          `json:"geometry"`
+	Properties exportFeatureProperties `json:"properties"`
+}
+
+type exportFeatureProperties struct {
+	Municipio    string `json:"municipio"`
+	Estado       string `json:"estado"`
+	Populacao    int    `json:"populacao,omitempty"`
+	CodigoIBGE   string `json:"codigo_ibge,omitempty"`
+	GeoNameID    int64  `json:"geonameid,omitempty"`
+	FeatureClass string `json:"feature_class,omitempty"`
+	FeatureCode  string `json:"feature_code,omitempty"`
+}
+
+// geoJSONWriter grava uma FeatureCollection (collection) ou uma Feature por
+// linha (NDJSON)
+type geoJSONWriter struct {
+	w          *bufio.Writer
+	collection bool
+	count      int64
+}
+
+func (gw *geoJSONWriter) begin() error {
+	if !gw.collection {
+		return nil
+	}
+	_, err := gw.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (gw *geoJSONWriter) write(location domain.Location) error {
+	data, err := json.Marshal(exportFeature{
+		Type:     "Feature",
+		Geometry: domain.GeoJSON{Type: "Point", Coordinates: location.Localizacao.Coordinates},
+		Properties: exportFeatureProperties{
+			Municipio:    location.Municipio,
+			Estado:       location.Estado,
+			Populacao:    location.Populacao,
+			CodigoIBGE:   location.CodigoIBGE,
+			GeoNameID:    location.GeoNameID,
+			FeatureClass: location.FeatureClass,
+			FeatureCode:  location.FeatureCode,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if gw.collection && gw.count > 0 {
+		if err := gw.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	gw.count++
+	if gw.collection {
+		if err := gw.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if _, err := gw.w.Write(data); err != nil {
+		return err
+	}
+	if gw.collection {
+		return nil
+	}
+	return gw.w.WriteByte('\n')
+}
+
+func (gw *geoJSONWriter) end() error {
+	if !gw.collection {
+		return nil
+	}
+	_, err := gw.w.WriteString("\n]}\n")
+	return err
+}
+
+// snapshotWriter grava o cabeçalho e um documento completo por linha (NDJSON)
+type snapshotWriter struct {
+	w      *bufio.Writer
+	header domain.SnapshotHeader
+}
+
+func (sw *snapshotWriter) begin() error {
+	return sw.line(sw.header)
+}
+
+func (sw *snapshotWriter) write(location domain.Location) error {
+	return sw.line(location)
+}
+
+func (sw *snapshotWriter) end() error { return nil }
+
+func (sw *snapshotWriter) line(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := sw.w.Write(data); err != nil {
+		return err
+	}
+	return sw.w.WriteByte('\n')
+}
+
+// formatCoordinate grava a coordenada com a menor quantidade de dígitos que a
+// reproduz exatamente
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// RestoreSnapshot recarrega em collection (vazio = coleção principal) um
+// arquivo gravado por Export no formato snapshot. Os documentos (inclusive
+// _id) são gravados como estão em uma coleção de preparo à medida que o
+// arquivo é lido, sem normalização e sem ignorar duplicatas; só depois de
+// conferido o total lido e o gravado ela substitui a coleção de destino, de
+// uma vez, como em CopyTo. Um snapshot truncado ou corrompido não apaga os dados.
+func (es *ExportService) RestoreSnapshot(ctx context.Context, r io.Reader, collection string) (*domain.SnapshotHeader, error) {
+	reader := bufio.NewReader(r)
+
+	line, err := readSnapshotLine(reader)
+	if err == io.EOF {
+		return nil, fmt.Errorf("snapshot vazio")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var header domain.SnapshotHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.Formato != domain.SnapshotFormat {
+		return nil, fmt.Errorf("o arquivo não é um snapshot (gerado com export -format=snapshot)")
+	}
+	if header.Versao != domain.SnapshotVersion {
+		return nil, fmt.Errorf("versão %d do snapshot não suportada (esperada %d)", header.Versao, domain.SnapshotVersion)
+	}
+	if header.Total < 0 {
+		return nil, fmt.Errorf("snapshot inválido: total de %d localizações", header.Total)
+	}
+
+	repo := es.target(collection)
+	staging := es.repo.WithCollection(repo.CollectionName() + "_restore")
+	if err := staging.DropCollection(ctx, staging.CollectionName()); err != nil {
+		return nil, err
+	}
+	// Descarta o preparo em caso de erro; após a troca, ele já foi removido
+	swapped := false
+	defer func() {
+		if !swapped {
+			staging.DropCollection(context.Background(), staging.CollectionName())
+		}
+	}()
+
+	var total int64
+	batch := make([]domain.Location, 0, config.DefaultBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := staging.InsertLocationsAsIs(ctx, batch); err != nil {
+			return fmt.Errorf("erro ao gravar localizações: %v", err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for lineNumber := 2; ; lineNumber++ {
+		line, err := readSnapshotLine(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var location domain.Location
+		if err := json.Unmarshal(line, &location); err != nil {
+			return nil, fmt.Errorf("linha %d: %v", lineNumber, err)
+		}
+		if total++; total > header.Total {
+			return nil, fmt.Errorf("snapshot inválido: mais localizações que as %d do cabeçalho", header.Total)
+		}
+		batch = append(batch, location)
+		if len(batch) == config.DefaultBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if total != header.Total {
+		return nil, fmt.Errorf("snapshot incompleto: %d de %d localizações", total, header.Total)
+	}
+
+	// Confere o que de fato foi gravado, não só o que foi lido
+	stored, err := staging.CountLocations(ctx, domain.LocationFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar localizações restauradas: %v", err)
+	}
+	if stored != header.Total {
+		return nil, fmt.Errorf("restauração incompleta: %d de %d localizações gravadas", stored, header.Total)
+	}
+
+	if err := staging.CopyTo(ctx, repo.CollectionName()); err != nil {
+		return nil, err
+	}
+	swapped = true
+	if err := staging.DropCollection(ctx, staging.CollectionName()); err != nil {
+		log.Printf("⚠️ Erro ao remover a coleção de preparo do snapshot: %v", err)
+	}
+
+	if err := repo.CreateGeoIndex(ctx); err != nil {
+		return nil, err
+	}
+	if err := repo.CreateTextIndex(ctx); err != nil {
+		return nil, err
+	}
+	if err := repo.CreateEstadoIndex(ctx); err != nil {
+		return nil, err
+	}
+	if err := repo.CreateIBGEIndex(ctx); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// readSnapshotLine lê uma linha sem limite de tamanho, sem o "\n"
+func readSnapshotLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(line), nil
+}
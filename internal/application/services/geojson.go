@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// geoJSONFeature é uma Feature GeoJSON com a geometria ainda não decodificada
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// geoJSONReader lê as features uma a uma, sem carregar o arquivo inteiro, o
+// que importa para arquivos de centenas de MB. Aceita uma FeatureCollection ou
+// NDJSON (uma Feature por linha); o formato é detectado pela primeira linha.
+type geoJSONReader struct {
+	// FeatureCollection
+	decoder *json.Decoder
+	inArray bool
+	found   bool
+
+	// NDJSON
+	lines *bufio.Reader
+	line  int64
+}
+
+// featureLineError é uma linha NDJSON que não é uma Feature válida; ao
+// contrário dos erros de uma FeatureCollection, a leitura pode continuar
+type featureLineError struct {
+	line int64
+	err  error
+}
+
+func (e *featureLineError) Error() string {
+	return fmt.Sprintf("linha %d: %v", e.line, e.err)
+}
+
+func newGeoJSONReader(r io.Reader) *geoJSONReader {
+	buffered := bufio.NewReaderSize(r, 1<<16)
+	if isNDJSON(buffered) {
+		return &geoJSONReader{lines: buffered}
+	}
+
+	decoder := json.NewDecoder(buffered)
+	decoder.UseNumber()
+	return &geoJSONReader{decoder: decoder}
+}
+
+// isNDJSON verifica se a primeira linha já é uma Feature completa. Uma
+// FeatureCollection minificada em uma linha só não cabe no buffer ou tem
+// outro "type".
+func isNDJSON(r *bufio.Reader) bool {
+	sample, _ := r.Peek(r.Size())
+	if i := bytes.IndexByte(sample, '\n'); i >= 0 {
+		sample = sample[:i]
+	}
+
+	var probe struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(sample, &probe) == nil && probe.Type == "Feature"
+}
+
+// Next retorna a próxima feature, ou io.EOF ao final
+func (g *geoJSONReader) Next() (geoJSONFeature, error) {
+	if g.lines != nil {
+		return g.nextLine()
+	}
+	return g.nextInCollection()
+}
+
+func (g *geoJSONReader) nextLine() (geoJSONFeature, error) {
+	for {
+		data, err := g.lines.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			if err == io.EOF {
+				return geoJSONFeature{}, io.EOF
+			}
+			return geoJSONFeature{}, fmt.Errorf("erro ao ler NDJSON: %v", err)
+		}
+		g.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var feature geoJSONFeature
+		if err := decoder.Decode(&feature); err != nil {
+			return geoJSONFeature{}, &featureLineError{line: g.line, err: err}
+		}
+		return feature, nil
+	}
+}
+
+func (g *geoJSONReader) nextInCollection() (geoJSONFeature, error) {
+	if g.decoder == nil {
+		return geoJSONFeature{}, io.EOF
+	}
+
+	if !g.inArray {
+		if err := g.seekFeatures(); err != nil {
+			g.decoder = nil
+			return geoJSONFeature{}, err
+		}
+	}
+
+	if g.decoder.More() {
+		var feature geoJSONFeature
+		if err := g.decoder.Decode(&feature); err != nil {
+			return geoJSONFeature{}, fmt.Errorf("erro ao ler feature: %v", err)
+		}
+		return feature, nil
+	}
+
+	if err := expectDelim(g.decoder, ']'); err != nil {
+		return geoJSONFeature{}, err
+	}
+	g.inArray = false
+	return g.nextInCollection()
+}
+
+// seekFeatures avança até o início do array "features", pulando as demais
+// chaves do objeto raiz; retorna io.EOF ao final do objeto
+func (g *geoJSONReader) seekFeatures() error {
+	if !g.found {
+		if err := expectDelim(g.decoder, '{'); err != nil {
+			return err
+		}
+	}
+
+	for g.decoder.More() {
+		token, err := g.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("erro ao ler GeoJSON: %v", err)
+		}
+
+		if key, _ := token.(string); key != "features" || g.found {
+			var skip json.RawMessage
+			if err := g.decoder.Decode(&skip); err != nil {
+				return fmt.Errorf("erro ao ler GeoJSON: %v", err)
+			}
+			continue
+		}
+
+		g.found = true
+		if err := expectDelim(g.decoder, '['); err != nil {
+			return err
+		}
+		g.inArray = true
+		return nil
+	}
+
+	if !g.found {
+		return fmt.Errorf("GeoJSON sem \"features\": esperado uma FeatureCollection")
+	}
+	return io.EOF
+}
+
+// readGeoJSONFeatures percorre as features de uma FeatureCollection ou de um
+// NDJSON, interrompendo no primeiro erro
+func readGeoJSONFeatures(r io.Reader, fn func(geoJSONFeature) error) error {
+	reader := newGeoJSONReader(r)
+	for {
+		feature, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(feature); err != nil {
+			return err
+		}
+	}
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("erro ao ler GeoJSON: %v", err)
+	}
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("GeoJSON inválido: esperado %q, encontrado %v", delim, token)
+	}
+	return nil
+}
+
+// geoJSONProperty retorna a primeira propriedade presente entre os nomes
+// aceitos, comparados como em findColumn ("CD_MUN" == "cd mun")
+func geoJSONProperty(properties map[string]interface{}, names []string) string {
+	return lookupProperty(normalizeProperties(properties), names)
+}
+
+// normalizeProperties indexa as propriedades pelo nome normalizado, para
+// várias buscas na mesma feature
+func normalizeProperties(properties map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		normalized[utils.FoldKey(strings.ReplaceAll(key, "_", " "))] = value
+	}
+	return normalized
+}
+
+func lookupProperty(normalized map[string]interface{}, names []string) string {
+	for _, name := range names {
+		value, ok := normalized[name]
+		if !ok || value == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			return strings.TrimSpace(v)
+		case json.Number:
+			return v.String()
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// toMultiPolygon converte uma geometria Polygon ou MultiPolygon, descartando
+// altitude, vértices repetidos em sequência e anéis degenerados, e fechando
+// anéis abertos. Anéis inválidos impedem a criação do índice 2dsphere.
+func toMultiPolygon(geometry *geoJSONGeometry) (domain.MultiPolygon, error) {
+	if geometry == nil {
+		return domain.MultiPolygon{}, fmt.Errorf("feature sem geometria")
+	}
+
+	var polygons [][][][]float64
+	switch geometry.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return domain.MultiPolygon{}, fmt.Errorf("coordenadas inválidas: %v", err)
+		}
+		polygons = [][][][]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(geometry.Coordinates, &polygons); err != nil {
+			return domain.MultiPolygon{}, fmt.Errorf("coordenadas inválidas: %v", err)
+		}
+	default:
+		return domain.MultiPolygon{}, fmt.Errorf("geometria %s não é um polígono", geometry.Type)
+	}
+
+	return cleanMultiPolygon(polygons)
+}
+
+// cleanMultiPolygon limpa os anéis de cada polígono (polígonos → anéis →
+// posições) e monta o MultiPolygon com os que sobrarem
+func cleanMultiPolygon(polygons [][][][]float64) (domain.MultiPolygon, error) {
+	result := domain.MultiPolygon{Type: "MultiPolygon"}
+	for _, polygon := range polygons {
+		var rings [][][2]float64
+		for i, ring := range polygon {
+			cleaned := cleanRing(ring)
+			if cleaned == nil {
+				if i == 0 {
+					break // anel externo inválido: descarta o polígono
+				}
+				continue
+			}
+			rings = append(rings, cleaned)
+		}
+		if len(rings) > 0 {
+			result.Coordinates = append(result.Coordinates, rings)
+		}
+	}
+
+	if len(result.Coordinates) == 0 {
+		return domain.MultiPolygon{}, fmt.Errorf("nenhum polígono válido")
+	}
+	return result, nil
+}
+
+// cleanRing normaliza um anel; retorna nil se sobrarem menos de 4 posições
+func cleanRing(ring [][]float64) [][2]float64 {
+	cleaned := make([][2]float64, 0, len(ring)+1)
+	for _, position := range ring {
+		if len(position) < 2 {
+			return nil
+		}
+		point := [2]float64{position[0], position[1]}
+		if n := len(cleaned); n > 0 && cleaned[n-1] == point {
+			continue
+		}
+		cleaned = append(cleaned, point)
+	}
+
+	if len(cleaned) > 0 && cleaned[0] != cleaned[len(cleaned)-1] {
+		cleaned = append(cleaned, cleaned[0])
+	}
+	if len(cleaned) < 4 {
+		return nil
+	}
+	return cleaned
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// ibgeMaxDistanceKm é a distância máxima aceita entre a coordenada informada no
+// CSV do IBGE e a localização candidata para que o vínculo seja feito
+const ibgeMaxDistanceKm = 30.0
+
+// Nomes de coluna aceitos (após FoldKey) para cada campo do CSV do IBGE.
+// Cobre a planilha DTB ("Código Município Completo", "Nome_Município") e
+// variações comuns ("codigo_ibge", "nome", "latitude", "longitude").
+var (
+	ibgeCodeColumns = []string{"codigo municipio completo", "codigo ibge", "cod ibge", "codigo municipio", "cd mun", "codigo"}
+	ibgeNameColumns = []string{"nome municipio", "nome do municipio", "nm mun", "municipio", "nome"}
+	ibgeLatColumns  = []string{"latitude", "lat"}
+	ibgeLonColumns  = []string{"longitude", "lon", "lng"}
+)
+
+// ibgeRegionColumns descreve as colunas de cada nível da hierarquia. A DTB traz
+// meso e microrregião com códigos relativos à UF ("13", "061"); codeLen é o
+// tamanho do código completo, obtido prefixando o código da UF.
+var ibgeRegionColumns = []struct {
+	level   string
+	codes   []string
+	names   []string
+	codeLen int
+}{
+	{domain.RegionLevelIntermediaria,
+		[]string{"regiao geografica intermediaria", "cod rgint", "cd rgint", "codigo regiao intermediaria"},
+		[]string{"nome regiao geografica intermediaria", "nome rgint", "nm rgint", "nome regiao intermediaria"}, 4},
+	{domain.RegionLevelImediata,
+		[]string{"regiao geografica imediata", "cod rgi", "cd rgi", "codigo regiao imediata"},
+		[]string{"nome regiao geografica imediata", "nome rgi", "nm rgi", "nome regiao imediata"}, 6},
+	{domain.RegionLevelMesorregiao,
+		[]string{"mesorregiao geografica", "cod meso", "cd meso", "codigo mesorregiao", "mesorregiao"},
+		[]string{"nome mesorregiao", "nm meso", "nome mesorregiao geografica"}, 4},
+	{domain.RegionLevelMicrorregiao,
+		[]string{"microrregiao geografica", "cod micro", "cd micro", "codigo microrregiao", "microrregiao"},
+		[]string{"nome microrregiao", "nm micro", "nome microrregiao geografica"}, 5},
+}
+
+// ibgeMunicipio é uma linha da tabela de municípios do IBGE
+type ibgeMunicipio struct {
+	Codigo    string
+	Nome      string
+	Estado    string
+	Latitude  float64
+	Longitude float64
+	HasCoords bool
+	// Hierarquia é nil quando o arquivo não traz as colunas de regiões
+	Hierarquia *domain.IBGEHierarchy
+}
+
+// ImportIBGEMunicipios lê a tabela de municípios do IBGE (CSV exportado da DTB)
+// e vincula o código de 7 dígitos às localizações já importadas, casando por
+// nome normalizado e UF e, quando o arquivo traz coordenadas, pela proximidade
+func (is *ImportService) ImportIBGEMunicipios(ctx context.Context, filename string) error {
+	municipios, err := readIBGEMunicipios(filename)
+	if err != nil {
+		return err
+	}
+
+	// Índice por estado: chave normalizada do nome -> localizações candidatas
+	indexes := map[string]map[string][]domain.Location{}
+
+	linked := 0
+	notFound := 0
+	tooFar := 0
+
+	for _, m := range municipios {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		index, ok := indexes[m.Estado]
+		if !ok {
+			locations, err := is.repo.GetLocationsByEstado(ctx, m.Estado)
+			if err != nil {
+				return fmt.Errorf("erro ao carregar localizações de %s: %v", m.Estado, err)
+			}
+
+			index = map[string][]domain.Location{}
+			for _, loc := range locations {
+				key := utils.FoldKey(loc.Municipio)
+				index[key] = append(index[key], loc)
+			}
+			indexes[m.Estado] = index
+		}
+
+		candidates := index[utils.FoldKey(m.Nome)]
+		if len(candidates) == 0 {
+			notFound++
+			continue
+		}
+
+		best, ok := pickIBGECandidate(m, candidates)
+		if !ok {
+			tooFar++
+			continue
+		}
+
+		if best.CodigoIBGE == m.Codigo && sameHierarchy(best.Hierarquia, m.Hierarquia) {
+			linked++
+			continue
+		}
+
+		if err := is.repo.SetCodigoIBGE(ctx, best.ID, m.Codigo, m.Hierarquia); err != nil {
+			return err
+		}
+		linked++
+	}
+
+	log.Printf("✅ Códigos IBGE vinculados: %d de %d municípios", linked, len(municipios))
+	log.Printf("   - Sem localização correspondente: %d", notFound)
+	log.Printf("   - Candidatos distantes mais de %.0f km: %d", ibgeMaxDistanceKm, tooFar)
+	return nil
+}
+
+// sameHierarchy indica que a hierarquia do arquivo já está gravada; um arquivo
+// sem as colunas de regiões não altera a hierarquia existente
+func sameHierarchy(current, imported *domain.IBGEHierarchy) bool {
+	if imported == nil {
+		return true
+	}
+	return current != nil && *current == *imported
+}
+
+// pickIBGECandidate escolhe a localização que melhor corresponde ao município:
+// a mais próxima quando há coordenadas, senão a mais populosa (mesmo critério
+// de GetLocationByName)
+func pickIBGECandidate(m ibgeMunicipio, candidates []domain.Location) (domain.Location, bool) {
+	if m.HasCoords {
+		bestDistance := math.MaxFloat64
+		var best domain.Location
+		for _, c := range candidates {
+			d := utils.HaversineKm(m.Latitude, m.Longitude, c.Localizacao.Coordinates[1], c.Localizacao.Coordinates[0])
+			if d < bestDistance {
+				bestDistance = d
+				best = c
+			}
+		}
+		return best, bestDistance <= ibgeMaxDistanceKm
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.Populacao > best.Populacao {
+			best = c
+		}
+	}
+	return best, true
+}
+
+// readIBGEMunicipios lê e valida o CSV do IBGE. Aceita separador ";", "," ou
+// tab, arquivos em UTF-8 ou Latin-1 e colunas identificadas pelo header.
+func readIBGEMunicipios(filename string) ([]ibgeMunicipio, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %v", err)
+	}
+
+	content := strings.TrimPrefix(utils.EnsureUTF8(data), "\ufeff")
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = detectDelimiter(content)
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler header: %v", err)
+	}
+
+	codeCol := findColumn(header, ibgeCodeColumns)
+	nameCol := findColumn(header, ibgeNameColumns)
+	if codeCol < 0 || nameCol < 0 {
+		return nil, fmt.Errorf("colunas de código e nome do município não encontradas no header: %v", header)
+	}
+	latCol := findColumn(header, ibgeLatColumns)
+	lonCol := findColumn(header, ibgeLonColumns)
+
+	type regionCols struct{ code, name int }
+	regionColumns := make([]regionCols, len(ibgeRegionColumns))
+	hasHierarchy := false
+	for i, rc := range ibgeRegionColumns {
+		regionColumns[i] = regionCols{findColumn(header, rc.codes), findColumn(header, rc.names)}
+		hasHierarchy = hasHierarchy || regionColumns[i].code >= 0
+	}
+
+	var municipios []ibgeMunicipio
+	rejected := 0
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo: %v", err)
+	}
+
+	for _, record := range records {
+		if codeCol >= len(record) || nameCol >= len(record) {
+			rejected++
+			continue
+		}
+
+		codigo := strings.TrimSpace(record[codeCol])
+		state, ok := domain.StateByCodigoIBGE(codigo)
+		if len(codigo) != 7 || !isDigits(codigo) || !ok {
+			rejected++
+			continue
+		}
+
+		m := ibgeMunicipio{
+			Codigo: codigo,
+			Nome:   strings.TrimSpace(record[nameCol]),
+			Estado: state.UF,
+		}
+
+		if latCol >= 0 && lonCol >= 0 && latCol < len(record) && lonCol < len(record) {
+			lat, errLat := strconv.ParseFloat(strings.TrimSpace(record[latCol]), 64)
+			lon, errLon := strconv.ParseFloat(strings.TrimSpace(record[lonCol]), 64)
+			if errLat == nil && errLon == nil {
+				m.Latitude, m.Longitude, m.HasCoords = lat, lon, true
+			}
+		}
+
+		if hasHierarchy {
+			hierarquia := domain.IBGEHierarchy{}
+			for i, rc := range ibgeRegionColumns {
+				cols := regionColumns[i]
+				if cols.code < 0 || cols.code >= len(record) {
+					continue
+				}
+				region := domain.IBGERegion{Codigo: fullRegionCode(state.CodigoIBGE, strings.TrimSpace(record[cols.code]), rc.codeLen)}
+				if region.Codigo == "" {
+					continue
+				}
+				if cols.name >= 0 && cols.name < len(record) {
+					region.Nome = strings.TrimSpace(record[cols.name])
+				}
+				setHierarchyRegion(&hierarquia, rc.level, region)
+			}
+			if !hierarquia.IsEmpty() {
+				m.Hierarquia = &hierarquia
+			}
+		}
+
+		municipios = append(municipios, m)
+	}
+
+	if rejected > 0 {
+		log.Printf("⚠️ %d linhas do arquivo IBGE ignoradas (código inválido)", rejected)
+	}
+
+	return municipios, nil
+}
+
+// fullRegionCode completa códigos relativos à UF ("13" → "3513") até codeLen
+// dígitos; códigos inválidos retornam ""
+func fullRegionCode(ufCode, code string, codeLen int) string {
+	if code == "" || !isDigits(code) || len(code) > codeLen {
+		return ""
+	}
+	if len(code) == codeLen {
+		return code
+	}
+	pad := codeLen - len(ufCode) - len(code)
+	if pad < 0 {
+		return ""
+	}
+	return ufCode + strings.Repeat("0", pad) + code
+}
+
+func setHierarchyRegion(h *domain.IBGEHierarchy, level string, region domain.IBGERegion) {
+	switch level {
+	case domain.RegionLevelIntermediaria:
+		h.Intermediaria = region
+	case domain.RegionLevelImediata:
+		h.Imediata = region
+	case domain.RegionLevelMesorregiao:
+		h.Mesorregiao = region
+	case domain.RegionLevelMicrorregiao:
+		h.Microrregiao = region
+	}
+}
+
+// detectDelimiter escolhe o separador mais frequente na primeira linha
+func detectDelimiter(content string) rune {
+	firstLine := content
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		firstLine = content[:i]
+	}
+
+	best := ';'
+	bestCount := strings.Count(firstLine, ";")
+	for _, candidate := range []rune{',', '\t'} {
+		if n := strings.Count(firstLine, string(candidate)); n > bestCount {
+			best, bestCount = candidate, n
+		}
+	}
+	return best
+}
+
+// findColumn retorna o índice da primeira coluna do header cujo nome normalizado
+// está na lista de nomes aceitos (na ordem de preferência da lista)
+func findColumn(header []string, names []string) int {
+	normalized := make([]string, len(header))
+	for i, h := range header {
+		normalized[i] = utils.FoldKey(strings.ReplaceAll(h, "_", " "))
+	}
+
+	for _, name := range names {
+		for i, h := range normalized {
+			if h == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// GetLocationByCodigoIBGE busca localização pelo código IBGE de 7 dígitos
+func (is *ImportService) GetLocationByCodigoIBGE(ctx context.Context, codigo string) (*domain.Location, error) {
+	loc, err := is.repo.GetLocationByCodigoIBGE(ctx, codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	return loc, nil
+}
+
+// CreateIBGEIndex cria índice no código IBGE do município
+func (is *ImportService) CreateIBGEIndex(ctx context.Context) error {
+	err := is.repo.CreateIBGEIndex(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
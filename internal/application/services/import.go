@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"strconv"
+	"time"
 
 	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
 	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
@@ -16,156 +16,318 @@ import (
 
 type ImportService struct {
 	repo domainIF.IGeoRepository
+	runs domainIF.IImportRunRepository
 }
 
-func NewGeoService(repo domainIF.IGeoRepository) *ImportService {
+// NewGeoService cria o serviço; runs pode ser nil para não registrar o
+// progresso das importações
+func NewGeoService(repo domainIF.IGeoRepository, runs domainIF.IImportRunRepository) *ImportService {
 
 	return &ImportService{
 		repo: repo,
+		runs: runs,
 	}
 }
 
-// ImportData importa dados de um arquivo CSV
-func (is *ImportService) ImportData(ctx context.Context, filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("erro ao abrir arquivo: %v", err)
+// ImportData importa dados no formato GeoNames lidos de r. O reader pode ser um
+// arquivo, uma entrada de um .zip ou o corpo de um download, sem a necessidade
+// de extrair nada em disco.
+//
+// A importação roda como um pipeline: leitura → parse/validação → lotes →
+// opts.Workers escritas concorrentes. Os canais entre as etapas são limitados,
+// então a leitura espera quando o banco está lento, e o cancelamento de ctx
+// (ou o primeiro erro de escrita) interrompe todas as etapas.
+//
+// Quando opts.SourceSHA256 é informado, cada lote confirmado é registrado em
+// import_runs; com opts.Resume a leitura recomeça logo após o último checkpoint.
+// Linhas rejeitadas vão para opts.Quarantine, e o relatório retornado (também
+// em caso de erro) traz as contagens por motivo e por estado.
+//
+// Com opts.DryRun nada é gravado (nem índices, nem import_runs): o arquivo é
+// apenas lido e validado e, com opts.Diff, comparado com a coleção atual.
+func (is *ImportService) ImportData(ctx context.Context, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	opts = withImportDefaults(opts)
+	report := newImportReport(opts)
+
+	if opts.DryRun {
+		return is.dryRun(ctx, r, opts, report)
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.Comma = '\t' // GeoNames usa tab como separador
-	reader.LazyQuotes = true
+	repo := is.target(opts)
 
-	// Pular header se existir
-	_, err = reader.Read()
-	if err != nil {
-		log.Println(fmt.Errorf("erro ao ler header: %v", err))
-		return err
+	// Garante que registros já gravados sejam ignorados ao retomar
+	if err := repo.CreateGeoNameIDIndex(ctx); err != nil {
+		return report.finish(0, err), err
 	}
 
-	var locations []domain.Location
+	run, err := is.startRun(ctx, opts)
+	if err != nil {
+		return report.finish(0, err), err
+	}
 
-	count := 0
-	rejectedTotal := 0
-	rejectedCountry := 0
-	rejectedState := 0
-	rejectedCoords := 0
-	rejectedBounds := 0
+	var startPos position
+	var firstSeq int
+	var previouslyInserted int64
 
-	// Mapa de conversão: admin1 code do GeoNames (número) -> código de estado (2 letras)
-	stateCodeMap := map[string]string{
-		"01": "DF", "02": "ES", "03": "BA", "04": "GO", "05": "MA", "06": "MT", "07": "MS",
-		"08": "MG", "09": "PA", "10": "PB", "11": "PR", "12": "PE", "13": "PI",
-		"14": "RJ", "15": "RN", "16": "RS", "17": "RO", "18": "RR", "19": "SC",
-		"20": "SP", "21": "SE", "22": "TO", "23": "RS", "24": "RO", "25": "AC", "26": "SC", "27": "SP", "28": "AL", "29": "AP", "30": "AM", "31": "CE",
+	if run != nil && run.CommittedOffset > 0 {
+		if err := skipTo(r, run.CommittedOffset); err != nil {
+			err = fmt.Errorf("erro ao posicionar no checkpoint: %v", err)
+			is.finishRun(run, err)
+			return report.finish(0, err), err
+		}
+		startPos = position{row: run.CommittedRows, offset: run.CommittedOffset}
+		firstSeq = run.LastBatch
+		previouslyInserted = run.Inserted
+		report.Resumed = true
+		log.Printf("↪️ Retomando importação a partir da linha %d (lote %d)", run.CommittedRows, run.LastBatch)
 	}
 
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
+	reader := csv.NewReader(r)
+	reader.Comma = '\t' // GeoNames usa tab como separador
+	reader.LazyQuotes = true
+
+	if startPos.offset == 0 {
+		// Pular header se existir
+		_, err := reader.Read()
 		if err != nil {
-			log.Printf("Erro ao ler linha: %v", err)
-			continue
+			log.Println(fmt.Errorf("erro ao ler header: %v", err))
+			is.finishRun(run, err)
+			return report.finish(0, err), err
 		}
+		startPos.offset = reader.InputOffset()
+	}
 
-		rejectedTotal++
-
-		// Formato GeoNames: geonameid, name, asciiname, alternatenames, latitude, longitude, ...
-		if len(record) < 18 {
-			continue
+	var checkpoint checkpointFunc
+	if run != nil {
+		checkpoint = func(seq int, end position, inserted int64) error {
+			return is.runs.SaveCheckpoint(ctx, run.ID, seq, end.row, end.offset, previouslyInserted+inserted)
 		}
+	}
 
-		// Filtro crítico: apenas importar registros do Brasil (countryCode == "BR")
-		if record[8] != "BR" {
-			rejectedCountry++
-			continue
+	pipeline := newImportPipeline(ctx, opts)
+	pipeline.read(reader, startPos)
+	pipeline.parse(report.parseGeoNamesRow(opts))
+	pipeline.batch(firstSeq)
+	inserted, err := pipeline.write(repo.InsertLocations, checkpoint)
+	if err == nil {
+		err = report.quarantineErr
+	}
+	is.finishRun(run, err)
+	if err != nil {
+		if run != nil {
+			log.Printf("⚠️ Importação interrompida; use -resume para continuar do último lote confirmado")
 		}
+		return report.finish(inserted, err), err
+	}
 
-		// Validar estado: não pode estar vazio
-		estadoCode := record[10]
-		if estadoCode == "" {
-			rejectedState++
-			continue
-		}
+	report.finish(inserted, nil)
+	report.log()
+	return report.ImportReport, nil
+}
+
+// target retorna o repositório da coleção de destino da importação
+func (is *ImportService) target(opts domain.ImportOptions) domainIF.IGeoRepository {
+	if opts.Collection == "" {
+		return is.repo
+	}
+	return is.repo.WithCollection(opts.Collection)
+}
 
-		// Converter admin1 code (número) para estado (2 letras)
-		estado, exists := stateCodeMap[estadoCode]
-		if !exists {
-			log.Printf("⚠️ Estado inválido ignorado: %s (código não encontrado no mapa, município: %s)", estadoCode, record[1])
-			rejectedState++
-			continue
+// dryRun passa o arquivo pelo mesmo pipeline da importação, trocando a escrita
+// no repositório por uma comparação com a coleção atual (opts.Diff) ou por nada
+func (is *ImportService) dryRun(ctx context.Context, r io.Reader, opts domain.ImportOptions, report *importReport) (*domain.ImportReport, error) {
+	if opts.Resume {
+		err := fmt.Errorf("-resume não pode ser usado em modo de simulação")
+		return report.finish(0, err), err
+	}
+
+	insert := func(context.Context, []domain.Location) error { return nil }
+	var differ *datasetDiffer
+	if opts.Diff {
+		var err error
+		if differ, err = newDatasetDiffer(ctx, is.target(opts)); err != nil {
+			return report.finish(0, err), err
 		}
+		insert = differ.compare
+	}
 
-		lat, err := strconv.ParseFloat(record[4], 64)
-		if err != nil {
-			rejectedCoords++
-			continue
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.LazyQuotes = true
+	if _, err := reader.Read(); err != nil {
+		err = fmt.Errorf("erro ao ler header: %v", err)
+		return report.finish(0, err), err
+	}
+
+	pipeline := newImportPipeline(ctx, opts)
+	pipeline.read(reader, position{offset: reader.InputOffset()})
+	pipeline.parse(report.parseGeoNamesRow(opts))
+	pipeline.batch(0)
+	_, err := pipeline.write(insert, nil)
+	if err == nil {
+		err = report.quarantineErr
+	}
+	if err != nil {
+		return report.finish(0, err), err
+	}
+
+	// Nenhum registro é gravado; o total "inserido" fica zerado
+	report.finish(0, nil)
+	if differ != nil {
+		report.Diff = differ.finish()
+	}
+	report.log()
+	if report.Diff != nil {
+		logDiff(report.Diff)
+	}
+	return report.ImportReport, nil
+}
+
+// startRun registra a importação em import_runs ou, com opts.Resume, reabre a
+// última importação interrompida do mesmo arquivo
+func (is *ImportService) startRun(ctx context.Context, opts domain.ImportOptions) (*domain.ImportRun, error) {
+	if is.runs == nil || opts.SourceSHA256 == "" {
+		if opts.Resume {
+			return nil, fmt.Errorf("retomar uma importação requer o checksum do arquivo de origem")
 		}
+		return nil, nil
+	}
 
-		lon, err := strconv.ParseFloat(record[5], 64)
+	if opts.Resume {
+		run, err := is.runs.FindResumable(ctx, opts.SourceSHA256)
 		if err != nil {
-			rejectedCoords++
-			continue
+			return nil, err
 		}
-
-		// Validar bounds de coordenadas brasileiras (segurança adicional)
-		// Brasil: lat entre -33.7 e 5.3, lon entre -73.9 e -28.8
-		if lat < -33.8 || lat > 5.4 || lon < -74.0 || lon > -28.7 {
-			rejectedBounds++
-			log.Printf("DEBUG: Coordenadas fora do bounds: lat=%v, lon=%v (municipio=%s, estado=%s)", lat, lon, record[1], estado)
-			continue
+		if run != nil {
+			if err := is.runs.MarkResumed(ctx, run.ID); err != nil {
+				return nil, err
+			}
+			return run, nil
 		}
+		log.Println("ℹ️ Nenhuma importação interrompida deste arquivo; iniciando do começo")
+	}
 
-		population := 0
-		if record[14] != "" {
-			population, _ = strconv.Atoi(record[14])
-		}
+	now := time.Now()
+	run := &domain.ImportRun{
+		Source:       opts.SourceName,
+		SourceSHA256: opts.SourceSHA256,
+		Status:       domain.ImportRunRunning,
+		StartedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := is.runs.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
 
-		// Usar o estado convertido (2 letras)
-		location := domain.Location{
-			Municipio: utils.NormalizeMunicipio(record[1]), // name com normalização
-			Estado:    estado,                              // código de estado convertido (SP, BA, etc)
-			Localizacao: domain.GeoJSON{
-				Type:        "Point",
-				Coordinates: [2]float64{lon, lat},
-			},
-			Populacao: population,
-		}
+// finishRun grava o status final. Usa um contexto próprio porque o da
+// importação pode ter expirado, que é justamente quando o registro importa.
+func (is *ImportService) finishRun(run *domain.ImportRun, runErr error) {
+	if run == nil {
+		return
+	}
 
-		locations = append(locations, location)
-		count++
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-		// Inserir em lotes de 1000
-		if count%1000 == 0 {
-			err := is.repo.InsertLocations(ctx, locations)
-			if err != nil {
-				log.Println(fmt.Errorf("erro ao inserir lote: %v", err))
-				return err
-			}
-			locations = []domain.Location{} // Limpar o array após inserção
-		}
+	status := domain.ImportRunCompleted
+	if runErr != nil {
+		status = domain.ImportRunFailed
+	}
+	if err := is.runs.FinishRun(ctx, run.ID, status, runErr); err != nil {
+		log.Printf("⚠️ %v", err)
 	}
+}
 
-	// Inserir registros restantes
-	if len(locations) > 0 {
-		err := is.repo.InsertLocations(ctx, locations)
-		if err != nil {
-			return err
-		}
+// FindResumableRun busca a última importação interrompida do arquivo com este checksum
+func (is *ImportService) FindResumableRun(ctx context.Context, sourceSHA256 string) (*domain.ImportRun, error) {
+	if is.runs == nil {
+		return nil, nil
 	}
+	return is.runs.FindResumable(ctx, sourceSHA256)
+}
 
-	log.Printf("✅ Importação concluída! Total: %d registros", count)
-	log.Printf("📊 Estatísticas de rejeição:")
-	log.Printf("   - Total de linhas processadas: %d", rejectedTotal)
-	log.Printf("   - Rejeitadas por país diferente de BR: %d", rejectedCountry)
-	log.Printf("   - Rejeitadas por estado inválido: %d", rejectedState)
-	log.Printf("   - Rejeitadas por erro ao ler coordenadas: %d", rejectedCoords)
-	log.Printf("   - Rejeitadas por coordenadas fora dos bounds: %d", rejectedBounds)
-	log.Printf("   - ✓ Aceitas e importadas: %d", count)
-	return nil
+// skipTo avança o reader até o byte offset, usando Seek quando possível
+func skipTo(r io.Reader, offset int64) error {
+	if seeker, ok := r.(io.Seeker); ok {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+
+	_, err := io.CopyN(io.Discard, r, offset)
+	return err
+}
+
+// parseGeoNamesRecord valida uma linha do GeoNames e a converte em Location.
+// Retorna o motivo da rejeição (domain.Reject*) e um detalhe, ou "" se a linha
+// é válida. Quando o estado já foi resolvido, a Location retornada o contém
+// mesmo na rejeição, para as contagens por estado.
+func parseGeoNamesRecord(record []string) (domain.Location, string, string) {
+	// Formato GeoNames: geonameid, name, asciiname, alternatenames, latitude, longitude, ...
+	if len(record) < 18 {
+		return domain.Location{}, domain.RejectShortRecord, fmt.Sprintf("%d colunas", len(record))
+	}
+
+	// Filtro crítico: apenas importar registros do Brasil (countryCode == "BR")
+	if record[8] != "BR" {
+		return domain.Location{}, domain.RejectCountry, record[8]
+	}
+
+	// Validar estado: não pode estar vazio
+	estadoCode := record[10]
+	if estadoCode == "" {
+		return domain.Location{}, domain.RejectState, "admin1 vazio"
+	}
+
+	// Converter admin1 code (número) para estado (2 letras)
+	state, exists := domain.StateByGeoNamesAdmin1(estadoCode)
+	if !exists {
+		return domain.Location{}, domain.RejectState, "admin1 " + estadoCode + " não é uma UF conhecida"
+	}
+	estado := state.UF
+
+	lat, err := strconv.ParseFloat(record[4], 64)
+	if err != nil {
+		return domain.Location{Estado: estado}, domain.RejectCoords, "latitude " + record[4]
+	}
+
+	lon, err := strconv.ParseFloat(record[5], 64)
+	if err != nil {
+		return domain.Location{Estado: estado}, domain.RejectCoords, "longitude " + record[5]
+	}
+
+	// Validar bounds de coordenadas brasileiras (segurança adicional)
+	if !inBrazilBounds(lon, lat) {
+		return domain.Location{Estado: estado}, domain.RejectBounds, fmt.Sprintf("lat=%v lon=%v", lat, lon)
+	}
+
+	population := 0
+	if record[14] != "" {
+		population, _ = strconv.Atoi(record[14])
+	}
+
+	geonameID, _ := strconv.ParseInt(record[0], 10, 64)
+
+	// Usar o estado convertido (2 letras)
+	return domain.Location{
+		GeoNameID: geonameID,
+		Municipio: utils.NormalizeMunicipio(record[1]), // name com normalização
+		Estado:    estado,                              // código de estado convertido (SP, BA, etc)
+		Localizacao: domain.GeoJSON{
+			Type:        "Point",
+			Coordinates: [2]float64{lon, lat},
+		},
+		Populacao:    population,
+		FeatureClass: record[6],
+		FeatureCode:  record[7],
+	}, "", ""
+}
+
+// inBrazilBounds verifica se o ponto está no retângulo que envolve o Brasil
+// (lat entre -33.7 e 5.3, lon entre -73.9 e -28.8, com margem)
+func inBrazilBounds(lon, lat float64) bool {
+	return lat >= -33.8 && lat <= 5.4 && lon >= -74.0 && lon <= -28.7
 }
 
 // ImportBrazilianCities importa dados simplificados de cidades brasileiras
@@ -182,7 +344,7 @@ func (is *ImportService) ImportBrazilianCitiesExampleTest(ctx context.Context) e
 		{Municipio: "Curitiba", Estado: "PR", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-49.2643, -25.4284}}},
 		{Municipio: "Recife", Estado: "PE", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-34.8813, -8.0476}}},
 		{Municipio: "Goiânia", Estado: "GO", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-49.2532, -16.6864}}},
-		{Municipio: "Porto Aleise", Estado: "RS", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-51.2302, -30.0346}}},
+		{Municipio: "Porto Alegre", Estado: "RS", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-51.2302, -30.0346}}},
 		{Municipio: "Belém", Estado: "PA", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-48.5044, -1.4558}}},
 		{Municipio: "Guarulhos", Estado: "SP", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-46.5333, -23.4625}}},
 		{Municipio: "Campinas", Estado: "SP", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-47.0608, -22.9099}}},
@@ -192,7 +354,7 @@ func (is *ImportService) ImportBrazilianCitiesExampleTest(ctx context.Context) e
 		{Municipio: "Duque de Caxias", Estado: "RJ", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-43.3055, -22.7858}}},
 		{Municipio: "Natal", Estado: "RN", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-35.2094, -5.7945}}},
 		{Municipio: "Teresina", Estado: "PI", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-42.8034, -5.0892}}},
-		{Municipio: "Campo isande", Estado: "MS", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-54.6295, -20.4697}}},
+		{Municipio: "Campo Grande", Estado: "MS", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-54.6295, -20.4697}}},
 		{Municipio: "João Pessoa", Estado: "PB", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-34.8631, -7.1195}}},
 		{Municipio: "Jaboatão dos Guararapes", Estado: "PE", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-35.0147, -8.1130}}},
 		{Municipio: "Osasco", Estado: "SP", Localizacao: domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-46.7917, -23.5329}}},
@@ -232,6 +394,11 @@ func (is *ImportService) GetLocationsInKilometersRange(ctx context.Context, long
 	return loc, nil
 }
 
+// GetLocationsInBBox retorna até limit localizações dentro do retângulo
+func (is *ImportService) GetLocationsInBBox(ctx context.Context, bbox domain.BBox, limit int) ([]domain.Location, error) {
+	return is.repo.GetLocationsInBBox(ctx, bbox, limit)
+}
+
 // CreateGeoIndex cria índice geoespacial
 func (is *ImportService) CreateGeoIndex(ctx context.Context) error {
 	err := is.repo.CreateGeoIndex(ctx)
@@ -250,19 +417,31 @@ func (is *ImportService) CreateTextIndex(ctx context.Context) error {
 	return nil
 }
 
-// ResetCollection recria a coleção, removendo todos os dados existentes
+// ResetCollection recria a coleção (vazio = coleção principal), removendo
+// todos os dados existentes, com os índices das consultas
 func (is *ImportService) ResetCollection(ctx context.Context, collection string) error {
-	err := is.repo.DropCollection(ctx, collection)
+	repo := is.target(domain.ImportOptions{Collection: collection})
+	err := repo.DropCollection(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	err = repo.CreateGeoIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = repo.CreateTextIndex(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = is.repo.CreateGeoIndex(ctx)
+	err = repo.CreateIBGEIndex(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = is.repo.CreateTextIndex(ctx)
+	err = repo.CreateEstadoIndex(ctx)
 	if err != nil {
 		return err
 	}
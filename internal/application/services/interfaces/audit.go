@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IAuditService interface {
+	// Audit percorre a coleção e aponta duplicatas, pontos fora da UF, população ausente, grafia suspeita e capitais ausentes
+	Audit(ctx context.Context, opts domain.AuditOptions) (*domain.AuditReport, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IBoundaryService interface {
+	// ImportBoundaries importa a malha municipal em GeoJSON, substituindo os limites existentes
+	ImportBoundaries(ctx context.Context, r io.Reader) error
+	// ImportBoundariesShapefile importa a malha municipal em shapefile (.shp ou .zip)
+	ImportBoundariesShapefile(ctx context.Context, path string) error
+	// FindMunicipioContaining busca o município cujo limite contém o ponto
+	FindMunicipioContaining(ctx context.Context, longitude, latitude float64) (*domain.Boundary, error)
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IConflationService interface {
+	// Conflate casa os registros das fontes e grava as localizações canônicas com a proveniência de cada campo
+	Conflate(ctx context.Context, opts domain.ConflationOptions) (*domain.ConflationReport, error)
+}
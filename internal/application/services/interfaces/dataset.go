@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IDatasetService interface {
+	// CreateSnapshot copia a coleção principal para um snapshot nomeado e o marca como ativo
+	CreateSnapshot(ctx context.Context, opts domain.SnapshotOptions) (*domain.DatasetVersion, error)
+	// ListSnapshots retorna os snapshots da versão mais recente para a mais antiga
+	ListSnapshots(ctx context.Context) ([]domain.DatasetVersion, error)
+	// ActivateSnapshot substitui a coleção principal pelo snapshot; nil se o nome não existe
+	ActivateSnapshot(ctx context.Context, nome string) (*domain.DatasetVersion, error)
+	// DeleteSnapshot remove um snapshot inativo; nil se o nome não existe
+	DeleteSnapshot(ctx context.Context, nome string) (*domain.DatasetVersion, error)
+	// MarkModified desmarca o snapshot ativo depois de uma alteração na coleção principal
+	MarkModified(ctx context.Context) (*domain.DatasetVersion, error)
+	// ActiveVersion retorna o snapshot ativo, com cache; nil se nenhum está ativo
+	ActiveVersion(ctx context.Context) *domain.DatasetVersion
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IExportService interface {
+	// Export grava as localizações que casam com o filtro no formato escolhido e retorna quantas foram gravadas
+	Export(ctx context.Context, w io.Writer, opts domain.ExportOptions) (int64, error)
+	// RestoreSnapshot recria a coleção com os documentos de um snapshot gravado por Export
+	RestoreSnapshot(ctx context.Context, r io.Reader, collection string) (*domain.SnapshotHeader, error)
+}
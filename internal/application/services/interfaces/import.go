@@ -2,19 +2,57 @@ package interfaces
 
 import (
 	"context"
+	"io"
 
 	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
 )
 
 type IImportService interface {
 	ImportBrazilianCitiesExampleTest(ctx context.Context) error
-	ImportData(ctx context.Context, filename string) error
+	ImportData(ctx context.Context, r io.Reader, opts domain.ImportOptions) (*domain.ImportReport, error)
+	// ImportShapefile importa uma camada de pontos ou polígonos (.shp ou .zip) com o mapeamento de atributos
+	ImportShapefile(ctx context.Context, path string, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error)
+	// ImportCSV importa um CSV genérico de pontos com o layout e o mapeamento de colunas de csvOpts
+	ImportCSV(ctx context.Context, r io.Reader, csvOpts domain.CSVOptions, opts domain.ImportOptions) (*domain.ImportReport, error)
+	// ImportGeoJSON importa pontos de uma FeatureCollection ou de NDJSON com o mapeamento de propriedades
+	ImportGeoJSON(ctx context.Context, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error)
+	// ImportKML importa os placemarks de ponto de um KML com o mapeamento de propriedades
+	ImportKML(ctx context.Context, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error)
+	// ImportGPX importa os waypoints de um GPX com o mapeamento de propriedades
+	ImportGPX(ctx context.Context, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error)
+	// ImportOSM importa os nós place=* (padrão: city, town, village) de um extrato PBF do OpenStreetMap
+	ImportOSM(ctx context.Context, r io.Reader, places []string, opts domain.ImportOptions) (*domain.ImportReport, error)
 	GetLocationByName(ctx context.Context, municipio, estado string) (*domain.Location, error)
 	GetLocationsInKilometersRange(ctx context.Context, longitude, latitude float64, rangeInKilometers float64) (*[]domain.Location, error)
+	// GetLocationsInBBox retorna até limit localizações dentro do retângulo
+	GetLocationsInBBox(ctx context.Context, bbox domain.BBox, limit int) ([]domain.Location, error)
 	// CreateGeoIndex cria índice geoespacial
 	CreateGeoIndex(ctx context.Context) error
 	// CreateTextIndex cria índice de texto para busca
 	CreateTextIndex(ctx context.Context) error
-	// ResetCollection recria a coleção, removendo todos os dados existentes
+	// ResetCollection recria a coleção (vazio = coleção principal), removendo
+	// todos os dados existentes
 	ResetCollection(ctx context.Context, collection string) error
+	// ImportIBGEMunicipios vincula os códigos IBGE da tabela DTB às localizações
+	ImportIBGEMunicipios(ctx context.Context, filename string) error
+	// GetLocationByCodigoIBGE busca localização pelo código IBGE de 7 dígitos
+	GetLocationByCodigoIBGE(ctx context.Context, codigo string) (*domain.Location, error)
+	// CreateIBGEIndex cria índice no código IBGE do município
+	CreateIBGEIndex(ctx context.Context) error
+	// CreateEstadoIndex cria os índices usados nas listagens por estado
+	CreateEstadoIndex(ctx context.Context) error
+	// ListStates retorna as UFs com os totais dos dados importados
+	ListStates(ctx context.Context) ([]domain.StateSummary, error)
+	// GetState retorna o resumo de uma UF; nil se a sigla não existe
+	GetState(ctx context.Context, uf string) (*domain.StateSummary, error)
+	// GetRegion soma os totais das UFs de uma região; nil se a região não existe
+	GetRegion(ctx context.Context, regiao string) (*domain.RegionSummary, error)
+	// ListMunicipiosByEstado retorna uma página das localizações de uma UF
+	ListMunicipiosByEstado(ctx context.Context, uf string, opts domain.ListOptions) (*domain.LocationPage, error)
+	// ListMunicipiosByRegion retorna uma página das localizações de uma região do IBGE
+	ListMunicipiosByRegion(ctx context.Context, level, codigo string, opts domain.ListOptions) (*domain.LocationPage, error)
+	// GetNearestMunicipio retorna o município com código IBGE mais próximo do ponto
+	GetNearestMunicipio(ctx context.Context, longitude, latitude float64) (*domain.Location, error)
+	// FindResumableRun busca a última importação interrompida do arquivo com este checksum
+	FindResumableRun(ctx context.Context, sourceSHA256 string) (*domain.ImportRun, error)
 }
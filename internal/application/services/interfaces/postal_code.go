@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IPostalCodeService interface {
+	// ImportPostalCodes importa o arquivo de CEPs do GeoNames
+	ImportPostalCodes(ctx context.Context, r io.Reader) error
+	// GetByCEP busca um CEP; o bool indica se o resultado veio da faixa de CEPs vizinhos
+	GetByCEP(ctx context.Context, cep string) (*domain.PostalCode, bool, error)
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Colunas procuradas (após FoldKey) quando o ColumnMapping não indica uma,
+// cobrindo as malhas e bases de localidades do IBGE
+var (
+	mappedNameColumns       = []string{"nm mun", "nm municip", "nm localid", "nome municipio", "municipio", "cidade", "nome", "name"}
+	mappedStateColumns      = []string{"sigla uf", "sg uf", "uf", "estado"}
+	mappedPopulationColumns = []string{"populacao", "pop", "population"}
+	mappedCodeColumns       = []string{"cd mun", "cd geocmu", "cd geocodm", "codigo ibge", "cod ibge"}
+)
+
+// mappedColumns são os índices no header das colunas de um ColumnMapping; -1
+// quando a coluna não existe
+type mappedColumns struct {
+	municipio int
+	estado    int
+	populacao int
+	codigo    int
+}
+
+// resolveColumnMapping localiza as colunas do mapeamento no header. Uma coluna
+// indicada explicitamente precisa existir; o município é obrigatório, e a UF
+// precisa vir da coluna de estado ou do código IBGE.
+func resolveColumnMapping(header []string, mapping domain.ColumnMapping) (mappedColumns, error) {
+	var columns mappedColumns
+	var err error
+
+	if columns.municipio, err = mappedColumn(header, mapping.Municipio, mappedNameColumns); err != nil {
+		return columns, err
+	}
+	if columns.estado, err = mappedColumn(header, mapping.Estado, mappedStateColumns); err != nil {
+		return columns, err
+	}
+	if columns.populacao, err = mappedColumn(header, mapping.Populacao, mappedPopulationColumns); err != nil {
+		return columns, err
+	}
+	if columns.codigo, err = mappedColumn(header, mapping.CodigoIBGE, mappedCodeColumns); err != nil {
+		return columns, err
+	}
+
+	if columns.municipio < 0 {
+		return columns, fmt.Errorf("coluna do município não encontrada em %v; indique-a no mapeamento", header)
+	}
+	if columns.estado < 0 && columns.codigo < 0 {
+		return columns, fmt.Errorf("coluna da UF ou do código IBGE não encontrada em %v; indique-a no mapeamento", header)
+	}
+	return columns, nil
+}
+
+func mappedColumn(header []string, name string, defaults []string) (int, error) {
+	if name == "" {
+		return findColumn(header, defaults), nil
+	}
+
+	i := findColumn(header, []string{utils.FoldKey(strings.ReplaceAll(name, "_", " "))})
+	if i < 0 {
+		return -1, fmt.Errorf("coluna %q não encontrada em %v", name, header)
+	}
+	return i, nil
+}
+
+// location monta a Location de um registro mapeado com as coordenadas já
+// lidas, aplicando as mesmas validações da importação GeoNames. Retorna o
+// motivo da rejeição (domain.Reject*) e um detalhe, ou "" se o registro é válido.
+func (c mappedColumns) location(values []string, lon, lat float64) (domain.Location, string, string) {
+	value := func(i int) string { return fieldValue(values, i) }
+
+	codigo := value(c.codigo)
+	if len(codigo) != 7 || !isDigits(codigo) {
+		codigo = ""
+	}
+
+	nome := value(c.municipio)
+	var state domain.State
+	var ok bool
+	if estado := value(c.estado); estado != "" {
+		if state, ok = domain.ResolveState(estado); !ok {
+			return domain.Location{}, domain.RejectState, estado + " não é uma UF conhecida"
+		}
+	} else if codigo != "" {
+		if state, ok = domain.StateByCodigoIBGE(codigo); !ok {
+			return domain.Location{}, domain.RejectState, "código IBGE " + codigo + " sem UF conhecida"
+		}
+	} else if nome, state, ok = splitStateSuffix(nome); !ok {
+		return domain.Location{}, domain.RejectState, "UF vazia"
+	}
+
+	municipio := utils.NormalizeMunicipio(nome)
+	if municipio == "" {
+		return domain.Location{Estado: state.UF}, domain.RejectMissingName, ""
+	}
+
+	if !inBrazilBounds(lon, lat) {
+		return domain.Location{Estado: state.UF}, domain.RejectBounds, fmt.Sprintf("lat=%v lon=%v", lat, lon)
+	}
+
+	// Colunas numéricas de DBF e planilhas costumam vir como "12345.000"
+	population := 0
+	if p, err := strconv.ParseFloat(value(c.populacao), 64); err == nil && p > 0 {
+		population = int(p)
+	}
+
+	return domain.Location{
+		Municipio: municipio,
+		Estado:    state.UF,
+		Localizacao: domain.GeoJSON{
+			Type:        "Point",
+			Coordinates: [2]float64{lon, lat},
+		},
+		Populacao:  population,
+		CodigoIBGE: codigo,
+	}, "", ""
+}
+
+// splitStateSuffix separa a UF indicada no próprio nome, como em marcadores de
+// KML e GPX: "Campinas - SP", "Campinas/SP" ou "Campinas (SP)"
+func splitStateSuffix(name string) (string, domain.State, bool) {
+	trimmed := strings.TrimSpace(name)
+	if strings.HasSuffix(trimmed, ")") {
+		if i := strings.LastIndex(trimmed, "("); i > 0 {
+			if state, ok := domain.StateByUF(strings.TrimSpace(trimmed[i+1 : len(trimmed)-1])); ok {
+				return strings.TrimSpace(trimmed[:i]), state, true
+			}
+		}
+	}
+
+	if i := strings.LastIndexAny(trimmed, "-/"); i > 0 {
+		if state, ok := domain.StateByUF(strings.TrimSpace(trimmed[i+1:])); ok {
+			return strings.TrimSpace(trimmed[:i]), state, true
+		}
+	}
+	return name, domain.State{}, false
+}
+
+// fieldValue retorna o valor da coluna i, ou "" se a linha for mais curta
+func fieldValue(values []string, i int) string {
+	if i < 0 || i >= len(values) {
+		return ""
+	}
+	return strings.TrimSpace(values[i])
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/osm"
+)
+
+// DefaultOSMPlaces são os valores de place=* importados quando nenhum é indicado
+var DefaultOSMPlaces = []string{"city", "town", "village"}
+
+// Tags do OSM de onde vem a UF quando o nó não tem IBGE:GEOCODIGO
+var osmStateTags = []string{"is_in:state_code", "addr:state", "is_in:state"}
+
+// ImportOSM importa as localidades de um extrato PBF do OpenStreetMap (ex:
+// brazil-latest.osm.pbf): nós place=city/town/village (ou os valores de
+// places) com name, population e IBGE:GEOCODIGO. A UF vem do código IBGE ou,
+// sem ele, das tags is_in:state_code e addr:state.
+//
+// O extrato é lido em streaming e passa pelo mesmo pipeline, relatório e
+// quarentena dos outros formatos; ways, relations e nós de outros tipos são
+// ignorados sem contar como rejeitados.
+func (is *ImportService) ImportOSM(ctx context.Context, r io.Reader, places []string, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	if len(places) == 0 {
+		places = DefaultOSMPlaces
+	}
+	wanted := make(map[string]bool, len(places))
+	for _, place := range places {
+		wanted[strings.TrimSpace(place)] = true
+	}
+
+	return is.importPoints(ctx, "extratos OSM", domain.ColumnMapping{}, opts, func(push func(pointFeature) error) error {
+		return osm.DecodeNodes(r, "place", func(node osm.Node) error {
+			if !wanted[node.Tags["place"]] {
+				return nil
+			}
+
+			codigo := strings.TrimSpace(node.Tags["IBGE:GEOCODIGO"])
+			estado := ""
+			for _, tag := range osmStateTags {
+				if estado = strings.TrimSpace(node.Tags[tag]); estado != "" {
+					break
+				}
+			}
+			if state, ok := domain.StateByCodigoIBGE(codigo); ok && isDigits(codigo) {
+				estado = state.UF
+			}
+
+			return push(pointFeature{
+				properties: map[string]interface{}{
+					"municipio":   node.Tags["name"],
+					"uf":          estado,
+					"populacao":   osmPopulation(node.Tags["population"]),
+					"codigo ibge": codigo,
+				},
+				lon: node.Lon,
+				lat: node.Lat,
+			})
+		})
+	})
+}
+
+// osmPopulation remove separadores de milhar ("12.345", "12 345"); valores que
+// não são um número inteiro (ex: "~5000") ficam sem população
+func osmPopulation(value string) string {
+	groups := strings.FieldsFunc(strings.TrimSpace(value), func(r rune) bool {
+		return r == '.' || r == ',' || r == ' '
+	})
+	for i, group := range groups {
+		if !isDigits(group) || (i > 0 && len(group) != 3) {
+			return ""
+		}
+	}
+	return strings.Join(groups, "")
+}
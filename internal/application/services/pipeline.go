@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/config"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+func withImportDefaults(opts domain.ImportOptions) domain.ImportOptions {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = config.DefaultBatchSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = config.DefaultWorkers
+	}
+	return opts
+}
+
+// position identifica até onde o arquivo de entrada foi consumido: número de
+// linhas de dados e byte logo após a última delas
+type position struct {
+	row    int64
+	offset int64
+}
+
+type pipelineRow struct {
+	record []string
+	pos    position
+	err    error       // erro de leitura da linha (CSV malformado)
+	data   interface{} // registro de formatos não tabulares (ex: shapefile)
+}
+
+// pipelineItem é uma linha já validada; linhas rejeitadas também passam
+// adiante (ok=false) para que o checkpoint avance sobre elas
+type pipelineItem struct {
+	location domain.Location
+	ok       bool
+	pos      position
+}
+
+type pipelineBatch struct {
+	seq       int
+	locations []domain.Location
+	end       position
+}
+
+// checkpointFunc é chamada, em ordem, sempre que todos os lotes até seq estão gravados
+type checkpointFunc func(seq int, end position, inserted int64) error
+
+// importPipeline encadeia as etapas da importação com canais limitados:
+//
+//	read → rows → parse → items → batch → batches → write (N workers)
+//
+// Cada etapa roda em sua goroutine e fecha o canal de saída ao terminar. O
+// primeiro erro cancela o contexto interno, o que faz todas as etapas pararem.
+type importPipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   domain.ImportOptions
+
+	wg   sync.WaitGroup
+	once sync.Once
+	err  error
+
+	rows     chan pipelineRow
+	items    chan pipelineItem
+	batches  chan pipelineBatch
+	firstSeq int
+}
+
+func newImportPipeline(ctx context.Context, opts domain.ImportOptions) *importPipeline {
+	inner, cancel := context.WithCancel(ctx)
+	return &importPipeline{
+		parent:  ctx,
+		ctx:     inner,
+		cancel:  cancel,
+		opts:    opts,
+		rows:    make(chan pipelineRow, opts.BatchSize),
+		items:   make(chan pipelineItem, opts.BatchSize),
+		batches: make(chan pipelineBatch, opts.Workers),
+	}
+}
+
+// fail registra o primeiro erro e interrompe o pipeline
+func (p *importPipeline) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// read lê as linhas do CSV. Linhas malformadas seguem adiante com o erro para
+// serem rejeitadas no parse. start é a posição do reader no arquivo (não zero
+// ao retomar uma importação).
+func (p *importPipeline) read(reader *csv.Reader, start position) {
+	baseOffset := start.offset - reader.InputOffset()
+	row := start.row
+
+	p.source(func() (pipelineRow, error) {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return pipelineRow{}, io.EOF
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return pipelineRow{}, err
+			}
+		}
+
+		row++
+		return pipelineRow{record: record, err: err, pos: position{row: row, offset: baseOffset + reader.InputOffset()}}, nil
+	})
+}
+
+// source alimenta o pipeline com as linhas retornadas por next até io.EOF.
+// Outros erros de next interrompem a importação.
+func (p *importPipeline) source(next func() (pipelineRow, error)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(p.rows)
+
+		for {
+			row, err := next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				p.fail(err)
+				return
+			}
+
+			select {
+			case p.rows <- row:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// push alimenta o pipeline a partir de leitores baseados em callback (KML,
+// GPX): produce chama send para cada linha, e send retorna false quando o
+// pipeline foi interrompido. Um erro de produce interrompe a importação.
+func (p *importPipeline) push(produce func(send func(pipelineRow) bool) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(p.rows)
+
+		err := produce(func(row pipelineRow) bool {
+			select {
+			case p.rows <- row:
+				return true
+			case <-p.ctx.Done():
+				return false
+			}
+		})
+		if err != nil && p.ctx.Err() == nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// parse converte as linhas em localizações; fn retorna false para rejeitar a
+// linha. fn roda sempre na mesma goroutine, então pode acumular contadores.
+func (p *importPipeline) parse(fn func(row pipelineRow) (domain.Location, bool)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(p.items)
+
+		for row := range p.rows {
+			item := pipelineItem{pos: row.pos}
+			item.location, item.ok = fn(row)
+
+			select {
+			case p.items <- item:
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// batch agrupa as localizações em lotes de opts.BatchSize. firstSeq é o número
+// do último lote já confirmado (não zero ao retomar).
+func (p *importPipeline) batch(firstSeq int) {
+	p.firstSeq = firstSeq
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(p.batches)
+
+		seq := firstSeq
+		current := pipelineBatch{locations: make([]domain.Location, 0, p.opts.BatchSize)}
+		pending := false
+
+		send := func() bool {
+			seq++
+			current.seq = seq
+			select {
+			case p.batches <- current:
+			case <-p.ctx.Done():
+				return false
+			}
+			current = pipelineBatch{locations: make([]domain.Location, 0, p.opts.BatchSize)}
+			pending = false
+			return true
+		}
+
+		for item := range p.items {
+			current.end = item.pos
+			pending = true
+			if item.ok {
+				current.locations = append(current.locations, item.location)
+			}
+
+			if len(current.locations) == p.opts.BatchSize && !send() {
+				return
+			}
+		}
+
+		// O último lote é enviado mesmo sem localizações para que o checkpoint
+		// cubra as linhas rejeitadas no fim do arquivo
+		if pending {
+			send()
+		}
+	}()
+}
+
+// write inicia opts.Workers escritores, espera todas as etapas terminarem e
+// retorna o total de registros gravados. Como os lotes terminam fora de ordem,
+// checkpoint só é chamada quando todos os lotes anteriores também terminaram.
+func (p *importPipeline) write(insert func(ctx context.Context, batch []domain.Location) error, checkpoint checkpointFunc) (int64, error) {
+	var mu sync.Mutex
+	var inserted, committedInserted int64
+	lastSeq := p.firstSeq
+	done := map[int]pipelineBatch{}
+
+	commit := func(batch pipelineBatch) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		inserted += int64(len(batch.locations))
+		done[batch.seq] = batch
+
+		for {
+			next, ok := done[lastSeq+1]
+			if !ok {
+				return nil
+			}
+			delete(done, next.seq)
+			lastSeq = next.seq
+			committedInserted += int64(len(next.locations))
+
+			if checkpoint != nil {
+				if err := checkpoint(next.seq, next.end, committedInserted); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for i := 0; i < p.opts.Workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+
+			for batch := range p.batches {
+				if len(batch.locations) > 0 {
+					if err := insert(p.ctx, batch.locations); err != nil {
+						p.fail(fmt.Errorf("erro ao inserir lote: %v", err))
+						return
+					}
+				}
+
+				if err := commit(batch); err != nil {
+					p.fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+	p.wg.Wait()
+	p.cancel()
+
+	if p.err != nil {
+		return inserted, p.err
+	}
+	if err := p.parent.Err(); err != nil {
+		return inserted, err
+	}
+	return inserted, nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+// geoNamesTSV gera um BR.txt em memória com header e n linhas; a cada
+// invalidEvery linhas (0 = nunca) uma tem latitude inválida
+func geoNamesTSV(n, invalidEvery int) string {
+	var sb strings.Builder
+	sb.WriteString("geonameid\tname\tasciiname\talternatenames\tlatitude\tlongitude\tfeature class\tfeature code\tcountry code\tcc2\tadmin1 code\tadmin2 code\tadmin3 code\tadmin4 code\tpopulation\televation\tdem\ttimezone\tmodification date\n")
+	for i := 1; i <= n; i++ {
+		lat := fmt.Sprintf("%.5f", -23.5+float64(i%1000)/10000)
+		if invalidEvery > 0 && i%invalidEvery == 0 {
+			lat = "x"
+		}
+		fmt.Fprintf(&sb, "%d\tCidade %d\tCidade %d\t\t%s\t-46.63333\tP\tPPL\tBR\t\t27\t\t\t\t%d\t\t760\tAmerica/Sao_Paulo\t2024-01-01\n",
+			3400000+i, i, i, lat, i*10)
+	}
+	return sb.String()
+}
+
+// runGeoNamesPipeline passa data pelo pipeline do GeoNames, como o ImportData,
+// trocando o repositório por insert
+func runGeoNamesPipeline(ctx context.Context, data string, opts domain.ImportOptions, insert func(context.Context, []domain.Location) error, checkpoint checkpointFunc) (int64, *importReport, error) {
+	opts = withImportDefaults(opts)
+	report := newImportReport(opts)
+
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.Comma = '\t'
+	reader.LazyQuotes = true
+	if _, err := reader.Read(); err != nil {
+		return 0, report, err
+	}
+
+	pipeline := newImportPipeline(ctx, opts)
+	pipeline.read(reader, position{offset: reader.InputOffset()})
+	pipeline.parse(report.parseGeoNamesRow(opts))
+	pipeline.batch(0)
+	inserted, err := pipeline.write(insert, checkpoint)
+	return inserted, report, err
+}
+
+// withDeadline falha o teste se fn não terminar a tempo (pipeline travado)
+func withDeadline(t *testing.T, timeout time.Duration, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("pipeline não terminou em %v", timeout)
+	}
+}
+
+func TestImportPipelineInsertsAllRows(t *testing.T) {
+	tests := []struct {
+		rows, invalidEvery, batchSize, workers int
+	}{
+		{rows: 0, batchSize: 10, workers: 1},
+		{rows: 1, batchSize: 10, workers: 4},
+		{rows: 2500, invalidEvery: 7, batchSize: 1000, workers: 3},
+		{rows: 1000, batchSize: 100, workers: 8},
+		// As últimas linhas rejeitadas ainda geram um lote vazio, para o checkpoint
+		{rows: 20, invalidEvery: 1, batchSize: 5, workers: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("rows=%d/batch=%d/workers=%d", tt.rows, tt.batchSize, tt.workers), func(t *testing.T) {
+			data := geoNamesTSV(tt.rows, tt.invalidEvery)
+
+			var mu sync.Mutex
+			seen := map[int64]bool{}
+			insert := func(_ context.Context, batch []domain.Location) error {
+				if len(batch) > tt.batchSize {
+					t.Errorf("lote com %d localizações, máximo %d", len(batch), tt.batchSize)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, l := range batch {
+					if seen[l.GeoNameID] {
+						t.Errorf("geonameid %d inserido duas vezes", l.GeoNameID)
+					}
+					seen[l.GeoNameID] = true
+				}
+				return nil
+			}
+
+			var last position
+			checkpoint := func(_ int, end position, _ int64) error {
+				last = end
+				return nil
+			}
+
+			inserted, report, err := runGeoNamesPipeline(context.Background(), data,
+				domain.ImportOptions{BatchSize: tt.batchSize, Workers: tt.workers}, insert, checkpoint)
+			if err != nil {
+				t.Fatalf("erro: %v", err)
+			}
+
+			rejected := 0
+			if tt.invalidEvery > 0 {
+				rejected = tt.rows / tt.invalidEvery
+			}
+			if want := int64(tt.rows - rejected); inserted != want || int64(len(seen)) != want {
+				t.Errorf("inseridos = %d (únicos %d), esperado %d", inserted, len(seen), want)
+			}
+			if report.Accepted != inserted || report.Rejected != int64(rejected) {
+				t.Errorf("relatório: aceitos %d, rejeitados %d", report.Accepted, report.Rejected)
+			}
+			if tt.rows > 0 && (last.row != int64(tt.rows) || last.offset != int64(len(data))) {
+				t.Errorf("último checkpoint em %+v, esperado linha %d e byte %d", last, tt.rows, len(data))
+			}
+		})
+	}
+}
+
+func TestImportPipelineCheckpointsInOrder(t *testing.T) {
+	data := geoNamesTSV(5000, 0)
+	rnd := rand.New(rand.NewSource(1))
+	var rndMu sync.Mutex
+
+	// Lotes terminam fora de ordem; os checkpoints não
+	insert := func(context.Context, []domain.Location) error {
+		rndMu.Lock()
+		d := time.Duration(rnd.Intn(2000)) * time.Microsecond
+		rndMu.Unlock()
+		time.Sleep(d)
+		return nil
+	}
+
+	var seqs []int
+	var lastRow, lastInserted int64
+	checkpoint := func(seq int, end position, inserted int64) error {
+		seqs = append(seqs, seq)
+		if end.row <= lastRow || inserted <= lastInserted {
+			t.Errorf("checkpoint %d regrediu: linha %d, inseridos %d", seq, end.row, inserted)
+		}
+		lastRow, lastInserted = end.row, inserted
+		return nil
+	}
+
+	if _, _, err := runGeoNamesPipeline(context.Background(), data, domain.ImportOptions{BatchSize: 100, Workers: 8}, insert, checkpoint); err != nil {
+		t.Fatalf("erro: %v", err)
+	}
+	if len(seqs) != 50 {
+		t.Fatalf("%d checkpoints, esperado 50", len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != i+1 {
+			t.Fatalf("checkpoints fora de ordem: %v", seqs)
+		}
+	}
+}
+
+func TestImportPipelineStopsOnInsertError(t *testing.T) {
+	data := geoNamesTSV(10000, 0)
+	errInsert := errors.New("falha no banco")
+
+	var calls int32
+	insert := func(context.Context, []domain.Location) error {
+		if atomic.AddInt32(&calls, 1) == 3 {
+			return errInsert
+		}
+		return nil
+	}
+
+	var committed int
+	checkpoint := func(seq int, _ position, _ int64) error {
+		committed = seq
+		return nil
+	}
+
+	withDeadline(t, 5*time.Second, func() {
+		_, _, err := runGeoNamesPipeline(context.Background(), data, domain.ImportOptions{BatchSize: 100, Workers: 1}, insert, checkpoint)
+		if err == nil || !strings.Contains(err.Error(), errInsert.Error()) {
+			t.Errorf("erro = %v, esperado %v", err, errInsert)
+		}
+	})
+	if committed != 2 {
+		t.Errorf("último checkpoint = lote %d, esperado 2", committed)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("%d inserções após o erro, esperado parar na 3ª", n)
+	}
+}
+
+func TestImportPipelineStopsOnCheckpointError(t *testing.T) {
+	data := geoNamesTSV(1000, 0)
+	errCheckpoint := errors.New("falha ao salvar checkpoint")
+
+	insert := func(context.Context, []domain.Location) error { return nil }
+	checkpoint := func(seq int, _ position, _ int64) error {
+		if seq == 2 {
+			return errCheckpoint
+		}
+		return nil
+	}
+
+	withDeadline(t, 5*time.Second, func() {
+		_, _, err := runGeoNamesPipeline(context.Background(), data, domain.ImportOptions{BatchSize: 100, Workers: 2}, insert, checkpoint)
+		if !errors.Is(err, errCheckpoint) {
+			t.Errorf("erro = %v, esperado %v", err, errCheckpoint)
+		}
+	})
+}
+
+// endlessSource alimenta o pipeline com linhas válidas sem fim e conta quantas
+// foram lidas
+func endlessSource(p *importPipeline, read *int64) {
+	record := strings.Split(strings.TrimSuffix(strings.SplitN(geoNamesTSV(1, 0), "\n", 2)[1], "\n"), "\t")
+	p.source(func() (pipelineRow, error) {
+		n := atomic.AddInt64(read, 1)
+		return pipelineRow{record: record, pos: position{row: n, offset: n}}, nil
+	})
+}
+
+func TestImportPipelineCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := domain.ImportOptions{BatchSize: 50, Workers: 2}
+	report := newImportReport(opts)
+
+	var read int64
+	var batches int32
+	insert := func(context.Context, []domain.Location) error {
+		if atomic.AddInt32(&batches, 1) == 5 {
+			cancel()
+		}
+		return nil
+	}
+
+	p := newImportPipeline(ctx, opts)
+	endlessSource(p, &read)
+	p.parse(report.parseGeoNamesRow(opts))
+	p.batch(0)
+
+	withDeadline(t, 5*time.Second, func() {
+		if _, err := p.write(insert, nil); !errors.Is(err, context.Canceled) {
+			t.Errorf("erro = %v, esperado context.Canceled", err)
+		}
+	})
+}
+
+func TestImportPipelineBackPressure(t *testing.T) {
+	opts := domain.ImportOptions{BatchSize: 10, Workers: 2}
+	report := newImportReport(opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Os escritores ficam parados: a leitura deve parar quando os canais enchem
+	release := make(chan struct{})
+	insert := func(ctx context.Context, _ []domain.Location) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+
+	var read int64
+	p := newImportPipeline(ctx, opts)
+	endlessSource(p, &read)
+	p.parse(report.parseGeoNamesRow(opts))
+	p.batch(0)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := p.write(insert, nil)
+		result <- err
+	}()
+
+	// Espera a leitura estabilizar
+	var last int64 = -1
+	for i := 0; i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+		n := atomic.LoadInt64(&read)
+		if n == last {
+			break
+		}
+		last = n
+	}
+
+	// rows e items (BatchSize cada), batches (Workers lotes), um lote em cada
+	// escritor, o lote em montagem e uma linha parada em cada etapa
+	bound := int64(opts.BatchSize*(2+opts.Workers+opts.Workers+1) + 3)
+	if n := atomic.LoadInt64(&read); n > bound {
+		t.Errorf("%d linhas lidas com os escritores parados; limite %d", n, bound)
+	}
+
+	cancel()
+	close(release)
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("erro = %v, esperado context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline não terminou após o cancelamento")
+	}
+}
+
+// BenchmarkImportPipeline mede a vazão do pipeline com os dados em memória e
+// uma escrita simulada (cópia do lote e uma espera fixa por lote, como a ida
+// e volta ao banco), variando o tamanho do lote e o número de escritores
+func BenchmarkImportPipeline(b *testing.B) {
+	const rows = 50000
+	data := geoNamesTSV(rows, 0)
+
+	for _, latency := range []time.Duration{0, 500 * time.Microsecond} {
+		for _, batchSize := range []int{100, 1000, 5000} {
+			for _, workers := range []int{1, 4, 8} {
+				name := fmt.Sprintf("latency=%v/batch=%d/workers=%d", latency, batchSize, workers)
+				b.Run(name, func(b *testing.B) {
+					insert := func(_ context.Context, batch []domain.Location) error {
+						sink := make([]domain.Location, len(batch))
+						copy(sink, batch)
+						if latency > 0 {
+							time.Sleep(latency)
+						}
+						return nil
+					}
+
+					b.SetBytes(int64(len(data)))
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						inserted, _, err := runGeoNamesPipeline(context.Background(), data,
+							domain.ImportOptions{BatchSize: batchSize, Workers: workers}, insert, nil)
+						if err != nil || inserted != rows {
+							b.Fatalf("inseridos %d, erro %v", inserted, err)
+						}
+					}
+					b.ReportMetric(float64(rows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+				})
+			}
+		}
+	}
+}
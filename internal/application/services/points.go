@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/gpx"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/kml"
+)
+
+// Ordem dos valores extraídos das propriedades de cada ponto
+var pointColumns = mappedColumns{municipio: 0, estado: 1, populacao: 2, codigo: 3}
+
+// pointFeature é um ponto lido de GeoJSON, KML ou GPX, com as propriedades
+// (já normalizadas) de onde vêm os campos do mapeamento
+type pointFeature struct {
+	properties map[string]interface{}
+	lon, lat   float64
+	// geometryErr indica que o registro não é um ponto válido
+	geometryErr error
+	// err indica um registro malformado (ex: linha NDJSON inválida)
+	err error
+}
+
+// ImportGeoJSON importa uma camada de pontos em GeoJSON, como FeatureCollection
+// ou NDJSON (uma Feature por linha), lida em streaming. As propriedades viram
+// Municipio, Estado, Populacao e CodigoIBGE conforme mapping, com os mesmos
+// nomes padrão e validações do CSV e do shapefile.
+//
+// O pipeline, o relatório e a quarentena são os mesmos de ImportData; features
+// que não são pontos e linhas NDJSON inválidas são rejeitadas. Não há
+// checkpoint nem -resume; com opts.DryRun o arquivo é apenas lido e validado.
+func (is *ImportService) ImportGeoJSON(ctx context.Context, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	reader := newGeoJSONReader(r)
+	return is.importPoints(ctx, "GeoJSON", mapping, opts, func(push func(pointFeature) error) error {
+		for {
+			feature, err := reader.Next()
+			if err == io.EOF {
+				return nil
+			}
+
+			var lineErr *featureLineError
+			if errors.As(err, &lineErr) {
+				if err := push(pointFeature{err: lineErr}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			point := pointFeature{properties: normalizeProperties(feature.Properties)}
+			point.lon, point.lat, point.geometryErr = geoJSONPoint(feature.Geometry)
+			if err := push(point); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// ImportKML importa os placemarks de ponto de um KML (Google Earth). O nome do
+// placemark é a propriedade "name", a descrição "description", e os campos de
+// ExtendedData (Data e SimpleData) entram com o próprio nome.
+func (is *ImportService) ImportKML(ctx context.Context, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	return is.importPoints(ctx, "KML", mapping, opts, func(push func(pointFeature) error) error {
+		return kml.Decode(r, func(placemark kml.Placemark) error {
+			properties := make(map[string]interface{}, len(placemark.Data)+2)
+			for key, value := range placemark.Data {
+				properties[key] = value
+			}
+			properties["name"] = placemark.Name
+			properties["description"] = placemark.Description
+
+			point := pointFeature{properties: normalizeProperties(properties)}
+			if placemark.Coordinates != nil {
+				point.lon, point.lat = placemark.Coordinates[0], placemark.Coordinates[1]
+			} else if placemark.Geometry == "" {
+				point.geometryErr = fmt.Errorf("placemark sem geometria")
+			} else {
+				point.geometryErr = fmt.Errorf("geometria %s não é um ponto", placemark.Geometry)
+			}
+			return push(point)
+		})
+	})
+}
+
+// ImportGPX importa os waypoints (wpt) de um GPX; as propriedades são "name",
+// "desc", "cmt" e "type"
+func (is *ImportService) ImportGPX(ctx context.Context, r io.Reader, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	return is.importPoints(ctx, "GPX", mapping, opts, func(push func(pointFeature) error) error {
+		return gpx.Decode(r, func(waypoint gpx.Waypoint) error {
+			point := pointFeature{properties: normalizeProperties(map[string]interface{}{
+				"name": waypoint.Name,
+				"desc": waypoint.Description,
+				"cmt":  waypoint.Comment,
+				"type": waypoint.Type,
+			})}
+			if waypoint.Coordinates != nil {
+				point.lon, point.lat = waypoint.Coordinates[0], waypoint.Coordinates[1]
+			} else {
+				point.geometryErr = fmt.Errorf("waypoint com lat/lon inválidos")
+			}
+			return push(point)
+		})
+	})
+}
+
+// importPoints passa os pontos entregues por read pelo pipeline de importação.
+// read roda na etapa de leitura do pipeline e para quando push retorna erro.
+func (is *ImportService) importPoints(ctx context.Context, format string, mapping domain.ColumnMapping, opts domain.ImportOptions, read func(push func(pointFeature) error) error) (*domain.ImportReport, error) {
+	opts = withImportDefaults(opts)
+	report := newImportReport(opts)
+
+	if opts.Resume || opts.Diff {
+		err := fmt.Errorf("-resume e -diff não se aplicam a %s", format)
+		return report.finish(0, err), err
+	}
+
+	names := [][]string{
+		propertyNames(mapping.Municipio, mappedNameColumns),
+		propertyNames(mapping.Estado, mappedStateColumns),
+		propertyNames(mapping.Populacao, mappedPopulationColumns),
+		propertyNames(mapping.CodigoIBGE, mappedCodeColumns),
+	}
+
+	repo := is.target(opts)
+	insert := func(context.Context, []domain.Location) error { return nil }
+	if !opts.DryRun {
+		if err := repo.CreateGeoIndex(ctx); err != nil {
+			return report.finish(0, err), err
+		}
+		if err := repo.CreateEstadoIndex(ctx); err != nil {
+			return report.finish(0, err), err
+		}
+		insert = repo.InsertLocations
+	}
+
+	var row int64
+	pipeline := newImportPipeline(ctx, opts)
+	pipeline.push(func(send func(pipelineRow) bool) error {
+		return read(func(point pointFeature) error {
+			row++
+			values := make([]string, len(names))
+			for i, n := range names {
+				values[i] = lookupProperty(point.properties, n)
+			}
+			if !send(pipelineRow{record: values, err: point.err, data: point, pos: position{row: row}}) {
+				return context.Canceled
+			}
+			return nil
+		})
+	})
+	pipeline.parse(func(row pipelineRow) (domain.Location, bool) {
+		if row.err != nil {
+			return domain.Location{}, report.reject(opts, row, domain.RejectMalformed, row.err.Error(), "")
+		}
+
+		point := row.data.(pointFeature)
+		if point.geometryErr != nil {
+			return domain.Location{}, report.reject(opts, row, domain.RejectGeometry, point.geometryErr.Error(), "")
+		}
+
+		location, reason, detail := pointColumns.location(row.record, point.lon, point.lat)
+		if reason != "" {
+			return location, report.reject(opts, row, reason, detail, location.Estado)
+		}
+
+		report.accept(location)
+		return location, true
+	})
+	pipeline.batch(0)
+	inserted, err := pipeline.write(insert, nil)
+	if err == nil {
+		err = report.quarantineErr
+	}
+	if opts.DryRun {
+		inserted = 0
+	}
+	if err != nil {
+		return report.finish(inserted, err), err
+	}
+
+	report.finish(inserted, nil)
+	report.log()
+	return report.ImportReport, nil
+}
+
+// propertyNames retorna o nome normalizado da propriedade indicada no
+// mapeamento ou, se vazio, os nomes padrão
+func propertyNames(name string, defaults []string) []string {
+	if name == "" {
+		return defaults
+	}
+	return []string{utils.FoldKey(strings.ReplaceAll(name, "_", " "))}
+}
+
+// geoJSONPoint lê as coordenadas [lon, lat] de uma geometria Point
+func geoJSONPoint(geometry *geoJSONGeometry) (float64, float64, error) {
+	if geometry == nil {
+		return 0, 0, fmt.Errorf("feature sem geometria")
+	}
+	if geometry.Type != "Point" {
+		return 0, 0, fmt.Errorf("geometria %s não é um ponto", geometry.Type)
+	}
+
+	var position []float64
+	if err := json.Unmarshal(geometry.Coordinates, &position); err != nil || len(position) < 2 {
+		return 0, 0, fmt.Errorf("coordenadas inválidas: %s", geometry.Coordinates)
+	}
+	return position[0], position[1], nil
+}
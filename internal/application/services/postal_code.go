@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	domainIF "github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+type PostalCodeService struct {
+	repo domainIF.IPostalCodeRepository
+}
+
+func NewPostalCodeService(repo domainIF.IPostalCodeRepository) *PostalCodeService {
+	return &PostalCodeService{
+		repo: repo,
+	}
+}
+
+// ImportPostalCodes importa o arquivo de CEPs do GeoNames (BR.txt dentro de
+// postal_codes/BR.zip), substituindo os CEPs existentes.
+//
+// Os CEPs são gravados em uma coleção de preparo, que só substitui a coleção
+// de CEPs depois que o arquivo inteiro foi lido com pelo menos um CEP válido:
+// um arquivo ausente, vazio ou corrompido não apaga os dados em uso.
+//
+// Formato (tab): country code, postal code, place name, admin name1, admin code1,
+// admin name2, admin code2, admin name3, admin code3, latitude, longitude, accuracy
+func (ps *PostalCodeService) ImportPostalCodes(ctx context.Context, r io.Reader) error {
+	staging := ps.repo.WithCollection(ps.repo.CollectionName() + "_staging")
+	if err := staging.DropCollection(ctx); err != nil {
+		return err
+	}
+	// Descarta o preparo em caso de erro; após a troca, ele já foi removido
+	swapped := false
+	defer func() {
+		if !swapped {
+			staging.DropCollection(context.Background())
+		}
+	}()
+	// O índice único descarta os CEPs repetidos do arquivo durante a inserção
+	if err := staging.CreateIndexes(ctx); err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	var postalCodes []domain.PostalCode
+
+	count := 0
+	rejectedCEP := 0
+	rejectedState := 0
+	rejectedCoords := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Erros de leitura (zip truncado, disco) interrompem a importação;
+			// só as linhas malformadas são puladas
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return fmt.Errorf("erro ao ler arquivo de CEPs: %v", err)
+			}
+			log.Printf("Erro ao ler linha: %v", err)
+			continue
+		}
+
+		if len(record) < 11 || record[0] != "BR" {
+			continue
+		}
+
+		cep, err := utils.NormalizeCEP(record[1])
+		if err != nil {
+			rejectedCEP++
+			continue
+		}
+
+		estado := resolveEstado(record[4], record[3])
+		if estado == "" {
+			rejectedState++
+			continue
+		}
+
+		lat, errLat := strconv.ParseFloat(record[9], 64)
+		lon, errLon := strconv.ParseFloat(record[10], 64)
+		if errLat != nil || errLon != nil {
+			rejectedCoords++
+			continue
+		}
+
+		// admin name2 é o município; quando ausente o place name é o próprio município
+		municipio := record[5]
+		localidade := record[2]
+		if municipio == "" {
+			municipio = localidade
+		}
+		if utils.FoldKey(localidade) == utils.FoldKey(municipio) {
+			localidade = ""
+		}
+
+		codigoIBGE := ""
+		if len(record[6]) == 7 && isDigits(record[6]) {
+			codigoIBGE = record[6]
+		}
+
+		postalCodes = append(postalCodes, domain.PostalCode{
+			CEP:        cep,
+			Localidade: localidade,
+			Municipio:  utils.NormalizeMunicipio(municipio),
+			Estado:     estado,
+			CodigoIBGE: codigoIBGE,
+			Localizacao: domain.GeoJSON{
+				Type:        "Point",
+				Coordinates: [2]float64{lon, lat},
+			},
+		})
+		count++
+
+		if len(postalCodes) == 1000 {
+			if err := staging.InsertPostalCodes(ctx, postalCodes); err != nil {
+				return fmt.Errorf("erro ao inserir lote de CEPs: %v", err)
+			}
+			postalCodes = []domain.PostalCode{}
+		}
+	}
+
+	if err := staging.InsertPostalCodes(ctx, postalCodes); err != nil {
+		return fmt.Errorf("erro ao inserir lote de CEPs: %v", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("nenhum CEP válido no arquivo; os CEPs existentes foram mantidos")
+	}
+
+	if err := staging.CopyTo(ctx, ps.repo.CollectionName()); err != nil {
+		return err
+	}
+	if err := ps.repo.CreateIndexes(ctx); err != nil {
+		return err
+	}
+	swapped = true
+	if err := staging.DropCollection(ctx); err != nil {
+		log.Printf("⚠️ Erro ao remover a coleção de preparo dos CEPs: %v", err)
+	}
+
+	log.Printf("✅ Importação de CEPs concluída! Total: %d registros", count)
+	log.Printf("   - Rejeitados por CEP inválido: %d", rejectedCEP)
+	log.Printf("   - Rejeitados por estado inválido: %d", rejectedState)
+	log.Printf("   - Rejeitados por erro ao ler coordenadas: %d", rejectedCoords)
+	return nil
+}
+
+// resolveEstado aceita a sigla da UF ou, como alternativa, o nome do estado
+func resolveEstado(code, name string) string {
+	if state, ok := domain.StateByUF(code); ok {
+		return state.UF
+	}
+	if state, ok := domain.StateByName(name); ok {
+		return state.UF
+	}
+	return ""
+}
+
+// GetByCEP busca um CEP aceitando formatos como "01310-100" e "01310100".
+// Quando o CEP exato não existe, usa os CEPs vizinhos cadastrados: se o
+// anterior e o seguinte pertencem ao mesmo município, o CEP está dentro da
+// faixa desse município e o resultado é marcado como aproximado.
+func (ps *PostalCodeService) GetByCEP(ctx context.Context, cep string) (*domain.PostalCode, bool, error) {
+	normalized, err := utils.NormalizeCEP(cep)
+	if err != nil {
+		return nil, false, err
+	}
+
+	postalCode, err := ps.repo.GetByCEP(ctx, normalized)
+	if err != nil {
+		return nil, false, err
+	}
+	if postalCode != nil {
+		return postalCode, false, nil
+	}
+
+	lower, upper, err := ps.repo.GetNeighbors(ctx, normalized)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if lower != nil && upper != nil &&
+		lower.Estado == upper.Estado && utils.FoldKey(lower.Municipio) == utils.FoldKey(upper.Municipio) {
+		approx := *lower
+		approx.CEP = normalized
+		approx.Localidade = ""
+		return &approx, true, nil
+	}
+
+	return nil, false, nil
+}
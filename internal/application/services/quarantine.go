@@ -0,0 +1,92 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+var tsvCleaner = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// QuarantineFile grava as linhas rejeitadas de uma importação. O formato é
+// escolhido pela extensão: .ndjson/.jsonl gera um JSON por linha; qualquer
+// outra gera TSV com as colunas row, reason, detail, estado e a linha original.
+type QuarantineFile struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	ndjson bool
+}
+
+// NewQuarantineFile cria (ou sobrescreve) o arquivo de quarentena
+func NewQuarantineFile(path string) (*QuarantineFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de quarentena: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	q := &QuarantineFile{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		ndjson: ext == ".ndjson" || ext == ".jsonl",
+	}
+
+	if !q.ndjson {
+		if _, err := q.writer.WriteString("row\treason\tdetail\testado\trecord\n"); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// Write grava uma linha rejeitada; pode ser usado como ImportOptions.Quarantine
+func (q *QuarantineFile) Write(rejected domain.RejectedRecord) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.ndjson {
+		data, err := json.Marshal(rejected)
+		if err != nil {
+			return err
+		}
+		q.writer.Write(data)
+		return q.writer.WriteByte('\n')
+	}
+
+	// Tabs e quebras de linha dentro dos campos são trocados por espaço para
+	// não quebrar o TSV; a linha original segue com suas colunas separadas por tab
+	record := make([]string, len(rejected.Record))
+	for i, field := range rejected.Record {
+		record[i] = tsvCleaner.Replace(field)
+	}
+
+	_, err := fmt.Fprintf(q.writer, "%s\t%s\t%s\t%s\t%s\n",
+		strconv.FormatInt(rejected.Row, 10),
+		rejected.Reason,
+		tsvCleaner.Replace(rejected.Detail),
+		rejected.Estado,
+		strings.Join(record, "\t"))
+	return err
+}
+
+// Close grava o buffer e fecha o arquivo
+func (q *QuarantineFile) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.writer.Flush(); err != nil {
+		q.file.Close()
+		return err
+	}
+	return q.file.Close()
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+// importReport acumula as contagens da importação. accept e reject são
+// chamados apenas pela etapa de parse (uma goroutine), então não há locks.
+type importReport struct {
+	*domain.ImportReport
+	start         time.Time
+	quarantineErr error
+}
+
+func newImportReport(opts domain.ImportOptions) *importReport {
+	now := time.Now()
+	return &importReport{
+		start: now,
+		ImportReport: &domain.ImportReport{
+			Source:          opts.SourceName,
+			SourceSHA256:    opts.SourceSHA256,
+			StartedAt:       now,
+			RejectedBy:      map[string]int64{},
+			AcceptedByState: map[string]int64{},
+			RejectedByState: map[string]int64{},
+			BatchSize:       opts.BatchSize,
+			Workers:         opts.Workers,
+			DryRun:          opts.DryRun,
+		},
+	}
+}
+
+func (r *importReport) accept(location domain.Location) {
+	r.RowsProcessed++
+	r.Accepted++
+	r.AcceptedByState[location.Estado]++
+}
+
+// parseGeoNamesRow é a etapa de parse do pipeline GeoNames: valida a linha e
+// contabiliza o resultado no relatório
+func (r *importReport) parseGeoNamesRow(opts domain.ImportOptions) func(pipelineRow) (domain.Location, bool) {
+	return func(row pipelineRow) (domain.Location, bool) {
+		if row.err != nil {
+			return domain.Location{}, r.reject(opts, row, domain.RejectMalformed, row.err.Error(), "")
+		}
+
+		location, reason, detail := parseGeoNamesRecord(row.record)
+		if reason != "" {
+			return location, r.reject(opts, row, reason, detail, location.Estado)
+		}
+
+		r.accept(location)
+		return location, true
+	}
+}
+
+// reject contabiliza a linha e a envia para a quarentena; sempre retorna false
+// para poder ser usado direto como retorno do parse
+func (r *importReport) reject(opts domain.ImportOptions, row pipelineRow, reason, detail, estado string) bool {
+	r.RowsProcessed++
+	r.Rejected++
+	r.RejectedBy[reason]++
+	if estado != "" {
+		r.RejectedByState[estado]++
+	}
+
+	if opts.Quarantine != nil && r.quarantineErr == nil {
+		err := opts.Quarantine(domain.RejectedRecord{
+			Row:    row.pos.row,
+			Reason: reason,
+			Detail: detail,
+			Estado: estado,
+			Record: row.record,
+		})
+		if err != nil {
+			log.Printf("⚠️ Erro ao gravar quarentena, linhas rejeitadas seguintes não serão gravadas: %v", err)
+			r.quarantineErr = err
+		}
+	}
+
+	return false
+}
+
+// finish fecha o relatório com o total gravado e o erro final, se houver
+func (r *importReport) finish(inserted int64, err error) *domain.ImportReport {
+	r.FinishedAt = time.Now()
+	r.DurationSeconds = r.FinishedAt.Sub(r.start).Seconds()
+	r.Inserted = inserted
+	if r.DurationSeconds > 0 {
+		r.RowsPerSecond = float64(r.RowsProcessed) / r.DurationSeconds
+	}
+
+	r.Status = domain.ImportRunCompleted
+	if err != nil {
+		r.Status = domain.ImportRunFailed
+		r.Error = err.Error()
+	}
+
+	return r.ImportReport
+}
+
+// log registra o resumo da importação no formato dos logs anteriores
+func (r *importReport) log() {
+	if r.DryRun {
+		log.Printf("🧪 Simulação concluída! Nada foi gravado; %d registros seriam importados", r.Accepted)
+	} else {
+		log.Printf("✅ Importação concluída! Total: %d registros", r.Inserted)
+	}
+	log.Printf("📊 Estatísticas de rejeição:")
+	log.Printf("   - Total de linhas processadas: %d", r.RowsProcessed)
+
+	reasons := make([]string, 0, len(r.RejectedBy))
+	for reason := range r.RejectedBy {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		log.Printf("   - Rejeitadas (%s): %d", reason, r.RejectedBy[reason])
+	}
+
+	if r.DryRun {
+		log.Printf("   - ✓ Aceitas: %d", r.Accepted)
+	} else {
+		log.Printf("   - ✓ Aceitas e importadas: %d", r.Inserted)
+	}
+	log.Printf("⏱️ Tempo: %.1fs (%.0f linhas/s, lotes de %d, %d workers)",
+		r.DurationSeconds, r.RowsPerSecond, r.BatchSize, r.Workers)
+}
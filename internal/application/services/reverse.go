@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services/interfaces"
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// LocateMunicipio resolve o município de uma coordenada pelo modo pedido
+// (domain.ReverseAuto, ReverseByPolygon ou ReverseByProximity) e retorna
+// também o método usado e, pela proximidade, a distância até a sede.
+// Pelo polígono, a localização vinculada ao mesmo código IBGE é usada para
+// trazer a hierarquia; sem ela, os dados vêm do próprio limite.
+func LocateMunicipio(ctx context.Context, locations interfaces.IImportService, boundaries interfaces.IBoundaryService, lon, lat float64, mode string) (*domain.Location, string, *float64, error) {
+	if mode != domain.ReverseByProximity {
+		boundary, err := boundaries.FindMunicipioContaining(ctx, lon, lat)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		if boundary != nil {
+			location, err := locations.GetLocationByCodigoIBGE(ctx, boundary.CodigoIBGE)
+			if err != nil {
+				return nil, "", nil, err
+			}
+			if location == nil {
+				location = &domain.Location{
+					Municipio:  boundary.Municipio,
+					Estado:     boundary.Estado,
+					CodigoIBGE: boundary.CodigoIBGE,
+				}
+			}
+			return location, domain.ReverseByPolygon, nil, nil
+		}
+
+		if mode == domain.ReverseByPolygon {
+			return nil, "", nil, nil
+		}
+	}
+
+	location, err := locations.GetNearestMunicipio(ctx, lon, lat)
+	if err != nil || location == nil {
+		return nil, "", nil, err
+	}
+
+	distance := utils.HaversineKm(lat, lon, location.Localizacao.Coordinates[1], location.Localizacao.Coordinates[0])
+	return location, domain.ReverseByProximity, &distance, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils/shapefile"
+)
+
+// ImportShapefile importa uma camada de pontos ou polígonos de um shapefile
+// (.shp ou .zip) como localizações, acrescentando-as à coleção. Pontos são
+// usados como estão; de cada polígono é usado o centroide do maior anel.
+// Os atributos do DBF viram Municipio, Estado, Populacao e CodigoIBGE conforme
+// mapping.
+//
+// O pipeline, a validação e o relatório são os mesmos de ImportData. Como o
+// shapefile não é lido em sequência de bytes, não há checkpoint nem -resume;
+// com opts.DryRun a camada é apenas lida e validada.
+func (is *ImportService) ImportShapefile(ctx context.Context, path string, mapping domain.ColumnMapping, opts domain.ImportOptions) (*domain.ImportReport, error) {
+	opts = withImportDefaults(opts)
+	report := newImportReport(opts)
+
+	if opts.Resume || opts.Diff {
+		err := fmt.Errorf("-resume e -diff não se aplicam a shapefiles")
+		return report.finish(0, err), err
+	}
+
+	reader, err := shapefile.Open(path)
+	if err != nil {
+		err = fmt.Errorf("erro ao abrir shapefile: %w", err)
+		return report.finish(0, err), err
+	}
+	defer reader.Close()
+
+	fields := reader.Fields()
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.Name
+	}
+
+	columns, err := resolveColumnMapping(header, mapping)
+	if err != nil {
+		return report.finish(0, err), err
+	}
+
+	kind := "pontos"
+	if reader.ShapeType().IsPolygon() {
+		kind = "polígonos (centroides)"
+	}
+	log.Printf("🗺️ Shapefile com %d registros de %s; colunas: %v", reader.Len(), kind, header)
+
+	insert := func(context.Context, []domain.Location) error { return nil }
+	if !opts.DryRun {
+		insert = is.target(opts).InsertLocations
+	}
+
+	var row int64
+	pipeline := newImportPipeline(ctx, opts)
+	pipeline.source(func() (pipelineRow, error) {
+		for {
+			record, err := reader.Next()
+			if err != nil {
+				return pipelineRow{}, err
+			}
+			if record.Deleted {
+				continue
+			}
+			row++
+			return pipelineRow{record: record.Values(fields), data: record.Shape, pos: position{row: row}}, nil
+		}
+	})
+	pipeline.parse(func(row pipelineRow) (domain.Location, bool) {
+		var point shapefile.Point
+		switch shape := row.data.(type) {
+		case shapefile.Point:
+			point = shape
+		case shapefile.Polygon:
+			point = shape.Centroid()
+		default:
+			return domain.Location{}, report.reject(opts, row, domain.RejectGeometry, "registro sem geometria", "")
+		}
+
+		location, reason, detail := columns.location(row.record, point.X, point.Y)
+		if reason != "" {
+			return location, report.reject(opts, row, reason, detail, location.Estado)
+		}
+
+		report.accept(location)
+		return location, true
+	})
+	pipeline.batch(0)
+	inserted, err := pipeline.write(insert, nil)
+	if err == nil {
+		err = report.quarantineErr
+	}
+	if opts.DryRun {
+		inserted = 0
+	}
+	if err != nil {
+		return report.finish(inserted, err), err
+	}
+
+	report.finish(inserted, nil)
+	report.log()
+	return report.ImportReport, nil
+}
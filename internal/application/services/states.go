@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+// nearestMunicipioMaxKm limita a busca do município mais próximo de uma
+// coordenada; distâncias maiores indicam um ponto fora do território
+const nearestMunicipioMaxKm = 100.0
+
+// ListStates retorna as 27 UFs do registro com o número de municípios, a
+// população somada deles e o número de localizações dos dados importados
+func (is *ImportService) ListStates(ctx context.Context) ([]domain.StateSummary, error) {
+	totals, err := is.repo.GetStateTotals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byUF := make(map[string]domain.StateTotals, len(totals))
+	for _, t := range totals {
+		byUF[t.Estado] = t
+	}
+
+	summaries := make([]domain.StateSummary, len(domain.States))
+	for i, state := range domain.States {
+		summaries[i] = domain.StateSummary{
+			State:        state,
+			Municipios:   byUF[state.UF].Municipios,
+			Populacao:    byUF[state.UF].Populacao,
+			Localizacoes: byUF[state.UF].Localizacoes,
+		}
+	}
+
+	return summaries, nil
+}
+
+// GetState retorna o resumo de uma UF; nil se a sigla não existe
+func (is *ImportService) GetState(ctx context.Context, uf string) (*domain.StateSummary, error) {
+	summaries, err := is.ListStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, summary := range summaries {
+		if summary.UF == uf {
+			return &summary, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetRegion soma os totais das UFs de uma região; nil se a região não existe
+func (is *ImportService) GetRegion(ctx context.Context, regiao string) (*domain.RegionSummary, error) {
+	summaries, err := is.ListStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	region := domain.RegionSummary{Regiao: regiao, Estados: []domain.StateSummary{}}
+	for _, summary := range summaries {
+		if summary.Regiao != regiao {
+			continue
+		}
+		region.Estados = append(region.Estados, summary)
+		region.Municipios += summary.Municipios
+		region.Populacao += summary.Populacao
+		region.Localizacoes += summary.Localizacoes
+	}
+
+	if len(region.Estados) == 0 {
+		return nil, nil
+	}
+	return &region, nil
+}
+
+// ListMunicipiosByEstado retorna uma página dos municípios de uma UF,
+// aplicando os padrões de paginação e ordenação
+func (is *ImportService) ListMunicipiosByEstado(ctx context.Context, uf string, opts domain.ListOptions) (*domain.LocationPage, error) {
+	return is.repo.ListLocationsByEstado(ctx, uf, withListDefaults(opts))
+}
+
+// ListMunicipiosByRegion retorna uma página das localizações de uma região do
+// IBGE (domain.RegionLevel*)
+func (is *ImportService) ListMunicipiosByRegion(ctx context.Context, level, codigo string, opts domain.ListOptions) (*domain.LocationPage, error) {
+	return is.repo.ListLocationsByRegion(ctx, level, codigo, withListDefaults(opts))
+}
+
+// GetNearestMunicipio retorna o município com código IBGE cuja sede está mais
+// próxima do ponto, até nearestMunicipioMaxKm
+func (is *ImportService) GetNearestMunicipio(ctx context.Context, longitude, latitude float64) (*domain.Location, error) {
+	return is.repo.GetNearestLocationWithIBGE(ctx, longitude, latitude, nearestMunicipioMaxKm)
+}
+
+// withListDefaults completa as opções de listagem, limitando o tamanho da página
+func withListDefaults(opts domain.ListOptions) domain.ListOptions {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = domain.DefaultPageLimit
+	}
+	if opts.Limit > domain.MaxPageLimit {
+		opts.Limit = domain.MaxPageLimit
+	}
+	if opts.Sort != domain.SortByPopulacao {
+		opts.Sort = domain.SortByMunicipio
+	}
+	return opts
+}
+
+// CreateEstadoIndex cria os índices usados nas listagens por estado
+func (is *ImportService) CreateEstadoIndex(ctx context.Context) error {
+	return is.repo.CreateEstadoIndex(ctx)
+}
@@ -5,33 +5,66 @@ import (
 
 	handlers "github.com/Kaguyo/Geolocation-Brasil/internal/api"
 	"github.com/Kaguyo/Geolocation-Brasil/internal/application/services"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/config"
 	"github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
 	"github.com/Kaguyo/Geolocation-Brasil/internal/infrastructure/mongodb"
 )
 
 type Application struct {
-	DB      *mongodb.Database
-	Router  http.Handler
-	Service services.ImportService
-	Server  *http.Server
+	DB         *mongodb.Database
+	Router     http.Handler
+	Service    services.ImportService
+	CEP        *services.PostalCodeService
+	Limites    *services.BoundaryService
+	Conflacao  *services.ConflationService
+	Auditoria  *services.AuditService
+	Exportacao *services.ExportService
+	Snapshots  *services.DatasetService
+	Server     *http.Server
 }
 
-func Build(mongoURI, dbName, collection string) (*Application, error) {
+func Build(cfg *config.Config) (*Application, error) {
 
-	db, err := mongodb.ConnectDB(mongoURI, dbName)
+	db, err := mongodb.ConnectDB(cfg.Storage.MongoURI, cfg.Storage.Database, cfg.Storage.ConnectTimeout)
 	if err != nil {
 		return nil, err
 	}
 
 	var geoRepository interfaces.IGeoRepository
-	geoRepository = mongodb.NewGeoRepository(db.Database) // Ajustar para aceitar interface em vez de struct concreta
-	geoService := services.NewGeoService(geoRepository)
-	geoHandler := handlers.NewAPI(geoService)
-	router := geoHandler.SetupRoutes()
+	geoRepository = mongodb.NewGeoRepository(db.Database, cfg.Storage.Collection) // Ajustar para aceitar interface em vez de struct concreta
+	var importRunRepository interfaces.IImportRunRepository = mongodb.NewImportRunRepository(db.Database)
+	geoService := services.NewGeoService(geoRepository, importRunRepository)
+
+	var postalCodeRepository interfaces.IPostalCodeRepository = mongodb.NewPostalCodeRepository(db.Database)
+	postalCodeService := services.NewPostalCodeService(postalCodeRepository)
+
+	var boundaryRepository interfaces.IBoundaryRepository = mongodb.NewBoundaryRepository(db.Database)
+	boundaryService := services.NewBoundaryService(boundaryRepository)
+
+	conflationService := services.NewConflationService(geoRepository)
+	auditService := services.NewAuditService(geoRepository, boundaryRepository)
+	exportService := services.NewExportService(geoRepository)
+
+	var datasetVersionRepository interfaces.IDatasetVersionRepository = mongodb.NewDatasetVersionRepository(db.Database)
+	datasetService := services.NewDatasetService(geoRepository, datasetVersionRepository, importRunRepository)
+
+	geoHandler := handlers.NewAPI(geoService, postalCodeService, boundaryService, datasetService)
+	router := geoHandler.SetupRoutes(handlers.RouterOptions{
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+		AllowedMethods: cfg.CORS.AllowedMethods,
+		AllowedHeaders: cfg.CORS.AllowedHeaders,
+		LogRequests:    cfg.Logging.Requests,
+	})
 
 	return &Application{
-		DB:      db,
-		Router:  router,
-		Service: *geoService,
+		DB:         db,
+		Router:     router,
+		Service:    *geoService,
+		CEP:        postalCodeService,
+		Limites:    boundaryService,
+		Conflacao:  conflationService,
+		Auditoria:  auditService,
+		Exportacao: exportService,
+		Snapshots:  datasetService,
 	}, nil
 }
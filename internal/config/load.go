@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigEnv aponta o arquivo de configuração quando -config não é informado
+const ConfigEnv = "GEO_CONFIG"
+
+// DefaultFiles são os arquivos procurados no diretório atual, nessa ordem,
+// quando nem -config nem GEO_CONFIG são informados
+var DefaultFiles = []string{"geolocation.yaml", "geolocation.yml", "geolocation.toml"}
+
+// field descreve uma chave da configuração: o nome no arquivo (seção.chave),
+// as variáveis de ambiente (a primeira tem precedência; as demais são nomes
+// antigos aceitos por compatibilidade) e onde o valor fica em Config
+type field struct {
+	key    string
+	env    []string
+	secret bool
+	value  func(c *Config) interface{}
+}
+
+// fields são todas as chaves, na ordem em que config print as mostra
+var fields = []field{
+	{key: "storage.mongo_uri", env: []string{"GEO_STORAGE_MONGO_URI", "MONGO_URI"}, secret: true, value: func(c *Config) interface{} { return &c.Storage.MongoURI }},
+	{key: "storage.database", env: []string{"GEO_STORAGE_DATABASE", "DB_NAME"}, value: func(c *Config) interface{} { return &c.Storage.Database }},
+	{key: "storage.collection", env: []string{"GEO_STORAGE_COLLECTION"}, value: func(c *Config) interface{} { return &c.Storage.Collection }},
+	{key: "storage.connect_timeout", env: []string{"GEO_STORAGE_CONNECT_TIMEOUT"}, value: func(c *Config) interface{} { return &c.Storage.ConnectTimeout }},
+
+	{key: "server.port", env: []string{"GEO_SERVER_PORT", "API_PORT"}, value: func(c *Config) interface{} { return &c.Server.Port }},
+	{key: "server.read_timeout", env: []string{"GEO_SERVER_READ_TIMEOUT"}, value: func(c *Config) interface{} { return &c.Server.ReadTimeout }},
+	{key: "server.write_timeout", env: []string{"GEO_SERVER_WRITE_TIMEOUT"}, value: func(c *Config) interface{} { return &c.Server.WriteTimeout }},
+	{key: "server.idle_timeout", env: []string{"GEO_SERVER_IDLE_TIMEOUT"}, value: func(c *Config) interface{} { return &c.Server.IdleTimeout }},
+	{key: "server.shutdown_timeout", env: []string{"GEO_SERVER_SHUTDOWN_TIMEOUT"}, value: func(c *Config) interface{} { return &c.Server.ShutdownTimeout }},
+
+	{key: "import.geonames_url", env: []string{"GEO_IMPORT_GEONAMES_URL"}, value: func(c *Config) interface{} { return &c.Import.GeoNamesURL }},
+	{key: "import.tmp_dir", env: []string{"GEO_IMPORT_TMP_DIR"}, value: func(c *Config) interface{} { return &c.Import.TmpDir }},
+	{key: "import.timeout", env: []string{"GEO_IMPORT_TIMEOUT"}, value: func(c *Config) interface{} { return &c.Import.Timeout }},
+	{key: "import.batch_size", env: []string{"GEO_IMPORT_BATCH_SIZE"}, value: func(c *Config) interface{} { return &c.Import.BatchSize }},
+	{key: "import.workers", env: []string{"GEO_IMPORT_WORKERS"}, value: func(c *Config) interface{} { return &c.Import.Workers }},
+
+	{key: "cors.allowed_origins", env: []string{"GEO_CORS_ALLOWED_ORIGINS"}, value: func(c *Config) interface{} { return &c.CORS.AllowedOrigins }},
+	{key: "cors.allowed_methods", env: []string{"GEO_CORS_ALLOWED_METHODS"}, value: func(c *Config) interface{} { return &c.CORS.AllowedMethods }},
+	{key: "cors.allowed_headers", env: []string{"GEO_CORS_ALLOWED_HEADERS"}, value: func(c *Config) interface{} { return &c.CORS.AllowedHeaders }},
+
+	{key: "logging.requests", env: []string{"GEO_LOGGING_REQUESTS"}, value: func(c *Config) interface{} { return &c.Logging.Requests }},
+	{key: "logging.file", env: []string{"GEO_LOGGING_FILE"}, value: func(c *Config) interface{} { return &c.Logging.File }},
+}
+
+func findField(key string) *field {
+	for i := range fields {
+		if fields[i].key == key {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// Load monta a configuração: os padrões, o arquivo (path, ou GEO_CONFIG, ou o
+// primeiro de DefaultFiles que existir) e as variáveis de ambiente lidas por
+// lookupEnv. As flags de cada comando são aplicadas depois, por quem chama.
+// A configuração não é validada aqui: chame Validate depois das flags.
+func Load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	for _, f := range fields {
+		cfg.origins[f.key] = "padrão"
+	}
+
+	explicit := path != ""
+	if !explicit {
+		if env, ok := lookupEnv(ConfigEnv); ok && env != "" {
+			path, explicit = env, true
+		}
+	}
+	if !explicit {
+		for _, name := range DefaultFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(lookupEnv); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile aplica as chaves de um arquivo YAML ou TOML, escolhido pela extensão
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("erro ao ler configuração: %v", err)
+	}
+
+	var entries []entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		entries, err = parseYAML(string(data))
+	case ".toml":
+		entries, err = parseTOML(string(data))
+	default:
+		return fmt.Errorf("formato de configuração não suportado: %s (use .yaml, .yml ou .toml)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %v", path, err)
+	}
+
+	source := "arquivo " + path
+	for _, e := range entries {
+		f := findField(e.key)
+		if f == nil {
+			return fmt.Errorf("erro ao ler %s: linha %d: chave desconhecida %q", path, e.line, e.key)
+		}
+		if err := f.set(c, e.values, e.list); err != nil {
+			return fmt.Errorf("erro ao ler %s: linha %d: %s: %v", path, e.line, e.key, err)
+		}
+		c.origins[f.key] = source
+	}
+	c.file = path
+	return nil
+}
+
+// loadEnv aplica as variáveis de ambiente; listas são separadas por vírgula
+// (as demais chaves usam o valor inteiro, já que URIs de réplica têm vírgulas)
+func (c *Config) loadEnv(lookupEnv func(string) (string, bool)) error {
+	for _, f := range fields {
+		for _, name := range f.env {
+			raw, ok := lookupEnv(name)
+			if !ok || raw == "" {
+				continue
+			}
+			values := []string{raw}
+			if _, isList := f.value(c).(*[]string); isList {
+				values = nil
+				for _, v := range strings.Split(raw, ",") {
+					if v = strings.TrimSpace(v); v != "" {
+						values = append(values, v)
+					}
+				}
+			}
+			if err := f.set(c, values, false); err != nil {
+				return fmt.Errorf("variável de ambiente %s: %v", name, err)
+			}
+			c.origins[f.key] = "env " + name
+			break
+		}
+	}
+	return nil
+}
+
+// set converte e grava o valor da chave; list indica que veio como lista
+func (f *field) set(c *Config, values []string, list bool) error {
+	target := f.value(c)
+	if list {
+		if _, ok := target.(*[]string); !ok {
+			return fmt.Errorf("esperado um único valor, não uma lista")
+		}
+	}
+	if p, ok := target.(*[]string); ok {
+		*p = append([]string{}, values...)
+		return nil
+	}
+
+	value := ""
+	if len(values) > 0 {
+		value = values[0]
+	}
+	switch p := target.(type) {
+	case *string:
+		*p = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("número inválido %q", value)
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("use true ou false, não %q", value)
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("duração inválida %q (ex: 15s, 5m)", value)
+		}
+		*p = d
+	}
+	return nil
+}
+
+// File retorna o arquivo de configuração carregado; vazio se nenhum
+func (c *Config) File() string {
+	return c.file
+}
+
+// SetOrigin registra que a chave foi sobreposta (ex: "flag -port")
+func (c *Config) SetOrigin(key, origin string) {
+	c.origins[key] = origin
+}
+
+// Setting é uma chave da configuração efetiva, pronta para exibição
+type Setting struct {
+	Key    string
+	Value  string // segredos já vêm mascarados
+	Origin string
+	Env    string // variável de ambiente principal
+}
+
+// Settings lista todas as chaves com o valor efetivo e a origem
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, 0, len(fields))
+	for _, f := range fields {
+		var value string
+		switch p := f.value(c).(type) {
+		case *string:
+			value = strconv.Quote(*p)
+			if f.secret {
+				value = strconv.Quote(RedactURI(*p))
+			}
+		case *int:
+			value = strconv.Itoa(*p)
+		case *bool:
+			value = strconv.FormatBool(*p)
+		case *time.Duration:
+			value = p.String()
+		case *[]string:
+			quoted := make([]string, len(*p))
+			for i, v := range *p {
+				quoted[i] = strconv.Quote(v)
+			}
+			value = "[" + strings.Join(quoted, ", ") + "]"
+		}
+		settings = append(settings, Setting{Key: f.key, Value: value, Origin: c.origins[f.key], Env: f.env[0]})
+	}
+	return settings
+}
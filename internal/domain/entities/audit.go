@@ -0,0 +1,63 @@
+package entities
+
+import "time"
+
+// Verificações da auditoria de qualidade dos dados
+const (
+	AuditDuplicate         = "duplicado"
+	AuditOutsideState      = "fora_do_estado"
+	AuditMissingPopulation = "populacao_ausente"
+	AuditNameCasing        = "grafia_suspeita"
+	AuditMissingCapital    = "capital_ausente"
+)
+
+// AuditChecks lista as verificações na ordem dos relatórios
+var AuditChecks = []string{AuditDuplicate, AuditOutsideState, AuditMissingPopulation, AuditNameCasing, AuditMissingCapital}
+
+// Valores padrão da auditoria
+const (
+	DefaultAuditDuplicateKm = 5.0
+	DefaultAuditSamples     = 100
+)
+
+// AuditOptions configura a auditoria de uma coleção
+type AuditOptions struct {
+	// Collection é a coleção auditada; vazio audita a coleção principal
+	Collection string
+	// DuplicateDistanceKm é a distância até a qual localizações de mesmo nome
+	// normalizado e UF são consideradas duplicadas
+	DuplicateDistanceKm float64
+	// Polygons confere a UF pelos limites municipais importados (import limites),
+	// uma consulta por localização; sem ele, apenas pelo retângulo da UF
+	Polygons bool
+	// MaxSamples limita os problemas listados por verificação (os totais
+	// continuam completos); negativo lista todos
+	MaxSamples int
+}
+
+// AuditIssue é um problema encontrado em uma localização (ou, em
+// capital_ausente, em uma UF)
+type AuditIssue struct {
+	Check      string  `json:"check"`
+	Municipio  string  `json:"municipio"`
+	Estado     string  `json:"estado"`
+	CodigoIBGE string  `json:"codigo_ibge,omitempty"`
+	GeoNameID  int64   `json:"geonameid,omitempty"`
+	Latitude   float64 `json:"latitude,omitempty"`
+	Longitude  float64 `json:"longitude,omitempty"`
+	Detail     string  `json:"detail,omitempty"`
+}
+
+// AuditReport é o resultado da auditoria
+type AuditReport struct {
+	Collection          string           `json:"collection,omitempty"`
+	GeneratedAt         time.Time        `json:"generated_at"`
+	DurationSeconds     float64          `json:"duration_seconds"`
+	Locations           int64            `json:"locations"`
+	DuplicateDistanceKm float64          `json:"duplicate_distance_km"`
+	Polygons            bool             `json:"polygons"`
+	Counts              map[string]int64 `json:"counts"`
+	// Notes explica limitações da execução (ex: limites não importados)
+	Notes  []string     `json:"notes,omitempty"`
+	Issues []AuditIssue `json:"issues"`
+}
@@ -0,0 +1,37 @@
+package entities
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// MultiPolygon é uma geometria GeoJSON MultiPolygon. Limites do tipo Polygon
+// são gravados como MultiPolygon de um polígono, para um único formato.
+type MultiPolygon struct {
+	Type        string           `json:"type" bson:"type"`
+	Coordinates [][][][2]float64 `json:"coordinates" bson:"coordinates"` // polígonos → anéis → [longitude, latitude]
+}
+
+// Boundary é o limite (malha) de um município
+type Boundary struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CodigoIBGE string             `json:"codigo_ibge" bson:"codigo_ibge"`
+	Municipio  string             `json:"municipio" bson:"municipio"`
+	Estado     string             `json:"estado" bson:"estado"`
+	AreaKm2    float64            `json:"area_km2,omitempty" bson:"area_km2,omitempty"`
+	Geometria  MultiPolygon       `json:"geometria" bson:"geometria"`
+}
+
+// Métodos de geocodificação reversa
+const (
+	ReverseByPolygon   = "poligono"    // município cujo limite contém o ponto
+	ReverseByProximity = "proximidade" // município com a sede mais próxima
+	ReverseAuto        = "auto"        // polígono e, se nenhum contiver o ponto, proximidade
+)
+
+// ReverseGeocodeResponse é a resposta da API para a geocodificação reversa
+type ReverseGeocodeResponse struct {
+	Municipio  string `json:"municipio"`
+	Estado     string `json:"estado"`
+	CodigoIBGE string `json:"codigo_ibge"`
+	Metodo     string `json:"metodo"`
+	// DistanciaKm é a distância até a sede, preenchida no método por proximidade
+	DistanciaKm *float64 `json:"distancia_km,omitempty"`
+}
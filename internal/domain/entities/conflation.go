@@ -0,0 +1,77 @@
+package entities
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Campos de Location escolhidos por fonte na conflação, com o mesmo nome do
+// documento no banco
+const (
+	FieldMunicipio   = "municipio"
+	FieldLocalizacao = "localizacao"
+	FieldPopulacao   = "populacao"
+	FieldCodigoIBGE  = "codigo_ibge"
+	FieldGeoNameID   = "geonameid"
+	FieldHierarquia  = "hierarquia"
+)
+
+// ConflationFields lista os campos aceitos em ConflationOptions.FieldPriority
+var ConflationFields = []string{FieldMunicipio, FieldLocalizacao, FieldPopulacao, FieldCodigoIBGE, FieldGeoNameID, FieldHierarquia}
+
+// Critérios usados para casar um registro com uma localização canônica
+const (
+	MatchByCodigoIBGE = "codigo_ibge"
+	MatchByNameState  = "nome_uf"
+)
+
+// DefaultConflationDistanceKm é a distância máxima entre registros de mesmo
+// nome e UF para que sejam considerados a mesma localidade
+const DefaultConflationDistanceKm = 20.0
+
+// ConflationSource é uma coleção importada de uma fonte (GeoNames, IBGE, OSM...)
+type ConflationSource struct {
+	Nome    string `json:"nome"`
+	Colecao string `json:"colecao"`
+}
+
+// ConflationOptions configura a conflação de várias fontes em uma coleção
+// canônica
+type ConflationOptions struct {
+	// Sources em ordem de prioridade padrão: o valor de um campo vem da
+	// primeira fonte que o tenha
+	Sources []ConflationSource
+	// FieldPriority sobrepõe a ordem das fontes para campos específicos
+	// (ex: populacao → osm, ibge, geonames); fontes omitidas vêm depois
+	FieldPriority map[string][]string
+	// Target é a coleção canônica, recriada a cada conflação
+	Target string
+	// MaxDistanceKm limita o casamento por nome e UF
+	MaxDistanceKm float64
+	// DryRun apenas casa os registros e gera o relatório
+	DryRun bool
+}
+
+// SourceRecord liga uma localização canônica a um registro de origem
+type SourceRecord struct {
+	Fonte   string             `json:"fonte" bson:"fonte"`
+	Colecao string             `json:"colecao" bson:"colecao"`
+	ID      primitive.ObjectID `json:"id" bson:"id"`
+	// Criterio é como o registro foi casado (MatchBy*); vazio no registro que
+	// originou a localização canônica
+	Criterio    string  `json:"criterio,omitempty" bson:"criterio,omitempty"`
+	DistanciaKm float64 `json:"distancia_km" bson:"distancia_km"`
+}
+
+// ConflationReport resume uma conflação
+type ConflationReport struct {
+	Target          string           `json:"target"`
+	MaxDistanceKm   float64          `json:"max_distance_km"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	RecordsBySource map[string]int64 `json:"records_by_source"`
+	Canonical       int64            `json:"canonical"`
+	Inserted        int64            `json:"inserted"`
+	MatchedBy       map[string]int64 `json:"matched_by"`
+	// SingleSource conta localizações canônicas com registro de uma só fonte
+	SingleSource map[string]int64 `json:"single_source_by_source"`
+	// FieldsBySource conta de qual fonte veio cada campo
+	FieldsBySource map[string]map[string]int64 `json:"fields_by_source"`
+	DryRun         bool                        `json:"dry_run"`
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DatasetVersion é um snapshot nomeado da coleção de localizações, guardado em
+// uma coleção própria do banco. O snapshot ativo é o que está na coleção
+// principal e responde as consultas da API.
+type DatasetVersion struct {
+	ID     primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Nome   string             `json:"nome" bson:"nome"`
+	Versao int                `json:"versao" bson:"versao"` // sequencial, nunca reutilizada
+	// Colecao guarda a cópia dos documentos
+	Colecao string `json:"colecao" bson:"colecao"`
+	Ativo   bool   `json:"ativo" bson:"ativo"`
+	// Fonte e SourceSHA256 identificam o arquivo importado; ImportadoEm é o fim
+	// da importação. Vêm da última importação concluída, se não informados.
+	Fonte        string     `json:"fonte,omitempty" bson:"fonte,omitempty"`
+	SourceSHA256 string     `json:"source_sha256,omitempty" bson:"source_sha256,omitempty"`
+	ImportadoEm  *time.Time `json:"importado_em,omitempty" bson:"importado_em,omitempty"`
+	CriadoEm     time.Time  `json:"criado_em" bson:"criado_em"`
+	AtivadoEm    *time.Time `json:"ativado_em,omitempty" bson:"ativado_em,omitempty"`
+	// Total e PorEstado são as contagens de localizações no snapshot
+	Total     int64            `json:"total" bson:"total"`
+	PorEstado map[string]int64 `json:"por_estado" bson:"por_estado"`
+}
+
+// SnapshotOptions configura a criação de um snapshot da coleção principal
+type SnapshotOptions struct {
+	Nome string
+	// Fonte e SourceSHA256 sobrepõem os da última importação concluída
+	Fonte        string
+	SourceSHA256 string
+}
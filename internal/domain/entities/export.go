@@ -0,0 +1,56 @@
+package entities
+
+import "time"
+
+// Formatos de exportação de localizações
+const (
+	ExportGeoNames = "geonames" // TSV com as 19 colunas do dump do GeoNames
+	ExportCSV      = "csv"      // CSV com as colunas padrão do import csv
+	ExportGeoJSON  = "geojson"  // FeatureCollection de pontos
+	ExportNDJSON   = "ndjson"   // uma Feature GeoJSON por linha
+	ExportSnapshot = "snapshot" // documentos completos, recarregáveis com import snapshot
+)
+
+// ExportFormats lista os formatos aceitos em ExportOptions.Format
+var ExportFormats = []string{ExportGeoNames, ExportCSV, ExportGeoJSON, ExportNDJSON, ExportSnapshot}
+
+// Identificação do formato de snapshot, gravada na primeira linha do arquivo
+const (
+	SnapshotFormat  = "geolocation-brasil-snapshot"
+	SnapshotVersion = 1
+)
+
+// LocationFilter seleciona localizações; campos vazios não filtram
+type LocationFilter struct {
+	Estados []string `json:"estados,omitempty"`
+	// FeatureCodes só casa registros importados do GeoNames (ex: PPLC, PPLA)
+	FeatureCodes []string `json:"feature_codes,omitempty"`
+	MinPopulacao int      `json:"min_populacao,omitempty"`
+	MaxPopulacao int      `json:"max_populacao,omitempty"` // 0 = sem limite
+	BBox         *BBox    `json:"bbox,omitempty"`
+}
+
+// IsEmpty indica que o filtro seleciona a coleção inteira
+func (f LocationFilter) IsEmpty() bool {
+	return len(f.Estados) == 0 && len(f.FeatureCodes) == 0 && f.MinPopulacao == 0 && f.MaxPopulacao == 0 && f.BBox == nil
+}
+
+// ExportOptions configura a exportação de uma coleção
+type ExportOptions struct {
+	// Collection é a coleção exportada; vazio exporta a coleção principal
+	Collection string
+	// Format é um dos Export*
+	Format string
+	Filter LocationFilter
+}
+
+// SnapshotHeader é a primeira linha de um snapshot: identifica o formato e
+// permite conferir, na carga, que o arquivo está completo
+type SnapshotHeader struct {
+	Formato     string          `json:"formato"`
+	Versao      int             `json:"versao"`
+	Colecao     string          `json:"colecao,omitempty"`
+	ExportadoEm time.Time       `json:"exportado_em"`
+	Filtro      *LocationFilter `json:"filtro,omitempty"`
+	Total       int64           `json:"total"`
+}
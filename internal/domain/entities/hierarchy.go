@@ -0,0 +1,87 @@
+package entities
+
+// Níveis da divisão territorial do IBGE abaixo da UF
+const (
+	RegionLevelIntermediaria = "intermediaria"
+	RegionLevelImediata      = "imediata"
+	RegionLevelMesorregiao   = "mesorregiao"
+	RegionLevelMicrorregiao  = "microrregiao"
+)
+
+// RegionLevels lista os níveis aceitos nas consultas por região
+var RegionLevels = []string{RegionLevelIntermediaria, RegionLevelImediata, RegionLevelMesorregiao, RegionLevelMicrorregiao}
+
+// IBGERegion é uma região da divisão territorial do IBGE
+type IBGERegion struct {
+	Codigo string `json:"codigo" bson:"codigo"`
+	Nome   string `json:"nome" bson:"nome"`
+}
+
+// IBGEHierarchy são as regiões às quais o município pertence. Meso e
+// microrregiões foram substituídas pelas regiões imediatas e intermediárias em
+// 2017, mas continuam na DTB e em séries históricas.
+type IBGEHierarchy struct {
+	Intermediaria IBGERegion `json:"intermediaria,omitempty" bson:"intermediaria,omitempty"`
+	Imediata      IBGERegion `json:"imediata,omitempty" bson:"imediata,omitempty"`
+	Mesorregiao   IBGERegion `json:"mesorregiao,omitempty" bson:"mesorregiao,omitempty"`
+	Microrregiao  IBGERegion `json:"microrregiao,omitempty" bson:"microrregiao,omitempty"`
+}
+
+// IsEmpty indica que nenhum nível foi informado
+func (h IBGEHierarchy) IsEmpty() bool {
+	return h == IBGEHierarchy{}
+}
+
+// Region retorna a região do nível informado
+func (h IBGEHierarchy) Region(level string) IBGERegion {
+	switch level {
+	case RegionLevelIntermediaria:
+		return h.Intermediaria
+	case RegionLevelImediata:
+		return h.Imediata
+	case RegionLevelMesorregiao:
+		return h.Mesorregiao
+	case RegionLevelMicrorregiao:
+		return h.Microrregiao
+	}
+	return IBGERegion{}
+}
+
+// IsRegionLevel indica se o nível é um dos RegionLevels
+func IsRegionLevel(level string) bool {
+	for _, l := range RegionLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// HierarchyResponse é a resposta da API com a hierarquia completa de um município
+type HierarchyResponse struct {
+	Municipio     string      `json:"municipio"`
+	CodigoIBGE    string      `json:"codigo_ibge"`
+	Estado        string      `json:"estado"`
+	NomeEstado    string      `json:"nome_estado"`
+	Regiao        string      `json:"regiao"`
+	Intermediaria *IBGERegion `json:"intermediaria,omitempty"`
+	Imediata      *IBGERegion `json:"imediata,omitempty"`
+	Mesorregiao   *IBGERegion `json:"mesorregiao,omitempty"`
+	Microrregiao  *IBGERegion `json:"microrregiao,omitempty"`
+	// DistanciaKm é preenchida nas consultas por coordenada: distância até a
+	// sede do município escolhido
+	DistanciaKm *float64 `json:"distancia_km,omitempty"`
+}
+
+// RegionMembersResponse é a resposta paginada com os municípios de uma região
+type RegionMembersResponse struct {
+	Nivel      string             `json:"nivel"`
+	Codigo     string             `json:"codigo"`
+	Nome       string             `json:"nome"`
+	Total      int64              `json:"total"`
+	Page       int64              `json:"page"`
+	Limit      int64              `json:"limit"`
+	Sort       string             `json:"sort"`
+	Order      string             `json:"order"`
+	Municipios []LocationResponse `json:"municipios"`
+}
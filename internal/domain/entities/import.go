@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportOptions configura uma importação de dados
+type ImportOptions struct {
+	// BatchSize é o número de registros enviados ao repositório por escrita
+	BatchSize int
+	// Workers é o número de escritas em lote executadas em paralelo
+	Workers int
+	// SourceName e SourceSHA256 identificam o arquivo importado. Quando o
+	// checksum é informado, o progresso é registrado em import_runs.
+	SourceName   string
+	SourceSHA256 string
+	// Resume continua a última importação interrompida do mesmo arquivo a
+	// partir do último lote confirmado
+	Resume bool
+	// Quarantine, se informado, recebe cada linha rejeitada com o motivo
+	Quarantine func(RejectedRecord) error
+	// DryRun executa leitura e validação completas sem gravar nada no repositório
+	DryRun bool
+	// Diff, junto com DryRun, compara o arquivo com a coleção atual
+	Diff bool
+	// Collection é a coleção de destino; vazio grava na coleção principal
+	Collection string
+}
+
+// ColumnMapping indica de quais colunas (ou atributos) de um arquivo vêm os
+// campos de Location. Colunas vazias são procuradas pelos nomes usuais do IBGE.
+type ColumnMapping struct {
+	Municipio  string
+	Estado     string // sigla, código IBGE de 2 dígitos ou nome da UF
+	Populacao  string
+	CodigoIBGE string // código de 7 dígitos; também define a UF se Estado faltar
+	Latitude   string // apenas em arquivos tabulares (CSV)
+	Longitude  string
+}
+
+// Codificações aceitas na importação de CSV
+const (
+	EncodingAuto   = "auto" // UTF-8 se válido, senão Latin-1
+	EncodingUTF8   = "utf-8"
+	EncodingLatin1 = "latin1"
+)
+
+// CSVOptions descreve o layout de um CSV genérico de pontos
+type CSVOptions struct {
+	// Delimiter é o separador de colunas; 0 detecta entre ';', ',' e tab
+	Delimiter rune
+	// NoHeader indica que a primeira linha já é de dados; as colunas são então
+	// referenciadas no mapeamento pela posição, começando em 1
+	NoHeader bool
+	// DecimalComma aceita coordenadas como "-23,5505"
+	DecimalComma bool
+	// Encoding é EncodingAuto (padrão), EncodingUTF8 ou EncodingLatin1
+	Encoding string
+	Mapping  ColumnMapping
+}
+
+// Status de uma execução de importação
+const (
+	ImportRunRunning   = "running"
+	ImportRunCompleted = "completed"
+	ImportRunFailed    = "failed"
+)
+
+// ImportRun registra o progresso de uma importação para permitir retomá-la
+type ImportRun struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Source       string             `json:"source" bson:"source"`
+	SourceSHA256 string             `json:"source_sha256" bson:"source_sha256"`
+	Status       string             `json:"status" bson:"status"`
+	Error        string             `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt    time.Time          `json:"started_at" bson:"started_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+	FinishedAt   *time.Time         `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	// Checkpoint: todas as linhas até CommittedRows (byte CommittedOffset do
+	// arquivo descompactado) já estão gravadas
+	CommittedRows   int64 `json:"committed_rows" bson:"committed_rows"`
+	CommittedOffset int64 `json:"committed_offset" bson:"committed_offset"`
+	LastBatch       int   `json:"last_batch" bson:"last_batch"`
+	Inserted        int64 `json:"inserted" bson:"inserted"`
+	Resumes         int   `json:"resumes" bson:"resumes"`
+}
+
+// Motivos de rejeição de uma linha na importação
+const (
+	RejectMalformed   = "linha_malformada"
+	RejectShortRecord = "registro_incompleto"
+	RejectCountry     = "pais_diferente"
+	RejectState       = "estado_invalido"
+	RejectCoords      = "coordenadas_invalidas"
+	RejectBounds      = "fora_dos_limites"
+	RejectMissingName = "municipio_vazio"
+	RejectGeometry    = "geometria_invalida"
+)
+
+// RejectedRecord é uma linha rejeitada na importação, com o motivo
+type RejectedRecord struct {
+	// Row é o número da linha de dados (1 = primeira linha após o header)
+	Row    int64    `json:"row"`
+	Reason string   `json:"reason"`
+	Detail string   `json:"detail,omitempty"`
+	Estado string   `json:"estado,omitempty"`
+	Record []string `json:"record"`
+}
+
+// ImportReport é o relatório estruturado de uma importação
+type ImportReport struct {
+	Source          string           `json:"source,omitempty"`
+	SourceSHA256    string           `json:"source_sha256,omitempty"`
+	Status          string           `json:"status"`
+	Error           string           `json:"error,omitempty"`
+	Resumed         bool             `json:"resumed"`
+	StartedAt       time.Time        `json:"started_at"`
+	FinishedAt      time.Time        `json:"finished_at"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	RowsProcessed   int64            `json:"rows_processed"`
+	Accepted        int64            `json:"accepted"`
+	Inserted        int64            `json:"inserted"`
+	Rejected        int64            `json:"rejected"`
+	RowsPerSecond   float64          `json:"rows_per_second"`
+	RejectedBy      map[string]int64 `json:"rejected_by_reason"`
+	AcceptedByState map[string]int64 `json:"accepted_by_state"`
+	RejectedByState map[string]int64 `json:"rejected_by_state"`
+	BatchSize       int              `json:"batch_size"`
+	Workers         int              `json:"workers"`
+	DryRun          bool             `json:"dry_run"`
+	Diff            *DatasetDiff     `json:"diff,omitempty"`
+}
+
+// Tipos de mudança encontrados ao comparar um arquivo com a coleção atual
+const (
+	DiffNew               = "novo"
+	DiffRemoved           = "removido"
+	DiffMoved             = "movido"
+	DiffRenamed           = "renomeado"
+	DiffPopulationChanged = "populacao_alterada"
+)
+
+// DatasetDiff resume as diferenças entre um arquivo e a coleção atual,
+// casando os registros pelo geonameid
+type DatasetDiff struct {
+	Unchanged         int64 `json:"unchanged"`
+	New               int64 `json:"new"`
+	Removed           int64 `json:"removed"`
+	Moved             int64 `json:"moved"`
+	Renamed           int64 `json:"renamed"`
+	PopulationChanged int64 `json:"population_changed"`
+	// CurrentWithoutID são registros da coleção sem geonameid (ex: dados de
+	// exemplo), que não podem ser comparados e seriam descartados
+	CurrentWithoutID int64       `json:"current_without_id"`
+	MovedThresholdKm float64     `json:"moved_threshold_km"`
+	Samples          []DiffEntry `json:"samples,omitempty"`
+}
+
+// DiffEntry é um exemplo de mudança encontrada no diff
+type DiffEntry struct {
+	Change     string  `json:"change"`
+	GeoNameID  int64   `json:"geonameid"`
+	Municipio  string  `json:"municipio"`
+	Estado     string  `json:"estado"`
+	Before     string  `json:"before,omitempty"`
+	After      string  `json:"after,omitempty"`
+	DistanceKm float64 `json:"distance_km,omitempty"`
+}
@@ -11,6 +11,17 @@ type Location struct {
 	Estado      string             `json:"estado" bson:"estado"`
 	Localizacao GeoJSON            `json:"localizacao" bson:"localizacao"`
 	Populacao   int                `json:"populacao,omitempty" bson:"populacao,omitempty"`
+	CodigoIBGE  string             `json:"codigo_ibge,omitempty" bson:"codigo_ibge,omitempty"` // código de 7 dígitos do município (DTB/IBGE)
+	GeoNameID   int64              `json:"geonameid,omitempty" bson:"geonameid,omitempty"`     // id do registro no GeoNames
+	Hierarquia  *IBGEHierarchy     `json:"hierarquia,omitempty" bson:"hierarquia,omitempty"`   // regiões do IBGE, vinculadas junto com o código
+	// Classe e código de feição do GeoNames (ex: P/PPLA2), apenas em registros
+	// importados do GeoNames
+	FeatureClass string `json:"feature_class,omitempty" bson:"feature_class,omitempty"`
+	FeatureCode  string `json:"feature_code,omitempty" bson:"feature_code,omitempty"`
+	// Proveniencia e Fontes só existem em coleções conflacionadas: a fonte de
+	// cada campo e os registros de origem casados
+	Proveniencia map[string]string `json:"proveniencia,omitempty" bson:"proveniencia,omitempty"`
+	Fontes       []SourceRecord    `json:"fontes,omitempty" bson:"fontes,omitempty"`
 }
 
 // GeoJSON representa um ponto geográfico no formato GeoJSON
@@ -21,10 +32,68 @@ type GeoJSON struct {
 
 // LocationResponse é a resposta da API
 type LocationResponse struct {
-	Municipio string  `json:"municipio"`
-	Estado    string  `json:"estado"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Municipio  string  `json:"municipio"`
+	Estado     string  `json:"estado"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	CodigoIBGE string  `json:"codigo_ibge"`
+	Populacao  int     `json:"populacao,omitempty"`
+}
+
+// Campos aceitos para ordenar listagens de localizações
+const (
+	SortByMunicipio = "municipio"
+	SortByPopulacao = "populacao"
+)
+
+// Limites de paginação das listagens
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// Limites de resultados da busca por retângulo (/bbox)
+const (
+	DefaultBBoxLimit = 500
+	MaxBBoxLimit     = 5000
+)
+
+// BBox é um retângulo em graus decimais
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// Contains indica se o ponto está no retângulo ampliado em margin graus
+func (b BBox) Contains(lon, lat, margin float64) bool {
+	return lon >= b.MinLon-margin && lon <= b.MaxLon+margin && lat >= b.MinLat-margin && lat <= b.MaxLat+margin
+}
+
+// ListOptions controla paginação e ordenação de listagens
+type ListOptions struct {
+	Page  int64  // começa em 1
+	Limit int64  // itens por página
+	Sort  string // SortByMunicipio ou SortByPopulacao
+	Desc  bool
+}
+
+// LocationPage é uma página de localizações com o total disponível
+type LocationPage struct {
+	Total int64
+	Items []Location
+}
+
+// MunicipiosResponse é a resposta paginada da listagem de municípios de um estado
+type MunicipiosResponse struct {
+	Estado     string             `json:"estado"`
+	Total      int64              `json:"total"`
+	Page       int64              `json:"page"`
+	Limit      int64              `json:"limit"`
+	Sort       string             `json:"sort"`
+	Order      string             `json:"order"`
+	Municipios []LocationResponse `json:"municipios"`
 }
 
 // ErrorResponse é a resposta de erro da API
@@ -32,3 +101,26 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
 }
+
+// PostalCode representa um CEP com o município e a coordenada aproximada
+type PostalCode struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CEP         string             `json:"cep" bson:"cep"` // 8 dígitos, sem hífen
+	Localidade  string             `json:"localidade,omitempty" bson:"localidade,omitempty"`
+	Municipio   string             `json:"municipio" bson:"municipio"`
+	Estado      string             `json:"estado" bson:"estado"`
+	CodigoIBGE  string             `json:"codigo_ibge,omitempty" bson:"codigo_ibge,omitempty"`
+	Localizacao GeoJSON            `json:"localizacao" bson:"localizacao"`
+}
+
+// CEPResponse é a resposta da API para consultas de CEP
+type CEPResponse struct {
+	CEP        string  `json:"cep"`
+	Localidade string  `json:"localidade,omitempty"`
+	Municipio  string  `json:"municipio"`
+	Estado     string  `json:"estado"`
+	CodigoIBGE string  `json:"codigo_ibge,omitempty"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Aproximado bool    `json:"aproximado"` // true quando resolvido pela faixa de CEPs vizinhos
+}
@@ -0,0 +1,213 @@
+package entities
+
+import (
+	"strings"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Regiões geográficas do Brasil
+const (
+	RegionNorte       = "Norte"
+	RegionNordeste    = "Nordeste"
+	RegionCentroOeste = "Centro-Oeste"
+	RegionSudeste     = "Sudeste"
+	RegionSul         = "Sul"
+)
+
+// Regions lista as regiões na ordem usada pelo IBGE
+var Regions = []string{RegionNorte, RegionNordeste, RegionSudeste, RegionSul, RegionCentroOeste}
+
+// State é uma unidade federativa (26 estados e o Distrito Federal)
+type State struct {
+	UF             string `json:"uf"`
+	CodigoIBGE     string `json:"codigo_ibge"`
+	Nome           string `json:"nome"`
+	Regiao         string `json:"regiao"`
+	Capital        string `json:"capital"`
+	FusoHorario    string `json:"fuso_horario"`
+	GeoNamesAdmin1 string `json:"geonames_admin1"`
+}
+
+// States é o registro das 27 unidades federativas, em ordem de código IBGE
+var States = []State{
+	{UF: "RO", CodigoIBGE: "11", Nome: "Rondônia", Regiao: RegionNorte, Capital: "Porto Velho", FusoHorario: "America/Porto_Velho", GeoNamesAdmin1: "24"},
+	{UF: "AC", CodigoIBGE: "12", Nome: "Acre", Regiao: RegionNorte, Capital: "Rio Branco", FusoHorario: "America/Rio_Branco", GeoNamesAdmin1: "01"},
+	{UF: "AM", CodigoIBGE: "13", Nome: "Amazonas", Regiao: RegionNorte, Capital: "Manaus", FusoHorario: "America/Manaus", GeoNamesAdmin1: "04"},
+	{UF: "RR", CodigoIBGE: "14", Nome: "Roraima", Regiao: RegionNorte, Capital: "Boa Vista", FusoHorario: "America/Boa_Vista", GeoNamesAdmin1: "25"},
+	{UF: "PA", CodigoIBGE: "15", Nome: "Pará", Regiao: RegionNorte, Capital: "Belém", FusoHorario: "America/Belem", GeoNamesAdmin1: "16"},
+	{UF: "AP", CodigoIBGE: "16", Nome: "Amapá", Regiao: RegionNorte, Capital: "Macapá", FusoHorario: "America/Belem", GeoNamesAdmin1: "03"},
+	{UF: "TO", CodigoIBGE: "17", Nome: "Tocantins", Regiao: RegionNorte, Capital: "Palmas", FusoHorario: "America/Araguaina", GeoNamesAdmin1: "31"},
+	{UF: "MA", CodigoIBGE: "21", Nome: "Maranhão", Regiao: RegionNordeste, Capital: "São Luís", FusoHorario: "America/Fortaleza", GeoNamesAdmin1: "13"},
+	{UF: "PI", CodigoIBGE: "22", Nome: "Piauí", Regiao: RegionNordeste, Capital: "Teresina", FusoHorario: "America/Fortaleza", GeoNamesAdmin1: "20"},
+	{UF: "CE", CodigoIBGE: "23", Nome: "Ceará", Regiao: RegionNordeste, Capital: "Fortaleza", FusoHorario: "America/Fortaleza", GeoNamesAdmin1: "06"},
+	{UF: "RN", CodigoIBGE: "24", Nome: "Rio Grande do Norte", Regiao: RegionNordeste, Capital: "Natal", FusoHorario: "America/Fortaleza", GeoNamesAdmin1: "22"},
+	{UF: "PB", CodigoIBGE: "25", Nome: "Paraíba", Regiao: RegionNordeste, Capital: "João Pessoa", FusoHorario: "America/Fortaleza", GeoNamesAdmin1: "17"},
+	{UF: "PE", CodigoIBGE: "26", Nome: "Pernambuco", Regiao: RegionNordeste, Capital: "Recife", FusoHorario: "America/Recife", GeoNamesAdmin1: "30"},
+	{UF: "AL", CodigoIBGE: "27", Nome: "Alagoas", Regiao: RegionNordeste, Capital: "Maceió", FusoHorario: "America/Maceio", GeoNamesAdmin1: "02"},
+	{UF: "SE", CodigoIBGE: "28", Nome: "Sergipe", Regiao: RegionNordeste, Capital: "Aracaju", FusoHorario: "America/Maceio", GeoNamesAdmin1: "28"},
+	{UF: "BA", CodigoIBGE: "29", Nome: "Bahia", Regiao: RegionNordeste, Capital: "Salvador", FusoHorario: "America/Bahia", GeoNamesAdmin1: "05"},
+	{UF: "MG", CodigoIBGE: "31", Nome: "Minas Gerais", Regiao: RegionSudeste, Capital: "Belo Horizonte", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "15"},
+	{UF: "ES", CodigoIBGE: "32", Nome: "Espírito Santo", Regiao: RegionSudeste, Capital: "Vitória", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "08"},
+	{UF: "RJ", CodigoIBGE: "33", Nome: "Rio de Janeiro", Regiao: RegionSudeste, Capital: "Rio de Janeiro", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "21"},
+	{UF: "SP", CodigoIBGE: "35", Nome: "São Paulo", Regiao: RegionSudeste, Capital: "São Paulo", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "27"},
+	{UF: "PR", CodigoIBGE: "41", Nome: "Paraná", Regiao: RegionSul, Capital: "Curitiba", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "18"},
+	{UF: "SC", CodigoIBGE: "42", Nome: "Santa Catarina", Regiao: RegionSul, Capital: "Florianópolis", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "26"},
+	{UF: "RS", CodigoIBGE: "43", Nome: "Rio Grande do Sul", Regiao: RegionSul, Capital: "Porto Alegre", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "23"},
+	{UF: "MS", CodigoIBGE: "50", Nome: "Mato Grosso do Sul", Regiao: RegionCentroOeste, Capital: "Campo Grande", FusoHorario: "America/Campo_Grande", GeoNamesAdmin1: "11"},
+	{UF: "MT", CodigoIBGE: "51", Nome: "Mato Grosso", Regiao: RegionCentroOeste, Capital: "Cuiabá", FusoHorario: "America/Cuiaba", GeoNamesAdmin1: "14"},
+	{UF: "GO", CodigoIBGE: "52", Nome: "Goiás", Regiao: RegionCentroOeste, Capital: "Goiânia", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "29"},
+	{UF: "DF", CodigoIBGE: "53", Nome: "Distrito Federal", Regiao: RegionCentroOeste, Capital: "Brasília", FusoHorario: "America/Sao_Paulo", GeoNamesAdmin1: "07"},
+}
+
+// Índices do registro, montados uma única vez
+var (
+	statesByUF     = map[string]State{}
+	statesByIBGE   = map[string]State{}
+	statesByAdmin1 = map[string]State{}
+	statesByName   = map[string]State{}
+)
+
+func init() {
+	for _, state := range States {
+		statesByUF[state.UF] = state
+		statesByIBGE[state.CodigoIBGE] = state
+		statesByAdmin1[state.GeoNamesAdmin1] = state
+		statesByName[utils.FoldKey(state.Nome)] = state
+	}
+}
+
+// StateByUF busca o estado pela sigla ("SP", "sp")
+func StateByUF(uf string) (State, bool) {
+	state, ok := statesByUF[strings.ToUpper(strings.TrimSpace(uf))]
+	return state, ok
+}
+
+// StateByCodigoIBGE busca o estado pelo código numérico do IBGE ("35") ou
+// pelos dois primeiros dígitos de um código de município ("3550308")
+func StateByCodigoIBGE(codigo string) (State, bool) {
+	codigo = strings.TrimSpace(codigo)
+	if len(codigo) < 2 {
+		return State{}, false
+	}
+	state, ok := statesByIBGE[codigo[:2]]
+	return state, ok
+}
+
+// StateByGeoNamesAdmin1 busca o estado pelo admin1 code do GeoNames ("27" = SP)
+func StateByGeoNamesAdmin1(code string) (State, bool) {
+	state, ok := statesByAdmin1[strings.TrimSpace(code)]
+	return state, ok
+}
+
+// StateByName busca o estado pelo nome, sem diferenciar acentos e maiúsculas
+func StateByName(name string) (State, bool) {
+	state, ok := statesByName[utils.FoldKey(name)]
+	return state, ok
+}
+
+// ResolveState aceita o estado informado pelo usuário como sigla ("SP"), nome
+// ("São Paulo", "sao paulo") ou código IBGE de dois dígitos ("35")
+func ResolveState(value string) (State, bool) {
+	value = strings.TrimSpace(value)
+	if state, ok := StateByUF(value); ok {
+		return state, true
+	}
+	if len(value) == 2 {
+		if state, ok := statesByIBGE[value]; ok {
+			return state, true
+		}
+	}
+	return StateByName(value)
+}
+
+// RegionByName aceita o nome da região sem diferenciar acentos, maiúsculas e
+// hífen ("Centro-Oeste", "centro oeste")
+func RegionByName(name string) (string, bool) {
+	key := utils.FoldKey(name)
+	for _, region := range Regions {
+		if utils.FoldKey(region) == key {
+			return region, true
+		}
+	}
+	return "", false
+}
+
+// stateBBoxes são os retângulos aproximados de cada UF, incluindo as ilhas
+// oceânicas habitadas (Fernando de Noronha em PE, Trindade em ES)
+var stateBBoxes = map[string][]BBox{
+	"RO": {{MinLon: -66.81, MinLat: -13.69, MaxLon: -59.77, MaxLat: -7.97}},
+	"AC": {{MinLon: -73.99, MinLat: -11.15, MaxLon: -66.62, MaxLat: -7.11}},
+	"AM": {{MinLon: -73.80, MinLat: -9.82, MaxLon: -56.10, MaxLat: 2.25}},
+	"RR": {{MinLon: -64.83, MinLat: -1.58, MaxLon: -58.89, MaxLat: 5.27}},
+	"PA": {{MinLon: -58.90, MinLat: -9.84, MaxLon: -46.06, MaxLat: 2.59}},
+	"AP": {{MinLon: -54.88, MinLat: -1.24, MaxLon: -49.88, MaxLat: 4.44}},
+	"TO": {{MinLon: -50.74, MinLat: -13.47, MaxLon: -45.70, MaxLat: -5.17}},
+	"MA": {{MinLon: -48.76, MinLat: -10.26, MaxLon: -41.80, MaxLat: -1.04}},
+	"PI": {{MinLon: -45.99, MinLat: -10.93, MaxLon: -40.37, MaxLat: -2.74}},
+	"CE": {{MinLon: -41.42, MinLat: -7.86, MaxLon: -37.25, MaxLat: -2.78}},
+	"RN": {{MinLon: -38.58, MinLat: -6.98, MaxLon: -34.97, MaxLat: -4.83}},
+	"PB": {{MinLon: -38.77, MinLat: -8.30, MaxLon: -34.79, MaxLat: -6.03}},
+	"PE": {
+		{MinLon: -41.36, MinLat: -9.48, MaxLon: -34.81, MaxLat: -7.32},
+		{MinLon: -32.50, MinLat: -3.90, MaxLon: -32.35, MaxLat: -3.80},
+	},
+	"AL": {{MinLon: -38.24, MinLat: -10.50, MaxLon: -35.15, MaxLat: -8.81}},
+	"SE": {{MinLon: -38.25, MinLat: -11.57, MaxLon: -36.39, MaxLat: -9.51}},
+	"BA": {{MinLon: -46.62, MinLat: -18.35, MaxLon: -37.34, MaxLat: -8.53}},
+	"MG": {{MinLon: -51.05, MinLat: -22.92, MaxLon: -39.86, MaxLat: -14.23}},
+	"ES": {
+		{MinLon: -41.88, MinLat: -21.30, MaxLon: -39.67, MaxLat: -17.89},
+		{MinLon: -29.35, MinLat: -20.55, MaxLon: -28.80, MaxLat: -20.45},
+	},
+	"RJ": {{MinLon: -44.89, MinLat: -23.37, MaxLon: -40.96, MaxLat: -20.76}},
+	"SP": {{MinLon: -53.11, MinLat: -25.31, MaxLon: -44.16, MaxLat: -19.78}},
+	"PR": {{MinLon: -54.62, MinLat: -26.72, MaxLon: -48.02, MaxLat: -22.52}},
+	"SC": {{MinLon: -53.84, MinLat: -29.35, MaxLon: -48.36, MaxLat: -25.96}},
+	"RS": {{MinLon: -57.65, MinLat: -33.75, MaxLon: -49.69, MaxLat: -27.08}},
+	"MS": {{MinLon: -58.17, MinLat: -24.07, MaxLon: -50.92, MaxLat: -17.17}},
+	"MT": {{MinLon: -61.63, MinLat: -18.04, MaxLon: -50.22, MaxLat: -7.35}},
+	"GO": {{MinLon: -53.25, MinLat: -19.50, MaxLon: -45.91, MaxLat: -12.40}},
+	"DF": {{MinLon: -48.29, MinLat: -16.05, MaxLon: -47.31, MaxLat: -15.50}},
+}
+
+// StateBBoxes retorna os retângulos aproximados da UF; nil se a sigla não existe
+func StateBBoxes(uf string) []BBox {
+	return stateBBoxes[strings.ToUpper(strings.TrimSpace(uf))]
+}
+
+// MunicipalSeatFeatureCodes são os códigos de feição do GeoNames das sedes
+// municipais: capital federal, capitais estaduais e sedes dos demais
+// municípios. Os outros registros do GeoNames (bairros, povoados, rios,
+// serras, fazendas...) não são municípios.
+var MunicipalSeatFeatureCodes = []string{"PPLC", "PPLA", "PPLA2"}
+
+// StateSummary é uma UF com os totais calculados a partir dos dados importados.
+// Municipios e Populacao contam só os municípios (veja StateTotals);
+// Localizacoes conta todos os registros da UF.
+type StateSummary struct {
+	State
+	Municipios   int64 `json:"municipios"`
+	Populacao    int64 `json:"populacao"`
+	Localizacoes int64 `json:"localizacoes"`
+}
+
+// RegionSummary é uma região com suas UFs e os totais somados
+type RegionSummary struct {
+	Regiao       string         `json:"regiao"`
+	Municipios   int64          `json:"municipios"`
+	Populacao    int64          `json:"populacao"`
+	Localizacoes int64          `json:"localizacoes"`
+	Estados      []StateSummary `json:"estados"`
+}
+
+// StateTotals são os totais de um estado agregados pelo repositório. Um
+// registro é um município quando tem código IBGE, quando é uma sede municipal
+// do GeoNames (MunicipalSeatFeatureCodes) ou quando não tem classe de feição
+// (fontes que já trazem só municípios, como os dados de exemplo).
+type StateTotals struct {
+	Estado       string `bson:"_id"`
+	Municipios   int64  `bson:"municipios"`
+	Populacao    int64  `bson:"populacao"` // dos municípios
+	Localizacoes int64  `bson:"localizacoes"`
+}
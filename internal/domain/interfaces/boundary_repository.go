@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IBoundaryRepository interface {
+	// WithCollection retorna o mesmo repositório apontando para outra coleção
+	WithCollection(name string) IBoundaryRepository
+	// CollectionName retorna o nome da coleção do repositório
+	CollectionName() string
+	// CopyTo substitui a coleção target por uma cópia desta, de uma só vez
+	CopyTo(ctx context.Context, target string) error
+	// CreateIndexes cria o índice 2dsphere dos limites e o índice único de código IBGE
+	CreateIndexes(ctx context.Context) error
+	// InsertBoundaries insere um lote de limites sem abortá-lo por um limite
+	// recusado; retorna os códigos IBGE recusados pelo índice 2dsphere
+	// (geometria inválida) e os ignorados por já existirem
+	InsertBoundaries(ctx context.Context, boundaries []domain.Boundary) (rejected, duplicates []string, err error)
+	// FindContaining busca o limite que contém o ponto, sem a geometria
+	FindContaining(ctx context.Context, longitude, latitude float64) (*domain.Boundary, error)
+	// DropCollection remove todos os limites
+	DropCollection(ctx context.Context) error
+}
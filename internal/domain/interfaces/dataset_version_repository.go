@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IDatasetVersionRepository interface {
+	// CreateVersion registra um snapshot com o próximo número de versão
+	CreateVersion(ctx context.Context, version *domain.DatasetVersion) error
+	// NextVersion retorna o número da próxima versão (a maior já usada + 1)
+	NextVersion(ctx context.Context) (int, error)
+	// ListVersions retorna os snapshots da versão mais recente para a mais antiga
+	ListVersions(ctx context.Context) ([]domain.DatasetVersion, error)
+	// FindByName busca um snapshot pelo nome; nil se não existe
+	FindByName(ctx context.Context, nome string) (*domain.DatasetVersion, error)
+	// FindActive busca o snapshot ativo; nil se nenhum está ativo
+	FindActive(ctx context.Context) (*domain.DatasetVersion, error)
+	// SetActive marca o snapshot como ativo e desmarca os demais
+	SetActive(ctx context.Context, nome string) error
+	// ClearActive desmarca o snapshot ativo
+	ClearActive(ctx context.Context) error
+	// DeleteVersion remove o registro do snapshot
+	DeleteVersion(ctx context.Context, nome string) error
+}
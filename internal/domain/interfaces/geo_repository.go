@@ -4,6 +4,7 @@ import (
 	"context"
 
 	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type IGeoRepository interface {
@@ -11,14 +12,54 @@ type IGeoRepository interface {
 	CreateGeoIndex(ctx context.Context) error
 	// CreateTextIndex cria índice de texto para busca
 	CreateTextIndex(ctx context.Context) error
-	// Inserts as many locations as given through parameter
+	// Inserts as many locations as given through parameter (registros com geonameid
+	// já existente são ignorados, o que torna a retomada de importações idempotente)
 	InsertLocations(ctx context.Context, locationBuffer []domain.Location) error
+	// InsertLocationsAsIs grava as localizações exatamente como recebidas, sem
+	// normalizar, e falha em qualquer erro de escrita, inclusive duplicatas
+	InsertLocationsAsIs(ctx context.Context, locations []domain.Location) error
 	// GetNearbyLocations busca localizações próximas a um ponto
 	GetLocationsInKilometersRange(ctx context.Context, longitude, latitude float64, maxDistanceKm float64) (*[]domain.Location, error)
+	// GetLocationsInBBox retorna até limit localizações dentro do retângulo
+	GetLocationsInBBox(ctx context.Context, bbox domain.BBox, limit int) ([]domain.Location, error)
 	// GetLocationByName busca localização por nome de município
 	GetLocationByName(ctx context.Context, municipio, estado string) (*domain.Location, error)
 	// ImportBrazilianCities importa dados simplificados de cidades brasileiras
 	ImportTest(ctx context.Context, locations []domain.Location) error
 	// DropCollection recria a coleção, removendo todos os dados existentes
 	DropCollection(ctx context.Context, collection string) error
+	// ForEachLocation percorre todas as localizações da coleção sem carregá-las de uma vez
+	ForEachLocation(ctx context.Context, fn func(domain.Location) error) error
+	// ForEachMatchingLocation percorre, em ordem de _id, as localizações que casam com o filtro
+	ForEachMatchingLocation(ctx context.Context, filter domain.LocationFilter, fn func(domain.Location) error) error
+	// CountLocations conta as localizações que casam com o filtro
+	CountLocations(ctx context.Context, filter domain.LocationFilter) (int64, error)
+	// CreateGeoNameIDIndex cria índice único no id do GeoNames
+	CreateGeoNameIDIndex(ctx context.Context) error
+	// CreateIBGEIndex cria índice no código IBGE do município
+	CreateIBGEIndex(ctx context.Context) error
+	// GetLocationsByEstado retorna todas as localizações de um estado
+	GetLocationsByEstado(ctx context.Context, estado string) ([]domain.Location, error)
+	// CreateEstadoIndex cria os índices usados nas listagens por estado
+	CreateEstadoIndex(ctx context.Context) error
+	// ListLocationsByEstado retorna uma página dos municípios de um estado, os
+	// mesmos contados em GetStateTotals
+	ListLocationsByEstado(ctx context.Context, estado string, opts domain.ListOptions) (*domain.LocationPage, error)
+	// GetStateTotals agrega, por estado, municípios, população e localizações
+	GetStateTotals(ctx context.Context) ([]domain.StateTotals, error)
+	// SetCodigoIBGE associa um código IBGE e, se informada, a hierarquia de
+	// regiões do IBGE a uma localização existente
+	SetCodigoIBGE(ctx context.Context, id primitive.ObjectID, codigo string, hierarquia *domain.IBGEHierarchy) error
+	// ListLocationsByRegion retorna uma página das localizações de uma região do IBGE
+	ListLocationsByRegion(ctx context.Context, level, codigo string, opts domain.ListOptions) (*domain.LocationPage, error)
+	// GetNearestLocationWithIBGE busca a localização com código IBGE mais próxima do ponto
+	GetNearestLocationWithIBGE(ctx context.Context, longitude, latitude, maxDistanceKm float64) (*domain.Location, error)
+	// GetLocationByCodigoIBGE busca localização pelo código IBGE de 7 dígitos
+	GetLocationByCodigoIBGE(ctx context.Context, codigo string) (*domain.Location, error)
+	// CollectionName retorna o nome da coleção do repositório
+	CollectionName() string
+	// CopyTo substitui a coleção target por uma cópia dos documentos desta coleção
+	CopyTo(ctx context.Context, target string) error
+	// WithCollection retorna o mesmo repositório apontando para outra coleção do banco
+	WithCollection(name string) IGeoRepository
 }
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IImportRunRepository interface {
+	// CreateRun registra o início de uma importação
+	CreateRun(ctx context.Context, run *domain.ImportRun) error
+	// FindResumable busca a última importação não concluída do arquivo com este checksum
+	FindResumable(ctx context.Context, sourceSHA256 string) (*domain.ImportRun, error)
+	// FindLastCompleted busca a última importação concluída; nil se não há nenhuma
+	FindLastCompleted(ctx context.Context) (*domain.ImportRun, error)
+	// MarkResumed marca uma importação interrompida como em execução novamente
+	MarkResumed(ctx context.Context, id primitive.ObjectID) error
+	// SaveCheckpoint grava o último lote confirmado
+	SaveCheckpoint(ctx context.Context, id primitive.ObjectID, batch int, rows, offset, inserted int64) error
+	// FinishRun marca a importação como concluída ou com falha
+	FinishRun(ctx context.Context, id primitive.ObjectID, status string, runErr error) error
+}
@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+)
+
+type IPostalCodeRepository interface {
+	// WithCollection retorna o mesmo repositório apontando para outra coleção
+	WithCollection(name string) IPostalCodeRepository
+	// CollectionName retorna o nome da coleção do repositório
+	CollectionName() string
+	// CopyTo substitui a coleção target por uma cópia desta, de uma só vez
+	CopyTo(ctx context.Context, target string) error
+	// CreateIndexes cria o índice único de CEP
+	CreateIndexes(ctx context.Context) error
+	// InsertPostalCodes insere um lote de CEPs
+	InsertPostalCodes(ctx context.Context, postalCodes []domain.PostalCode) error
+	// GetByCEP busca um CEP exato (8 dígitos, sem hífen)
+	GetByCEP(ctx context.Context, cep string) (*domain.PostalCode, error)
+	// GetNeighbors retorna o maior CEP cadastrado abaixo e o menor acima do CEP informado
+	GetNeighbors(ctx context.Context, cep string) (lower, upper *domain.PostalCode, err error)
+	// DropCollection remove todos os CEPs
+	DropCollection(ctx context.Context) error
+}
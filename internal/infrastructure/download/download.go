@@ -0,0 +1,433 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHeaderTimeout é o tempo máximo de espera pelos headers da resposta.
+// O download em si é limitado apenas pelo contexto, já que arquivos grandes
+// podem levar minutos.
+const DefaultHeaderTimeout = 30 * time.Second
+
+var (
+	// ErrChecksumMismatch indica que o SHA-256 do arquivo baixado difere do esperado
+	ErrChecksumMismatch = errors.New("checksum do arquivo baixado não confere")
+	// ErrSizeMismatch indica que o tamanho do arquivo baixado difere do esperado
+	ErrSizeMismatch = errors.New("tamanho do arquivo baixado não confere")
+)
+
+// ProgressFunc recebe os bytes já obtidos e o total esperado (-1 se desconhecido)
+type ProgressFunc func(done, total int64)
+
+// Options configura um download
+type Options struct {
+	// URL de origem: http(s):// ou file:// para um espelho local
+	URL string
+	// Dest é o caminho final do arquivo. Durante o download os dados ficam em
+	// Dest+".part", o que permite retomar com Range após uma falha, e os
+	// metadados (ETag, Last-Modified, checksum) em Dest+".meta.json".
+	Dest string
+	// ExpectedSHA256 (hex) e ExpectedSize, quando informados, são verificados ao final
+	ExpectedSHA256 string
+	ExpectedSize   int64
+	// Force ignora os metadados salvos e baixa novamente mesmo se a origem não mudou
+	Force bool
+	// HeaderTimeout limita a espera pela resposta do servidor (0 = DefaultHeaderTimeout)
+	HeaderTimeout time.Duration
+	// Progress, se informado, é chamado conforme os dados chegam
+	Progress ProgressFunc
+	// Client permite injetar um http.Client (ex: em testes)
+	Client *http.Client
+}
+
+// Result descreve o resultado de um download
+type Result struct {
+	Path string
+	// NotModified indica que a origem não mudou desde o último download
+	// (304 ou arquivo local com mesmo tamanho e data) e nada foi baixado;
+	// Path pode não existir se o arquivo foi removido depois de usado
+	NotModified bool
+	Size        int64
+	SHA256      string
+	// Resumed indica que o download continuou de um .part existente
+	Resumed bool
+}
+
+// metadata é persistida ao lado do arquivo para requisições condicionais
+type metadata struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"` // vazio enquanto o download não foi concluído
+}
+
+// Fetch baixa opts.URL para opts.Dest
+func Fetch(ctx context.Context, opts Options) (*Result, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("URL de origem inválida: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return fetchHTTP(ctx, opts)
+	case "file":
+		return fetchFile(ctx, opts, u)
+	default:
+		return nil, fmt.Errorf("esquema de URL não suportado: %q", u.Scheme)
+	}
+}
+
+func fetchHTTP(ctx context.Context, opts Options) (*Result, error) {
+	client := opts.Client
+	if client == nil {
+		headerTimeout := opts.HeaderTimeout
+		if headerTimeout == 0 {
+			headerTimeout = DefaultHeaderTimeout
+		}
+		client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   15 * time.Second,
+				ResponseHeaderTimeout: headerTimeout,
+			},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := loadMetadata(opts)
+	partPath := opts.Dest + ".part"
+
+	// Retomar um download interrompido: pede apenas os bytes que faltam. If-Range
+	// garante que, se o arquivo mudou na origem, o servidor devolve tudo (200).
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil && info.Size() > 0 {
+		offset = info.Size()
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if meta != nil && meta.ETag != "" {
+			req.Header.Set("If-Range", meta.ETag)
+		} else if meta != nil && meta.LastModified != "" {
+			req.Header.Set("If-Range", meta.LastModified)
+		}
+	} else if meta != nil && meta.SHA256 != "" {
+		// Só faz requisição condicional se o último download foi concluído
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao baixar arquivo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	resumed := false
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	total := resp.ContentLength
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return &Result{Path: opts.Dest, NotModified: true, Size: meta.Size, SHA256: meta.SHA256}, nil
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return nil, fmt.Errorf("resposta parcial inesperada: Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		resumed = true
+		flags = os.O_WRONLY | os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+	case http.StatusOK:
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// O .part já tem todos os bytes (o processo parou entre a cópia e a
+		// renomeação) ou é maior que o arquivo na origem. No primeiro caso basta
+		// concluí-lo; no segundo ele é descartado e o download recomeça.
+		if offset > 0 {
+			if size, err := contentRangeTotal(resp.Header.Get("Content-Range")); err == nil && size == offset {
+				if meta == nil {
+					meta = &metadata{URL: opts.URL, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+				}
+				result, err := finalize(opts, partPath, meta)
+				if err != nil {
+					return nil, err
+				}
+				result.Resumed = true
+				return result, nil
+			}
+			if err := os.Remove(partPath); err != nil {
+				return nil, fmt.Errorf("erro ao descartar download parcial: %w", err)
+			}
+			resp.Body.Close()
+			return fetchHTTP(ctx, opts)
+		}
+		return nil, fmt.Errorf("erro ao baixar arquivo: status %d", resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("erro ao baixar arquivo: status %d", resp.StatusCode)
+	}
+
+	// Metadados da versão que está sendo baixada, salvos já no início para que
+	// If-Range funcione ao retomar este .part
+	newMeta := &metadata{
+		URL:          opts.URL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if !resumed {
+		if err := saveMetadata(opts.Dest, newMeta); err != nil {
+			return nil, err
+		}
+	} else if meta != nil {
+		newMeta = meta
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo: %w", err)
+	}
+
+	_, err = io.Copy(out, &progressReader{r: resp.Body, done: offset, total: total, progress: opts.Progress})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// O .part é mantido para que a próxima execução retome daqui
+		return nil, fmt.Errorf("erro ao salvar arquivo: %w", err)
+	}
+
+	result, err := finalize(opts, partPath, newMeta)
+	if err != nil {
+		return nil, err
+	}
+	result.Resumed = resumed
+	return result, nil
+}
+
+// fetchFile copia de um espelho local (file://). A data de modificação e o
+// tamanho do arquivo fazem o papel de Last-Modified/ETag.
+func fetchFile(ctx context.Context, opts Options, u *url.URL) (*Result, error) {
+	source := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		source = "//" + u.Host + u.Path
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir espelho local: %w", err)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	lastModified := info.ModTime().UTC().Format(http.TimeFormat)
+	etag := fmt.Sprintf("%q", strconv.FormatInt(info.Size(), 10)+"-"+strconv.FormatInt(info.ModTime().UnixNano(), 10))
+
+	if meta := loadMetadata(opts); meta != nil && meta.SHA256 != "" && meta.ETag == etag {
+		return &Result{Path: opts.Dest, NotModified: true, Size: meta.Size, SHA256: meta.SHA256}, nil
+	}
+
+	partPath := opts.Dest + ".part"
+	out, err := os.Create(partPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo: %w", err)
+	}
+
+	_, err = io.Copy(out, &progressReader{r: &contextReader{ctx: ctx, r: in}, total: info.Size(), progress: opts.Progress})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("erro ao copiar espelho local: %w", err)
+	}
+
+	return finalize(opts, partPath, &metadata{URL: opts.URL, ETag: etag, LastModified: lastModified})
+}
+
+// finalize verifica tamanho e checksum do .part, move para o destino e salva os metadados
+func finalize(opts Options, partPath string, meta *metadata) (*Result, error) {
+	size, sum, err := hashFile(partPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ExpectedSize > 0 && size != opts.ExpectedSize {
+		os.Remove(partPath)
+		RemoveMetadata(opts.Dest)
+		return nil, fmt.Errorf("%w: esperado %d bytes, obtido %d", ErrSizeMismatch, opts.ExpectedSize, size)
+	}
+	if opts.ExpectedSHA256 != "" && !strings.EqualFold(sum, opts.ExpectedSHA256) {
+		os.Remove(partPath)
+		RemoveMetadata(opts.Dest)
+		return nil, fmt.Errorf("%w: esperado %s, obtido %s", ErrChecksumMismatch, opts.ExpectedSHA256, sum)
+	}
+
+	if err := os.Rename(partPath, opts.Dest); err != nil {
+		return nil, fmt.Errorf("erro ao mover arquivo baixado: %w", err)
+	}
+
+	meta.Size = size
+	meta.SHA256 = sum
+	if err := saveMetadata(opts.Dest, meta); err != nil {
+		return nil, err
+	}
+
+	return &Result{Path: opts.Dest, Size: size, SHA256: sum}, nil
+}
+
+// loadMetadata retorna os metadados do último download da mesma URL, se ainda válidos
+func loadMetadata(opts Options) *metadata {
+	if opts.Force {
+		return nil
+	}
+
+	data, err := os.ReadFile(opts.Dest + ".meta.json")
+	if err != nil {
+		return nil
+	}
+
+	var meta metadata
+	if err := json.Unmarshal(data, &meta); err != nil || meta.URL != opts.URL {
+		return nil
+	}
+
+	return &meta
+}
+
+func saveMetadata(dest string, meta *metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest+".meta.json", data, 0644); err != nil {
+		return fmt.Errorf("erro ao salvar metadados do download: %w", err)
+	}
+	return nil
+}
+
+// RemoveMetadata apaga os metadados salvos, forçando o próximo download completo
+func RemoveMetadata(dest string) error {
+	err := os.Remove(dest + ".meta.json")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("erro ao calcular checksum: %w", err)
+	}
+
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contentRangeStart extrai o início de um header "bytes 100-199/200"
+func contentRangeStart(header string) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("Content-Range inválido: %q", header)
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, fmt.Errorf("Content-Range inválido: %q", header)
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
+
+// contentRangeTotal extrai o tamanho total de um header "bytes */200" ou
+// "bytes 100-199/200"
+func contentRangeTotal(header string) (int64, error) {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok || !strings.HasPrefix(header, "bytes ") || total == "*" {
+		return 0, fmt.Errorf("Content-Range inválido: %q", header)
+	}
+	return strconv.ParseInt(total, 10, 64)
+}
+
+// progressReader reporta o avanço da leitura
+type progressReader struct {
+	r        io.Reader
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.progress != nil && n > 0 {
+		p.progress(p.done, p.total)
+	}
+	return n, err
+}
+
+// contextReader interrompe a leitura quando o contexto é cancelado
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(b)
+}
+
+// LogProgress retorna um ProgressFunc que registra o avanço no log a cada 10%
+// (ou a cada 10 MB quando o tamanho total é desconhecido)
+func LogProgress(label string) ProgressFunc {
+	var last int64 = -1
+	return func(done, total int64) {
+		if total > 0 {
+			step := done * 10 / total
+			if step != last {
+				last = step
+				log.Printf("   %s: %d%% (%.1f/%.1f MB)", label, step*10, float64(done)/1e6, float64(total)/1e6)
+			}
+			return
+		}
+
+		if step := done / 10e6; step != last {
+			last = step
+			log.Printf("   %s: %.1f MB", label, float64(done)/1e6)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	testContent = bytes.Repeat([]byte("0123456789abcdef"), 4096) // 64 KiB
+	testModTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func testSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// testServer serve content com http.ServeContent, que implementa Range,
+// If-Range, If-None-Match e If-Modified-Since, e guarda os headers recebidos
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	content  []byte
+	etag     string
+	requests []http.Header
+}
+
+func newTestServer(t *testing.T, content []byte, etag string) *testServer {
+	t.Helper()
+	ts := &testServer{content: content, etag: etag}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.mu.Lock()
+		ts.requests = append(ts.requests, r.Header.Clone())
+		content, etag := ts.content, ts.etag
+		ts.mu.Unlock()
+
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		http.ServeContent(w, r, "BR.zip", testModTime, bytes.NewReader(content))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) lastRequest() http.Header {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.requests[len(ts.requests)-1]
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("erro ao ler %s: %v", path, err)
+	}
+	return data
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("erro ao gravar %s: %v", path, err)
+	}
+}
+
+func TestFetchHTTPDownloadsAndVerifies(t *testing.T) {
+	ts := newTestServer(t, testContent, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "BR.zip")
+
+	result, err := Fetch(context.Background(), Options{
+		URL:            ts.URL,
+		Dest:           dest,
+		ExpectedSHA256: testSHA256(testContent),
+		ExpectedSize:   int64(len(testContent)),
+	})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if result.NotModified || result.Resumed {
+		t.Errorf("esperado download completo, obtido %+v", result)
+	}
+	if result.Size != int64(len(testContent)) || result.SHA256 != testSHA256(testContent) {
+		t.Errorf("tamanho/checksum = %d/%s", result.Size, result.SHA256)
+	}
+	if !bytes.Equal(readFile(t, dest), testContent) {
+		t.Error("conteúdo baixado difere da origem")
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Errorf(".part deveria ter sido removido: %v", err)
+	}
+}
+
+func TestFetchHTTPResumesWithRange(t *testing.T) {
+	ts := newTestServer(t, testContent, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "BR.zip")
+
+	half := len(testContent) / 2
+	writeFile(t, dest+".part", testContent[:half])
+	if err := saveMetadata(dest, &metadata{URL: ts.URL, ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest, ExpectedSHA256: testSHA256(testContent)})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !result.Resumed {
+		t.Error("esperado download retomado")
+	}
+	req := ts.lastRequest()
+	if got := req.Get("Range"); got != "bytes=32768-" {
+		t.Errorf("Range = %q", got)
+	}
+	if got := req.Get("If-Range"); got != `"v1"` {
+		t.Errorf("If-Range = %q", got)
+	}
+	if !bytes.Equal(readFile(t, dest), testContent) {
+		t.Error("conteúdo retomado difere da origem")
+	}
+}
+
+func TestFetchHTTPRestartsWhenIfRangeChanged(t *testing.T) {
+	ts := newTestServer(t, testContent, `"v2"`)
+	dest := filepath.Join(t.TempDir(), "BR.zip")
+
+	// .part de uma versão anterior: If-Range não confere e o servidor devolve 200
+	writeFile(t, dest+".part", []byte("conteúdo da versão antiga"))
+	if err := saveMetadata(dest, &metadata{URL: ts.URL, ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if result.Resumed {
+		t.Error("download não deveria ser retomado quando o ETag mudou")
+	}
+	if !bytes.Equal(readFile(t, dest), testContent) {
+		t.Error("conteúdo difere da nova versão")
+	}
+}
+
+func TestFetchHTTPNotModified(t *testing.T) {
+	tests := []struct {
+		name       string
+		etag       string
+		wantHeader string
+	}{
+		{"etag", `"v1"`, "If-None-Match"},
+		{"last-modified", "", "If-Modified-Since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, testContent, tt.etag)
+			dest := filepath.Join(t.TempDir(), "BR.zip")
+
+			if _, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest}); err != nil {
+				t.Fatalf("primeiro Fetch: %v", err)
+			}
+			result, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest})
+			if err != nil {
+				t.Fatalf("segundo Fetch: %v", err)
+			}
+			if !result.NotModified {
+				t.Errorf("esperado NotModified, obtido %+v", result)
+			}
+			if result.SHA256 != testSHA256(testContent) {
+				t.Errorf("SHA256 = %s", result.SHA256)
+			}
+			if ts.lastRequest().Get(tt.wantHeader) == "" {
+				t.Errorf("header %s ausente na requisição condicional", tt.wantHeader)
+			}
+
+			// Force ignora os metadados e baixa de novo
+			result, err = Fetch(context.Background(), Options{URL: ts.URL, Dest: dest, Force: true})
+			if err != nil {
+				t.Fatalf("Fetch com Force: %v", err)
+			}
+			if result.NotModified {
+				t.Error("Force deveria baixar novamente")
+			}
+		})
+	}
+}
+
+func TestFetchHTTPCompletePartAfter416(t *testing.T) {
+	ts := newTestServer(t, testContent, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "BR.zip")
+
+	// O processo parou entre a cópia e a renomeação: o .part está completo
+	writeFile(t, dest+".part", testContent)
+	if err := saveMetadata(dest, &metadata{URL: ts.URL, ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest, ExpectedSHA256: testSHA256(testContent)})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !result.Resumed || result.SHA256 != testSHA256(testContent) {
+		t.Errorf("resultado inesperado: %+v", result)
+	}
+	if !bytes.Equal(readFile(t, dest), testContent) {
+		t.Error("conteúdo difere da origem")
+	}
+
+	// O próximo download é condicional, não mais com Range
+	if _, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest}); err != nil {
+		t.Fatalf("Fetch seguinte: %v", err)
+	}
+	if got := ts.lastRequest().Get("Range"); got != "" {
+		t.Errorf("Range = %q na requisição seguinte", got)
+	}
+}
+
+func TestFetchHTTPDiscardsOversizedPartAfter416(t *testing.T) {
+	ts := newTestServer(t, testContent, `"v1"`)
+	dest := filepath.Join(t.TempDir(), "BR.zip")
+
+	writeFile(t, dest+".part", append(append([]byte{}, testContent...), "lixo"...))
+	if err := saveMetadata(dest, &metadata{URL: ts.URL, ETag: `"v1"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: dest})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if result.Resumed {
+		t.Error("o .part maior que a origem deveria ser descartado")
+	}
+	if !bytes.Equal(readFile(t, dest), testContent) {
+		t.Error("conteúdo difere da origem")
+	}
+}
+
+func TestFetchVerificationFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr error
+	}{
+		{"checksum", Options{ExpectedSHA256: testSHA256([]byte("outro"))}, ErrChecksumMismatch},
+		{"tamanho", Options{ExpectedSize: int64(len(testContent)) + 1}, ErrSizeMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, testContent, `"v1"`)
+			dest := filepath.Join(t.TempDir(), "BR.zip")
+			opts := tt.opts
+			opts.URL, opts.Dest = ts.URL, dest
+
+			_, err := Fetch(context.Background(), opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("erro = %v, esperado %v", err, tt.wantErr)
+			}
+			for _, path := range []string{dest, dest + ".part", dest + ".meta.json"} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s deveria ter sido removido: %v", filepath.Base(path), err)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchHTTPErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	_, err := Fetch(context.Background(), Options{URL: ts.URL, Dest: filepath.Join(t.TempDir(), "BR.zip")})
+	if err == nil {
+		t.Fatal("esperado erro para status 404")
+	}
+}
+
+func TestFetchFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "espelho.zip")
+	writeFile(t, source, testContent)
+	dest := filepath.Join(dir, "BR.zip")
+	url := "file://" + filepath.ToSlash(source)
+
+	result, err := Fetch(context.Background(), Options{URL: url, Dest: dest, ExpectedSHA256: testSHA256(testContent)})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if result.NotModified || !bytes.Equal(readFile(t, dest), testContent) {
+		t.Errorf("cópia inesperada: %+v", result)
+	}
+
+	result, err = Fetch(context.Background(), Options{URL: url, Dest: dest})
+	if err != nil {
+		t.Fatalf("segundo Fetch: %v", err)
+	}
+	if !result.NotModified {
+		t.Error("espelho inalterado deveria resultar em NotModified")
+	}
+
+	// Arquivo alterado (tamanho e data diferentes) é copiado de novo
+	changed := append(append([]byte{}, testContent...), "novo"...)
+	writeFile(t, source, changed)
+	if err := os.Chtimes(source, testModTime, testModTime); err != nil {
+		t.Fatal(err)
+	}
+	result, err = Fetch(context.Background(), Options{URL: url, Dest: dest})
+	if err != nil {
+		t.Fatalf("terceiro Fetch: %v", err)
+	}
+	if result.NotModified || !bytes.Equal(readFile(t, dest), changed) {
+		t.Errorf("espelho alterado não foi copiado: %+v", result)
+	}
+}
+
+func TestFetchUnsupportedScheme(t *testing.T) {
+	_, err := Fetch(context.Background(), Options{URL: "ftp://example.com/BR.zip", Dest: filepath.Join(t.TempDir(), "BR.zip")})
+	if err == nil {
+		t.Fatal("esperado erro para esquema ftp")
+	}
+}
+
+func TestContentRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantStart int64
+		wantTotal int64
+		startErr  bool
+		totalErr  bool
+	}{
+		{header: "bytes 100-199/200", wantStart: 100, wantTotal: 200},
+		{header: "bytes */200", startErr: true, wantTotal: 200},
+		{header: "bytes 0-9/*", wantStart: 0, totalErr: true},
+		{header: "items 0-9/10", startErr: true, totalErr: true},
+	}
+
+	for _, tt := range tests {
+		start, err := contentRangeStart(tt.header)
+		if (err != nil) != tt.startErr || (err == nil && start != tt.wantStart) {
+			t.Errorf("contentRangeStart(%q) = %d, %v", tt.header, start, err)
+		}
+		total, err := contentRangeTotal(tt.header)
+		if (err != nil) != tt.totalErr || (err == nil && total != tt.wantTotal) {
+			t.Errorf("contentRangeTotal(%q) = %d, %v", tt.header, total, err)
+		}
+	}
+}
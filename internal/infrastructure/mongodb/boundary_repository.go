@@ -0,0 +1,151 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Códigos de erro de escrita do MongoDB tratados na inserção de limites
+const (
+	duplicateKeyErrorCode    = 11000 // código IBGE já existente (índice único)
+	invalidGeometryErrorCode = 16755 // geometria recusada pelo índice 2dsphere
+)
+
+type BoundaryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBoundaryRepository(db *mongo.Database) *BoundaryRepository {
+	return &BoundaryRepository{
+		collection: db.Collection("limites_municipais"),
+	}
+}
+
+// WithCollection retorna o mesmo repositório apontando para outra coleção,
+// usada como área de preparo das importações
+func (br *BoundaryRepository) WithCollection(name string) interfaces.IBoundaryRepository {
+	return &BoundaryRepository{
+		collection: br.collection.Database().Collection(name),
+	}
+}
+
+// CollectionName retorna o nome da coleção do repositório
+func (br *BoundaryRepository) CollectionName() string {
+	return br.collection.Name()
+}
+
+// CopyTo substitui a coleção target por uma cópia dos documentos desta. O
+// $out troca a coleção de uma vez e mantém os índices que ela já tinha.
+func (br *BoundaryRepository) CopyTo(ctx context.Context, target string) error {
+	pipeline := mongo.Pipeline{{{Key: "$out", Value: target}}}
+	cursor, err := br.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("erro ao copiar coleção %s para %s: %v", br.collection.Name(), target, err)
+	}
+	return cursor.Close(ctx)
+}
+
+// CreateIndexes cria o índice 2dsphere dos limites e o índice único de código IBGE
+func (br *BoundaryRepository) CreateIndexes(ctx context.Context) error {
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "geometria", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "codigo_ibge", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+
+	_, err := br.collection.Indexes().CreateMany(ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de limites: %v", err)
+	}
+
+	log.Println("✅ Índices de limites municipais criados!")
+	return nil
+}
+
+// InsertBoundaries insere um lote de limites sem abortá-lo por um limite
+// recusado; retorna os códigos IBGE recusados pelo índice 2dsphere (geometria
+// inválida) e os ignorados por já existirem (o primeiro registro do código
+// prevalece). Outros erros de escrita são retornados como erro.
+func (br *BoundaryRepository) InsertBoundaries(ctx context.Context, boundaries []domain.Boundary) ([]string, []string, error) {
+	if len(boundaries) == 0 {
+		return nil, nil, nil
+	}
+
+	documents := make([]interface{}, len(boundaries))
+	for i, b := range boundaries {
+		documents[i] = b
+	}
+
+	// Não ordenado: um polígono recusado pelo índice 2dsphere não impede os demais
+	_, err := br.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return nil, nil, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return nil, nil, err
+	}
+
+	var rejected, duplicates []string
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Index < 0 || writeErr.Index >= len(boundaries) {
+			return nil, nil, err
+		}
+		codigo := boundaries[writeErr.Index].CodigoIBGE
+
+		switch writeErr.Code {
+		case duplicateKeyErrorCode:
+			duplicates = append(duplicates, codigo)
+		case invalidGeometryErrorCode:
+			rejected = append(rejected, codigo)
+		default:
+			return nil, nil, fmt.Errorf("erro ao inserir limite %s: %v", codigo, writeErr.Message)
+		}
+	}
+
+	return rejected, duplicates, nil
+}
+
+// FindContaining busca o limite que contém o ponto, sem a geometria
+func (br *BoundaryRepository) FindContaining(ctx context.Context, longitude, latitude float64) (*domain.Boundary, error) {
+	filter := bson.M{
+		"geometria": bson.M{
+			"$geoIntersects": bson.M{
+				"$geometry": bson.M{
+					"type":        "Point",
+					"coordinates": []float64{longitude, latitude},
+				},
+			},
+		},
+	}
+	opts := options.FindOne().SetProjection(bson.M{"geometria": 0})
+
+	var boundary domain.Boundary
+	err := br.collection.FindOne(ctx, filter, opts).Decode(&boundary)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &boundary, nil
+}
+
+// DropCollection remove todos os limites
+func (br *BoundaryRepository) DropCollection(ctx context.Context) error {
+	err := br.collection.Drop(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao dropar coleção de limites: %v", err)
+	}
+	log.Println("✅ Coleção de limites municipais deletada com sucesso!")
+	return nil
+}
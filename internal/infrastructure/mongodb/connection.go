@@ -15,9 +15,9 @@ type Database struct {
 	Database *mongo.Database
 }
 
-// ConnectDB estabelece conexão com MongoDB
-func ConnectDB(uri, dbName string) (*Database, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// ConnectDB estabelece conexão com MongoDB; timeout limita a conexão e o ping
+func ConnectDB(uri, dbName string, timeout time.Duration) (*Database, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	clientOptions := options.Client().ApplyURI(uri)
@@ -0,0 +1,137 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DatasetVersionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDatasetVersionRepository(db *mongo.Database) *DatasetVersionRepository {
+	return &DatasetVersionRepository{
+		collection: db.Collection("dataset_versions"),
+	}
+}
+
+// CreateVersion registra um snapshot; os índices únicos de nome e versão
+// impedem que duas criações simultâneas usem o mesmo número
+func (dr *DatasetVersionRepository) CreateVersion(ctx context.Context, version *domain.DatasetVersion) error {
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "nome", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "versao", Value: 1}}, Options: options.Index().SetUnique(true)},
+	}
+	if _, err := dr.collection.Indexes().CreateMany(ctx, indexModels); err != nil {
+		return fmt.Errorf("erro ao criar índices dos snapshots: %v", err)
+	}
+
+	result, err := dr.collection.InsertOne(ctx, version)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("já existe um snapshot com o nome %q ou a versão %d", version.Nome, version.Versao)
+		}
+		return fmt.Errorf("erro ao registrar snapshot: %v", err)
+	}
+
+	version.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// NextVersion retorna o número da próxima versão
+func (dr *DatasetVersionRepository) NextVersion(ctx context.Context) (int, error) {
+	opts := options.FindOne().SetSort(bson.M{"versao": -1})
+
+	var last domain.DatasetVersion
+	err := dr.collection.FindOne(ctx, bson.M{}, opts).Decode(&last)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 1, nil
+		}
+		return 0, err
+	}
+
+	return last.Versao + 1, nil
+}
+
+// ListVersions retorna os snapshots da versão mais recente para a mais antiga
+func (dr *DatasetVersionRepository) ListVersions(ctx context.Context) ([]domain.DatasetVersion, error) {
+	opts := options.Find().SetSort(bson.M{"versao": -1})
+	cursor, err := dr.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	versions := []domain.DatasetVersion{}
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// FindByName busca um snapshot pelo nome
+func (dr *DatasetVersionRepository) FindByName(ctx context.Context, nome string) (*domain.DatasetVersion, error) {
+	return dr.findOne(ctx, bson.M{"nome": nome})
+}
+
+// FindActive busca o snapshot ativo
+func (dr *DatasetVersionRepository) FindActive(ctx context.Context) (*domain.DatasetVersion, error) {
+	return dr.findOne(ctx, bson.M{"ativo": true})
+}
+
+func (dr *DatasetVersionRepository) findOne(ctx context.Context, filter bson.M) (*domain.DatasetVersion, error) {
+	var version domain.DatasetVersion
+	err := dr.collection.FindOne(ctx, filter).Decode(&version)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// SetActive marca o snapshot como ativo e desmarca os demais
+func (dr *DatasetVersionRepository) SetActive(ctx context.Context, nome string) error {
+	if err := dr.ClearActive(ctx); err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"ativo": true, "ativado_em": time.Now()}}
+	result, err := dr.collection.UpdateOne(ctx, bson.M{"nome": nome}, update)
+	if err != nil {
+		return fmt.Errorf("erro ao ativar snapshot: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("snapshot %q não encontrado", nome)
+	}
+	return nil
+}
+
+// ClearActive desmarca o snapshot ativo
+func (dr *DatasetVersionRepository) ClearActive(ctx context.Context) error {
+	_, err := dr.collection.UpdateMany(ctx, bson.M{"ativo": true}, bson.M{"$set": bson.M{"ativo": false}})
+	if err != nil {
+		return fmt.Errorf("erro ao desativar snapshot: %v", err)
+	}
+	return nil
+}
+
+// DeleteVersion remove o registro do snapshot
+func (dr *DatasetVersionRepository) DeleteVersion(ctx context.Context, nome string) error {
+	_, err := dr.collection.DeleteOne(ctx, bson.M{"nome": nome})
+	if err != nil {
+		return fmt.Errorf("erro ao remover snapshot: %v", err)
+	}
+	return nil
+}
@@ -6,8 +6,10 @@ import (
 	"log"
 
 	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
 	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -16,12 +18,38 @@ type GeoRepository struct {
 	collection *mongo.Collection
 }
 
-func NewGeoRepository(db *mongo.Database) *GeoRepository {
+// NewGeoRepository cria o repositório da coleção principal de localizações
+func NewGeoRepository(db *mongo.Database, collection string) *GeoRepository {
 	return &GeoRepository{
-		collection: db.Collection("geolocations"),
+		collection: db.Collection(collection),
 	}
 }
 
+// WithCollection retorna o mesmo repositório apontando para outra coleção do
+// banco, usada em importações de conjuntos de pontos próprios (lojas, clínicas...)
+func (gr *GeoRepository) WithCollection(name string) interfaces.IGeoRepository {
+	return &GeoRepository{
+		collection: gr.collection.Database().Collection(name),
+	}
+}
+
+// CollectionName retorna o nome da coleção do repositório
+func (gr *GeoRepository) CollectionName() string {
+	return gr.collection.Name()
+}
+
+// CopyTo substitui a coleção target por uma cópia dos documentos desta coleção,
+// feita no servidor com $out: a troca é atômica e os índices já existentes em
+// target são mantidos
+func (gr *GeoRepository) CopyTo(ctx context.Context, target string) error {
+	pipeline := mongo.Pipeline{{{Key: "$out", Value: target}}}
+	cursor, err := gr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("erro ao copiar coleção %s para %s: %v", gr.collection.Name(), target, err)
+	}
+	return cursor.Close(ctx)
+}
+
 // CreateGeoIndex cria índice geoespacial
 func (gr *GeoRepository) CreateGeoIndex(ctx context.Context) error {
 	indexModel := mongo.IndexModel{
@@ -72,14 +100,34 @@ func (gr *GeoRepository) InsertLocations(ctx context.Context, locationBuffer []d
 		documents[i] = loc
 	}
 
-	_, err := gr.collection.InsertMany(ctx, documents)
-	if err != nil {
+	// Não ordenado: o servidor aplica o lote inteiro sem serializar os documentos
+	_, err := gr.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeyErrors(err) {
 		return err
 	}
 
 	return nil
 }
 
+// InsertLocationsAsIs grava as localizações como recebidas (ex: restauração
+// de snapshot). Ao contrário de InsertLocations, não normaliza o município nem
+// ignora duplicatas: qualquer erro de escrita é retornado
+func (gr *GeoRepository) InsertLocationsAsIs(ctx context.Context, locations []domain.Location) error {
+	if len(locations) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(locations))
+	for i, loc := range locations {
+		documents[i] = loc
+	}
+
+	if _, err := gr.collection.InsertMany(ctx, documents); err != nil {
+		return fmt.Errorf("erro ao inserir localizações: %v", err)
+	}
+	return nil
+}
+
 // Inserts as many locations as given through parameter
 func (gr *GeoRepository) GetLocationsInKilometersRange(ctx context.Context, longitude, latitude float64, maxDistanceKm float64) (*[]domain.Location, error) {
 	filter := bson.M{
@@ -108,6 +156,42 @@ func (gr *GeoRepository) GetLocationsInKilometersRange(ctx context.Context, long
 	return &locations, nil
 }
 
+// GetLocationsInBBox retorna até limit localizações dentro do retângulo, em
+// ordem de município
+func (gr *GeoRepository) GetLocationsInBBox(ctx context.Context, bbox domain.BBox, limit int) ([]domain.Location, error) {
+	ring := [][]float64{
+		{bbox.MinLon, bbox.MinLat},
+		{bbox.MaxLon, bbox.MinLat},
+		{bbox.MaxLon, bbox.MaxLat},
+		{bbox.MinLon, bbox.MaxLat},
+		{bbox.MinLon, bbox.MinLat},
+	}
+	filter := bson.M{
+		"localizacao": bson.M{
+			"$geoWithin": bson.M{
+				"$geometry": bson.M{
+					"type":        "Polygon",
+					"coordinates": [][][]float64{ring},
+				},
+			},
+		},
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "municipio", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := gr.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	locations := []domain.Location{}
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
 // Funcionalidade de teste de importação de localidades
 func (gr *GeoRepository) ImportTest(ctx context.Context, locations []domain.Location) error {
 
@@ -172,3 +256,329 @@ func (gr *GeoRepository) DropCollection(ctx context.Context, collection string)
 	log.Println("✅ Coleção deletada com sucesso!")
 	return nil
 }
+
+// CreateIBGEIndex cria índice no código IBGE do município
+func (gr *GeoRepository) CreateIBGEIndex(ctx context.Context) error {
+	indexModels := []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "codigo_ibge", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}}
+	// Um índice por nível da hierarquia, para as listagens por região
+	for _, level := range domain.RegionLevels {
+		indexModels = append(indexModels, mongo.IndexModel{
+			Keys:    bson.D{{Key: regionLevelFields[level], Value: 1}, {Key: "municipio", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		})
+	}
+
+	_, err := gr.collection.Indexes().CreateMany(ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índice de código IBGE: %v", err)
+	}
+
+	log.Println("✅ Índice de código IBGE criado!")
+	return nil
+}
+
+// GetLocationsByEstado retorna todas as localizações de um estado
+func (gr *GeoRepository) GetLocationsByEstado(ctx context.Context, estado string) ([]domain.Location, error) {
+	cursor, err := gr.collection.Find(ctx, bson.M{"estado": estado})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var locations []domain.Location
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// CreateEstadoIndex cria os índices usados nas listagens por estado
+func (gr *GeoRepository) CreateEstadoIndex(ctx context.Context) error {
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "estado", Value: 1}, {Key: "municipio", Value: 1}}},
+		{Keys: bson.D{{Key: "estado", Value: 1}, {Key: "populacao", Value: -1}}},
+	}
+
+	_, err := gr.collection.Indexes().CreateMany(ctx, indexModels)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índices de estado: %v", err)
+	}
+
+	log.Println("✅ Índices de estado criados!")
+	return nil
+}
+
+// ListLocationsByEstado retorna uma página dos municípios de um estado; os
+// demais lugares do GeoNames (bairros, fazendas, rios) ficam de fora
+func (gr *GeoRepository) ListLocationsByEstado(ctx context.Context, estado string, opts domain.ListOptions) (*domain.LocationPage, error) {
+	return gr.listLocations(ctx, municipiosByEstadoFilter(estado), opts)
+}
+
+// regionLevelFields mapeia o nível da divisão territorial para o campo do código
+var regionLevelFields = map[string]string{
+	domain.RegionLevelIntermediaria: "hierarquia.intermediaria.codigo",
+	domain.RegionLevelImediata:      "hierarquia.imediata.codigo",
+	domain.RegionLevelMesorregiao:   "hierarquia.mesorregiao.codigo",
+	domain.RegionLevelMicrorregiao:  "hierarquia.microrregiao.codigo",
+}
+
+// ListLocationsByRegion retorna uma página das localizações de uma região do IBGE
+func (gr *GeoRepository) ListLocationsByRegion(ctx context.Context, level, codigo string, opts domain.ListOptions) (*domain.LocationPage, error) {
+	field, ok := regionLevelFields[level]
+	if !ok {
+		return nil, fmt.Errorf("nível de região desconhecido: %s", level)
+	}
+	return gr.listLocations(ctx, bson.M{field: codigo}, opts)
+}
+
+// listLocations pagina e ordena as localizações que atendem ao filtro
+func (gr *GeoRepository) listLocations(ctx context.Context, filter bson.M, opts domain.ListOptions) (*domain.LocationPage, error) {
+	total, err := gr.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	direction := 1
+	if opts.Desc {
+		direction = -1
+	}
+	// Desempate pelo nome para que a paginação seja estável
+	sort := bson.D{{Key: opts.Sort, Value: direction}}
+	if opts.Sort != domain.SortByMunicipio {
+		sort = append(sort, bson.E{Key: "municipio", Value: 1})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: 1})
+
+	findOpts := options.Find().
+		SetSort(sort).
+		SetSkip((opts.Page - 1) * opts.Limit).
+		SetLimit(opts.Limit)
+
+	cursor, err := gr.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	locations := []domain.Location{}
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, err
+	}
+
+	return &domain.LocationPage{Total: total, Items: locations}, nil
+}
+
+// GetNearestLocationWithIBGE busca a localização com código IBGE mais próxima do ponto
+func (gr *GeoRepository) GetNearestLocationWithIBGE(ctx context.Context, longitude, latitude, maxDistanceKm float64) (*domain.Location, error) {
+	filter := bson.M{
+		"codigo_ibge": bson.M{"$exists": true},
+		"localizacao": bson.M{
+			"$near": bson.M{
+				"$geometry": bson.M{
+					"type":        "Point",
+					"coordinates": []float64{longitude, latitude},
+				},
+				"$maxDistance": maxDistanceKm * 1000,
+			},
+		},
+	}
+
+	var location domain.Location
+	err := gr.collection.FindOne(ctx, filter).Decode(&location)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+// municipioExpr é a expressão de agregação que diz se o documento é um
+// município: tem código IBGE, ou é uma sede municipal do GeoNames (classe P
+// com um dos domain.MunicipalSeatFeatureCodes), ou não tem feature_class
+// (importado de CSV, GeoJSON ou shapefile). É a mesma regra na contagem de
+// GetStateTotals e na listagem de ListLocationsByEstado, para que os dois
+// números batam.
+func municipioExpr() bson.D {
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$codigo_ibge", ""}}}, ""}}},
+		bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$eq", Value: bson.A{"$feature_class", "P"}}},
+			bson.D{{Key: "$in", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$feature_code", ""}}}, domain.MunicipalSeatFeatureCodes}}},
+		}}},
+		bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$feature_class", ""}}}, ""}}},
+	}}}
+}
+
+// municipiosByEstadoFilter seleciona os municípios de uma UF (veja municipioExpr)
+func municipiosByEstadoFilter(estado string) bson.M {
+	return bson.M{"estado": estado, "$expr": municipioExpr()}
+}
+
+// GetStateTotals agrega, por estado, o número de municípios, a população
+// deles e o número total de localizações (veja domain.StateTotals)
+func (gr *GeoRepository) GetStateTotals(ctx context.Context) ([]domain.StateTotals, error) {
+	// Os dois totais saem do mesmo $group; isMunicipio escolhe o que entra
+	// nas contagens de municípios
+	isMunicipio := municipioExpr()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$estado"},
+			{Key: "municipios", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{isMunicipio, 1, 0}}}}}},
+			{Key: "populacao", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{
+				isMunicipio, bson.D{{Key: "$ifNull", Value: bson.A{"$populacao", 0}}}, 0,
+			}}}}}},
+			{Key: "localizacoes", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}
+
+	cursor, err := gr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []domain.StateTotals
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// SetCodigoIBGE associa um código IBGE e, se informada, a hierarquia de
+// regiões do IBGE a uma localização existente
+func (gr *GeoRepository) SetCodigoIBGE(ctx context.Context, id primitive.ObjectID, codigo string, hierarquia *domain.IBGEHierarchy) error {
+	update := bson.M{"codigo_ibge": codigo}
+	if hierarquia != nil {
+		update["hierarquia"] = hierarquia
+	}
+
+	_, err := gr.collection.UpdateByID(ctx, id, bson.M{"$set": update})
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar código IBGE: %v", err)
+	}
+	return nil
+}
+
+// GetLocationByCodigoIBGE busca localização pelo código IBGE de 7 dígitos
+func (gr *GeoRepository) GetLocationByCodigoIBGE(ctx context.Context, codigo string) (*domain.Location, error) {
+	var location domain.Location
+
+	err := gr.collection.FindOne(ctx, bson.M{"codigo_ibge": codigo}).Decode(&location)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &location, nil
+}
+
+// CreateGeoNameIDIndex cria índice único no id do GeoNames
+func (gr *GeoRepository) CreateGeoNameIDIndex(ctx context.Context) error {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "geonameid", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	}
+
+	_, err := gr.collection.Indexes().CreateOne(ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índice de geonameid: %v", err)
+	}
+
+	return nil
+}
+
+// onlyDuplicateKeyErrors indica se todas as falhas de um InsertMany não ordenado
+// foram de chave duplicada (registros que já haviam sido gravados)
+func onlyDuplicateKeyErrors(err error) bool {
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+// ForEachLocation percorre todas as localizações da coleção sem carregá-las de uma vez
+func (gr *GeoRepository) ForEachLocation(ctx context.Context, fn func(domain.Location) error) error {
+	return gr.forEach(ctx, bson.M{}, options.Find(), fn)
+}
+
+// ForEachMatchingLocation percorre, em ordem de _id, as localizações que casam
+// com o filtro; a ordem estável torna as exportações reproduzíveis
+func (gr *GeoRepository) ForEachMatchingLocation(ctx context.Context, filter domain.LocationFilter, fn func(domain.Location) error) error {
+	findOpts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	return gr.forEach(ctx, locationFilter(filter), findOpts, fn)
+}
+
+// CountLocations conta as localizações que casam com o filtro
+func (gr *GeoRepository) CountLocations(ctx context.Context, filter domain.LocationFilter) (int64, error) {
+	return gr.collection.CountDocuments(ctx, locationFilter(filter))
+}
+
+// locationFilter converte o filtro do domínio na consulta do MongoDB
+func locationFilter(filter domain.LocationFilter) bson.M {
+	query := bson.M{}
+	if len(filter.Estados) > 0 {
+		query["estado"] = bson.M{"$in": filter.Estados}
+	}
+	if len(filter.FeatureCodes) > 0 {
+		query["feature_code"] = bson.M{"$in": filter.FeatureCodes}
+	}
+	switch {
+	case filter.MinPopulacao > 0 && filter.MaxPopulacao > 0:
+		query["populacao"] = bson.M{"$gte": filter.MinPopulacao, "$lte": filter.MaxPopulacao}
+	case filter.MinPopulacao > 0:
+		query["populacao"] = bson.M{"$gte": filter.MinPopulacao}
+	case filter.MaxPopulacao > 0:
+		// População 0 não é gravada (omitempty) e também está abaixo do máximo
+		query["$or"] = bson.A{
+			bson.M{"populacao": bson.M{"$lte": filter.MaxPopulacao}},
+			bson.M{"populacao": bson.M{"$exists": false}},
+		}
+	}
+	if bbox := filter.BBox; bbox != nil {
+		// $box é plano, como o retângulo informado, e dispensa o índice 2dsphere
+		query["localizacao.coordinates"] = bson.M{
+			"$geoWithin": bson.M{
+				"$box": [][]float64{{bbox.MinLon, bbox.MinLat}, {bbox.MaxLon, bbox.MaxLat}},
+			},
+		}
+	}
+	return query
+}
+
+func (gr *GeoRepository) forEach(ctx context.Context, filter bson.M, findOpts *options.FindOptions, fn func(domain.Location) error) error {
+	cursor, err := gr.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var location domain.Location
+		if err := cursor.Decode(&location); err != nil {
+			return err
+		}
+		if err := fn(location); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// testDatabase conecta ao MongoDB de GEO_TEST_MONGO_URI em um banco
+// descartável; sem a variável, o teste é pulado
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("GEO_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("defina GEO_TEST_MONGO_URI para rodar os testes com MongoDB")
+	}
+	db, err := ConnectDB(uri, fmt.Sprintf("geo_test_%d", time.Now().UnixNano()), 10*time.Second)
+	if err != nil {
+		t.Fatalf("ConnectDB: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Database.Drop(ctx)
+		db.Close(ctx)
+	})
+	return db.Database
+}
+
+func TestOnlyDuplicateKeyErrors(t *testing.T) {
+	writeErrors := func(codes ...int) mongo.BulkWriteException {
+		var bulkErr mongo.BulkWriteException
+		for i, code := range codes {
+			bulkErr.WriteErrors = append(bulkErr.WriteErrors, mongo.BulkWriteError{
+				WriteError: mongo.WriteError{Index: i, Code: code, Message: "erro"},
+			})
+		}
+		return bulkErr
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"só duplicados", writeErrors(11000, 11000), true},
+		{"duplicado e documento inválido", writeErrors(11000, 121), false},
+		{"duplicado e documento grande demais", writeErrors(10334, 11000), false},
+		{"sem erros de escrita", writeErrors(), false},
+		{"write concern", mongo.BulkWriteException{
+			WriteErrors:       writeErrors(11000).WriteErrors,
+			WriteConcernError: &mongo.WriteConcernError{Code: 64},
+		}, false},
+		{"outro erro", errors.New("conexão perdida"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := onlyDuplicateKeyErrors(tt.err); got != tt.want {
+				t.Errorf("onlyDuplicateKeyErrors = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMunicipiosByEstadoFilterUsesMunicipioExpr(t *testing.T) {
+	filter := municipiosByEstadoFilter("SP")
+	if filter["estado"] != "SP" || !reflect.DeepEqual(filter["$expr"], municipioExpr()) {
+		t.Errorf("filtro = %v, esperado estado SP e a mesma regra de GetStateTotals", filter)
+	}
+}
+
+// A listagem de /estados/{uf}/municipios e a contagem de /estados usam a
+// mesma regra de município
+func TestListLocationsByEstadoMatchesStateTotals(t *testing.T) {
+	repo := NewGeoRepository(testDatabase(t), "localizacoes")
+	ctx := context.Background()
+
+	point := domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-47, -22}}
+	locations := []domain.Location{
+		{Municipio: "Campinas", Estado: "SP", Localizacao: point, CodigoIBGE: "3509502", FeatureClass: "P", FeatureCode: "PPLA2", Populacao: 1000},
+		{Municipio: "Santos", Estado: "SP", Localizacao: point, FeatureClass: "P", FeatureCode: "PPLA2", Populacao: 400},
+		{Municipio: "São Paulo", Estado: "SP", Localizacao: point, FeatureClass: "P", FeatureCode: "PPLA", Populacao: 12000},
+		{Municipio: "Loja Própria", Estado: "SP", Localizacao: point}, // CSV, sem feature_class
+		{Municipio: "Barão Geraldo", Estado: "SP", Localizacao: point, FeatureClass: "P", FeatureCode: "PPLX", Populacao: 50},
+		{Municipio: "Fazenda Boa Vista", Estado: "SP", Localizacao: point, FeatureClass: "S", FeatureCode: "FRM"},
+		{Municipio: "Rio Tietê", Estado: "SP", Localizacao: point, FeatureClass: "H", FeatureCode: "STM"},
+		{Municipio: "Curitiba", Estado: "PR", Localizacao: point, FeatureClass: "P", FeatureCode: "PPLA"},
+	}
+	if err := repo.InsertLocations(ctx, locations); err != nil {
+		t.Fatalf("InsertLocations: %v", err)
+	}
+
+	totals, err := repo.GetStateTotals(ctx)
+	if err != nil {
+		t.Fatalf("GetStateTotals: %v", err)
+	}
+	var sp domain.StateTotals
+	for _, total := range totals {
+		if total.Estado == "SP" {
+			sp = total
+		}
+	}
+	if sp.Municipios != 4 || sp.Populacao != 13400 || sp.Localizacoes != 7 {
+		t.Errorf("totais de SP = %+v, esperado 4 municípios, população 13400 e 7 localizações", sp)
+	}
+
+	page, err := repo.ListLocationsByEstado(ctx, "SP", domain.ListOptions{Page: 1, Limit: 50, Sort: domain.SortByMunicipio})
+	if err != nil {
+		t.Fatalf("ListLocationsByEstado: %v", err)
+	}
+	if page.Total != sp.Municipios || int64(len(page.Items)) != sp.Municipios {
+		t.Errorf("listagem com total %d e %d itens, esperado os %d municípios contados", page.Total, len(page.Items), sp.Municipios)
+	}
+	for _, loc := range page.Items {
+		if loc.FeatureClass != "" && loc.FeatureClass != "P" {
+			t.Errorf("%s (%s) não é município", loc.Municipio, loc.FeatureCode)
+		}
+	}
+}
+
+func TestInsertLocationsAsIs(t *testing.T) {
+	repo := NewGeoRepository(testDatabase(t), "localizacoes")
+	ctx := context.Background()
+
+	point := domain.GeoJSON{Type: "Point", Coordinates: [2]float64{-47, -22}}
+	id := primitive.NewObjectID()
+	location := domain.Location{ID: id, Municipio: "SÃO PAULO", Estado: "SP", Localizacao: point}
+	if err := repo.InsertLocationsAsIs(ctx, []domain.Location{location}); err != nil {
+		t.Fatalf("InsertLocationsAsIs: %v", err)
+	}
+
+	// O município é gravado como recebido, sem normalização
+	var stored []domain.Location
+	err := repo.ForEachLocation(ctx, func(l domain.Location) error {
+		stored = append(stored, l)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachLocation: %v", err)
+	}
+	if len(stored) != 1 || stored[0].ID != id || stored[0].Municipio != "SÃO PAULO" {
+		t.Fatalf("gravado %+v, esperado %+v", stored, location)
+	}
+
+	// Duplicatas não são ignoradas como em InsertLocations
+	if err := repo.InsertLocationsAsIs(ctx, []domain.Location{location}); err == nil {
+		t.Error("esperado erro ao inserir _id duplicado")
+	}
+}
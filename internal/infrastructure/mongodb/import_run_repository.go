@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ImportRunRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImportRunRepository(db *mongo.Database) *ImportRunRepository {
+	return &ImportRunRepository{
+		collection: db.Collection("import_runs"),
+	}
+}
+
+// CreateRun registra o início de uma importação
+func (ir *ImportRunRepository) CreateRun(ctx context.Context, run *domain.ImportRun) error {
+	result, err := ir.collection.InsertOne(ctx, run)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar importação: %v", err)
+	}
+
+	run.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// FindResumable busca a última importação não concluída do arquivo com este checksum
+func (ir *ImportRunRepository) FindResumable(ctx context.Context, sourceSHA256 string) (*domain.ImportRun, error) {
+	filter := bson.M{
+		"source_sha256": sourceSHA256,
+		"status":        bson.M{"$ne": domain.ImportRunCompleted},
+	}
+	opts := options.FindOne().SetSort(bson.M{"started_at": -1})
+
+	var run domain.ImportRun
+	err := ir.collection.FindOne(ctx, filter, opts).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// FindLastCompleted busca a última importação concluída
+func (ir *ImportRunRepository) FindLastCompleted(ctx context.Context) (*domain.ImportRun, error) {
+	filter := bson.M{"status": domain.ImportRunCompleted}
+	opts := options.FindOne().SetSort(bson.M{"finished_at": -1})
+
+	var run domain.ImportRun
+	err := ir.collection.FindOne(ctx, filter, opts).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// MarkResumed marca uma importação interrompida como em execução novamente
+func (ir *ImportRunRepository) MarkResumed(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"status": domain.ImportRunRunning, "updated_at": time.Now()},
+		"$unset": bson.M{"error": ""},
+		"$inc":   bson.M{"resumes": 1},
+	}
+
+	_, err := ir.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("erro ao retomar importação: %v", err)
+	}
+	return nil
+}
+
+// SaveCheckpoint grava o último lote confirmado
+func (ir *ImportRunRepository) SaveCheckpoint(ctx context.Context, id primitive.ObjectID, batch int, rows, offset, inserted int64) error {
+	update := bson.M{"$set": bson.M{
+		"last_batch":       batch,
+		"committed_rows":   rows,
+		"committed_offset": offset,
+		"inserted":         inserted,
+		"updated_at":       time.Now(),
+	}}
+
+	_, err := ir.collection.UpdateByID(ctx, id, update)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar checkpoint da importação: %v", err)
+	}
+	return nil
+}
+
+// FinishRun marca a importação como concluída ou com falha
+func (ir *ImportRunRepository) FinishRun(ctx context.Context, id primitive.ObjectID, status string, runErr error) error {
+	now := time.Now()
+	set := bson.M{"status": status, "updated_at": now, "finished_at": now}
+	if runErr != nil {
+		set["error"] = runErr.Error()
+	}
+
+	_, err := ir.collection.UpdateByID(ctx, id, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("erro ao finalizar registro da importação: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	domain "github.com/Kaguyo/Geolocation-Brasil/internal/domain/entities"
+	"github.com/Kaguyo/Geolocation-Brasil/internal/domain/interfaces"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PostalCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPostalCodeRepository(db *mongo.Database) *PostalCodeRepository {
+	return &PostalCodeRepository{
+		collection: db.Collection("ceps"),
+	}
+}
+
+// WithCollection retorna o mesmo repositório apontando para outra coleção,
+// usada como área de preparo das importações
+func (pr *PostalCodeRepository) WithCollection(name string) interfaces.IPostalCodeRepository {
+	return &PostalCodeRepository{
+		collection: pr.collection.Database().Collection(name),
+	}
+}
+
+// CollectionName retorna o nome da coleção do repositório
+func (pr *PostalCodeRepository) CollectionName() string {
+	return pr.collection.Name()
+}
+
+// CopyTo substitui a coleção target por uma cópia dos documentos desta. O
+// $out troca a coleção de uma vez e mantém os índices que ela já tinha.
+func (pr *PostalCodeRepository) CopyTo(ctx context.Context, target string) error {
+	pipeline := mongo.Pipeline{{{Key: "$out", Value: target}}}
+	cursor, err := pr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return fmt.Errorf("erro ao copiar coleção %s para %s: %v", pr.collection.Name(), target, err)
+	}
+	return cursor.Close(ctx)
+}
+
+// CreateIndexes cria o índice único de CEP
+func (pr *PostalCodeRepository) CreateIndexes(ctx context.Context) error {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "cep", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := pr.collection.Indexes().CreateOne(ctx, indexModel)
+	if err != nil {
+		return fmt.Errorf("erro ao criar índice de CEP: %v", err)
+	}
+
+	log.Println("✅ Índice de CEP criado!")
+	return nil
+}
+
+// InsertPostalCodes insere um lote de CEPs
+func (pr *PostalCodeRepository) InsertPostalCodes(ctx context.Context, postalCodes []domain.PostalCode) error {
+	if len(postalCodes) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(postalCodes))
+	for i, pc := range postalCodes {
+		documents[i] = pc
+	}
+
+	// Não ordenado: CEPs repetidos no arquivo são ignorados pelo índice único
+	// sem abortar o lote; qualquer outra falha de escrita é um erro
+	_, err := pr.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeyErrors(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GetByCEP busca um CEP exato (8 dígitos, sem hífen)
+func (pr *PostalCodeRepository) GetByCEP(ctx context.Context, cep string) (*domain.PostalCode, error) {
+	var postalCode domain.PostalCode
+
+	err := pr.collection.FindOne(ctx, bson.M{"cep": cep}).Decode(&postalCode)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &postalCode, nil
+}
+
+// GetNeighbors retorna o maior CEP cadastrado abaixo e o menor acima do CEP informado
+func (pr *PostalCodeRepository) GetNeighbors(ctx context.Context, cep string) (*domain.PostalCode, *domain.PostalCode, error) {
+	lower, err := pr.findOneSorted(ctx, bson.M{"cep": bson.M{"$lt": cep}}, -1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upper, err := pr.findOneSorted(ctx, bson.M{"cep": bson.M{"$gt": cep}}, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lower, upper, nil
+}
+
+func (pr *PostalCodeRepository) findOneSorted(ctx context.Context, filter bson.M, order int) (*domain.PostalCode, error) {
+	opts := options.FindOne().SetSort(bson.M{"cep": order})
+
+	var postalCode domain.PostalCode
+	err := pr.collection.FindOne(ctx, filter, opts).Decode(&postalCode)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &postalCode, nil
+}
+
+// DropCollection remove todos os CEPs
+func (pr *PostalCodeRepository) DropCollection(ctx context.Context) error {
+	err := pr.collection.Drop(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao dropar coleção de CEPs: %v", err)
+	}
+	log.Println("✅ Coleção de CEPs deletada com sucesso!")
+	return nil
+}
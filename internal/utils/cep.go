@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeCEP remove pontuação do CEP e valida que restam 8 dígitos.
+// Aceita formatos como "01310-100", "01310100" e "01.310-100".
+func NormalizeCEP(cep string) (string, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '.', ' ':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(cep))
+
+	if len(cleaned) != 8 {
+		return "", fmt.Errorf("CEP deve ter 8 dígitos: %q", cep)
+	}
+	for _, r := range cleaned {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("CEP deve conter apenas dígitos: %q", cep)
+		}
+	}
+
+	return cleaned, nil
+}
+
+// FormatCEP formata um CEP normalizado (8 dígitos) como "01310-100"
+func FormatCEP(cep string) string {
+	if len(cep) != 8 {
+		return cep
+	}
+	return cep[:5] + "-" + cep[5:]
+}
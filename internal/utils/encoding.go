@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Latin1ToUTF8 converte um texto codificado em ISO-8859-1 (Latin-1) para UTF-8
+func Latin1ToUTF8(data []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(data))
+	for _, b := range data {
+		sb.WriteRune(rune(b))
+	}
+	return sb.String()
+}
+
+// EnsureUTF8 retorna o texto como está se já for UTF-8 válido; caso contrário
+// assume Latin-1, codificação comum em arquivos do IBGE e em tabelas DBF
+func EnsureUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return Latin1ToUTF8(data)
+}
@@ -0,0 +1,19 @@
+package utils
+
+import "math"
+
+// EarthRadiusKm é o raio médio da Terra em quilômetros
+const EarthRadiusKm = 6371.0
+
+// HaversineKm calcula a distância em quilômetros entre dois pontos (graus decimais)
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,116 @@
+// Package gpx lê e grava waypoints em GPX 1.1, o formato de aparelhos de GPS.
+// As coordenadas seguem a mesma ordem de domain.GeoJSON: [longitude, latitude].
+package gpx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Waypoint é um ponto marcado (wpt). Pontos de trilhas e rotas não são lidos.
+type Waypoint struct {
+	Name        string
+	Description string
+	Comment     string
+	Type        string
+	// Coordinates é o ponto [longitude, latitude]; nil se lat/lon forem inválidos
+	Coordinates *[2]float64
+}
+
+type waypointXML struct {
+	Lat         string `xml:"lat,attr"`
+	Lon         string `xml:"lon,attr"`
+	Name        string `xml:"name,omitempty"`
+	Comment     string `xml:"cmt,omitempty"`
+	Description string `xml:"desc,omitempty"`
+	Type        string `xml:"type,omitempty"`
+}
+
+// Decode percorre os waypoints do arquivo em streaming, chamando fn para cada um
+func Decode(r io.Reader, fn func(Waypoint) error) error {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler GPX: %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "wpt" {
+			continue
+		}
+
+		var wpt waypointXML
+		if err := decoder.DecodeElement(&wpt, &start); err != nil {
+			return fmt.Errorf("erro ao ler waypoint: %v", err)
+		}
+
+		waypoint := Waypoint{
+			Name:        wpt.Name,
+			Description: wpt.Description,
+			Comment:     wpt.Comment,
+			Type:        wpt.Type,
+		}
+		lat, latErr := strconv.ParseFloat(wpt.Lat, 64)
+		lon, lonErr := strconv.ParseFloat(wpt.Lon, 64)
+		if latErr == nil && lonErr == nil {
+			waypoint.Coordinates = &[2]float64{lon, lat}
+		}
+
+		if err := fn(waypoint); err != nil {
+			return err
+		}
+	}
+}
+
+type document struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Name      string        `xml:"metadata>name,omitempty"`
+	Waypoints []waypointXML `xml:"wpt"`
+}
+
+// Write grava os waypoints como um arquivo GPX 1.1
+func Write(w io.Writer, creator, name string, waypoints []Waypoint) error {
+	doc := document{
+		Xmlns:     "http://www.topografix.com/GPX/1/1",
+		Version:   "1.1",
+		Creator:   creator,
+		Name:      name,
+		Waypoints: make([]waypointXML, 0, len(waypoints)),
+	}
+
+	for _, wp := range waypoints {
+		if wp.Coordinates == nil {
+			continue
+		}
+		doc.Waypoints = append(doc.Waypoints, waypointXML{
+			Lat:         strconv.FormatFloat(wp.Coordinates[1], 'f', -1, 64),
+			Lon:         strconv.FormatFloat(wp.Coordinates[0], 'f', -1, 64),
+			Name:        wp.Name,
+			Comment:     wp.Comment,
+			Description: wp.Description,
+			Type:        wp.Type,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("erro ao gerar GPX: %v", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,267 @@
+// Package kml lê e grava placemarks de pontos em KML e KMZ (Google Earth).
+// As coordenadas seguem a mesma ordem de domain.GeoJSON: [longitude, latitude].
+package kml
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Placemark é um marcador com os dados de ExtendedData (Data e SimpleData)
+type Placemark struct {
+	Name        string
+	Description string
+	Data        map[string]string
+	// Coordinates é o ponto [longitude, latitude]; nil quando o placemark não
+	// tem um Point (linhas, polígonos ou coordenadas inválidas)
+	Coordinates *[2]float64
+	// Geometry é o elemento de geometria encontrado (Point, LineString...)
+	Geometry string
+}
+
+// Decode percorre os placemarks do documento em streaming, em qualquer nível
+// de Document/Folder, chamando fn para cada um
+func Decode(r io.Reader, fn func(Placemark) error) error {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao ler KML: %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		placemark, err := decodePlacemark(decoder)
+		if err != nil {
+			return err
+		}
+		if err := fn(placemark); err != nil {
+			return err
+		}
+	}
+}
+
+// decodePlacemark lê os elementos até o fim do Placemark. Em MultiGeometry
+// vale o primeiro Point.
+func decodePlacemark(decoder *xml.Decoder) (Placemark, error) {
+	placemark := Placemark{Data: map[string]string{}}
+	var path []string
+	var dataName string
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return placemark, fmt.Errorf("erro ao ler Placemark: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			switch name {
+			case "Point", "LineString", "LinearRing", "Polygon", "Model", "Track":
+				if placemark.Geometry == "" || placemark.Geometry == "MultiGeometry" {
+					placemark.Geometry = name
+				}
+			case "MultiGeometry":
+				if placemark.Geometry == "" {
+					placemark.Geometry = name
+				}
+			case "Data", "SimpleData":
+				dataName = attr(t, "name")
+			}
+			path = append(path, name)
+
+		case xml.EndElement:
+			if len(path) == 0 {
+				return placemark, nil // </Placemark>
+			}
+			path = path[:len(path)-1]
+
+		case xml.CharData:
+			if len(path) == 0 {
+				continue
+			}
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+
+			switch current, parent := path[len(path)-1], parentOf(path); {
+			case current == "name" && len(path) == 1:
+				placemark.Name += text
+			case current == "description" && len(path) == 1:
+				placemark.Description += text
+			case current == "value" && parent == "Data", current == "SimpleData":
+				placemark.Data[dataName] += text
+			case current == "coordinates" && parent == "Point" && placemark.Coordinates == nil:
+				placemark.Coordinates = parseCoordinates(text)
+			}
+		}
+	}
+}
+
+func parentOf(path []string) string {
+	if len(path) < 2 {
+		return ""
+	}
+	return path[len(path)-2]
+}
+
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// parseCoordinates lê "lon,lat[,alt]"
+func parseCoordinates(text string) *[2]float64 {
+	parts := strings.Split(strings.Fields(text)[0], ",")
+	if len(parts) < 2 {
+		return nil
+	}
+	lon, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return nil
+	}
+	lat, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return nil
+	}
+	return &[2]float64{lon, lat}
+}
+
+// OpenKMZ abre o documento KML principal de um KMZ (doc.kml ou o primeiro
+// .kml do arquivo), com os limites de tamanho de utils.OpenZipEntry
+func OpenKMZ(path string) (io.ReadCloser, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir KMZ: %w", err)
+	}
+
+	entry := ""
+	for _, f := range archive.File {
+		if !strings.EqualFold(filepath.Ext(f.Name), ".kml") {
+			continue
+		}
+		if entry == "" || strings.EqualFold(filepath.Base(f.Name), "doc.kml") {
+			entry = f.Name
+		}
+	}
+	archive.Close()
+
+	if entry == "" {
+		return nil, fmt.Errorf("nenhum arquivo .kml encontrado em %s", path)
+	}
+	return utils.OpenZipEntry(path, entry)
+}
+
+// Write grava os placemarks como um documento KML
+func Write(w io.Writer, name string, placemarks []Placemark) error {
+	doc := document{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Document: documentBody{
+			Name:       name,
+			Placemarks: make([]placemarkXML, 0, len(placemarks)),
+		},
+	}
+
+	for _, p := range placemarks {
+		if p.Coordinates == nil {
+			continue
+		}
+		out := placemarkXML{
+			Name:        p.Name,
+			Description: p.Description,
+			Point: pointXML{
+				Coordinates: strconv.FormatFloat(p.Coordinates[0], 'f', -1, 64) + "," + strconv.FormatFloat(p.Coordinates[1], 'f', -1, 64),
+			},
+		}
+		if len(p.Data) > 0 {
+			keys := make([]string, 0, len(p.Data))
+			for key := range p.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			out.ExtendedData = &extendedDataXML{}
+			for _, key := range keys {
+				out.ExtendedData.Data = append(out.ExtendedData.Data, dataXML{Name: key, Value: p.Data[key]})
+			}
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, out)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("erro ao gerar KML: %v", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteKMZ grava os placemarks como KMZ (zip com doc.kml)
+func WriteKMZ(w io.Writer, name string, placemarks []Placemark) error {
+	archive := zip.NewWriter(w)
+	doc, err := archive.Create("doc.kml")
+	if err != nil {
+		return err
+	}
+	if err := Write(doc, name, placemarks); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+type document struct {
+	XMLName  xml.Name     `xml:"kml"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Document documentBody `xml:"Document"`
+}
+
+type documentBody struct {
+	Name       string         `xml:"name,omitempty"`
+	Placemarks []placemarkXML `xml:"Placemark"`
+}
+
+type placemarkXML struct {
+	Name         string           `xml:"name"`
+	Description  string           `xml:"description,omitempty"`
+	ExtendedData *extendedDataXML `xml:"ExtendedData,omitempty"`
+	Point        pointXML         `xml:"Point"`
+}
+
+type extendedDataXML struct {
+	Data []dataXML `xml:"Data"`
+}
+
+type dataXML struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type pointXML struct {
+	Coordinates string `xml:"coordinates"`
+}
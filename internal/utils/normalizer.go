@@ -21,3 +21,45 @@ func NormalizeMunicipio(municipio string) string {
 
 	return strings.Join(splittedMunicipio, " ")
 }
+
+// accentReplacer remove os acentos usados em nomes de municípios brasileiros
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// asciiReplacer remove os acentos mantendo maiúsculas e minúsculas
+var asciiReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "Á", "A", "À", "A", "Â", "A", "Ã", "A", "Ä", "A",
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "É", "E", "È", "E", "Ê", "E", "Ë", "E",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "Í", "I", "Ì", "I", "Î", "I", "Ï", "I",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o", "Ó", "O", "Ò", "O", "Ô", "O", "Õ", "O", "Ö", "O",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "Ú", "U", "Ù", "U", "Û", "U", "Ü", "U",
+	"ç", "c", "Ç", "C", "ñ", "n", "Ñ", "N",
+)
+
+// ASCIIName remove os acentos de um nome, como a coluna asciiname do GeoNames
+// (ex: "São João d'Aliança" → "Sao Joao d'Alianca")
+func ASCIIName(name string) string {
+	return asciiReplacer.Replace(name)
+}
+
+// FoldKey gera uma chave de comparação para nomes: minúsculas, sem acentos e
+// com hífens, apóstrofos e espaços repetidos reduzidos a um único espaço.
+// Ex: "Santa Bárbara d'Oeste" e "SANTA BARBARA D OESTE" geram a mesma chave.
+func FoldKey(name string) string {
+	name = accentReplacer.Replace(strings.ToLower(name))
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '\'', '`', '´', '’', '.':
+			return ' '
+		}
+		return r
+	}, name)
+
+	return strings.Join(strings.Fields(name), " ")
+}
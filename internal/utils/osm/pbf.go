@@ -0,0 +1,532 @@
+// Package osm lê os nós de extratos do OpenStreetMap no formato PBF
+// (https://wiki.openstreetmap.org/wiki/PBF_Format), sem dependências externas.
+// Apenas nós com tags são entregues; ways e relations são ignorados.
+package osm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Limites do formato: cabeçalhos de até 64 KiB e blocos de até 32 MiB
+const (
+	maxHeaderSize = 64 * 1024
+	maxBlobSize   = 32 * 1024 * 1024
+)
+
+// Recursos obrigatórios que este leitor entende; extratos com outros (ex:
+// HistoricalInformation) são recusados
+var supportedFeatures = map[string]bool{
+	"OsmSchema-V0.6": true,
+	"DenseNodes":     true,
+}
+
+// Node é um nó com tags, com as coordenadas em graus decimais
+type Node struct {
+	ID   int64
+	Lon  float64
+	Lat  float64
+	Tags map[string]string
+}
+
+// DecodeNodes percorre os nós com tags do extrato em streaming, bloco a
+// bloco, chamando fn para cada um. Com withTag, só os nós que têm essa chave
+// são decodificados, o que evita montar as tags dos demais.
+func DecodeNodes(r io.Reader, withTag string, fn func(Node) error) error {
+	reader := bufio.NewReaderSize(r, 1<<16)
+
+	for {
+		blobType, data, err := readBlob(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch blobType {
+		case "OSMHeader":
+			if err := checkHeader(data); err != nil {
+				return err
+			}
+		case "OSMData":
+			if err := decodeBlock(data, withTag, fn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readBlob lê o próximo BlobHeader e o Blob correspondente, já descompactado
+func readBlob(r io.Reader) (string, []byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if err == io.EOF {
+			return "", nil, io.EOF
+		}
+		return "", nil, fmt.Errorf("erro ao ler PBF: %v", err)
+	}
+
+	headerSize := binary.BigEndian.Uint32(size[:])
+	if headerSize > maxHeaderSize {
+		return "", nil, fmt.Errorf("cabeçalho de bloco PBF com %d bytes", headerSize)
+	}
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", nil, fmt.Errorf("erro ao ler cabeçalho de bloco PBF: %v", err)
+	}
+
+	var blobType string
+	var dataSize uint64
+	m := message{buf: header}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		switch {
+		case field == 1 && wire == wireBytes:
+			b, err := m.bytes()
+			if err != nil {
+				return "", nil, err
+			}
+			blobType = string(b)
+		case field == 3 && wire == wireVarint:
+			if dataSize, err = m.varint(); err != nil {
+				return "", nil, err
+			}
+		default:
+			if err := m.skip(wire); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	if dataSize > maxBlobSize {
+		return "", nil, fmt.Errorf("bloco PBF com %d bytes", dataSize)
+	}
+	blob := make([]byte, dataSize)
+	if _, err := io.ReadFull(r, blob); err != nil {
+		return "", nil, fmt.Errorf("erro ao ler bloco PBF: %v", err)
+	}
+
+	data, err := decodeBlob(blob)
+	return blobType, data, err
+}
+
+// decodeBlob retorna o conteúdo do Blob, sem compressão ou com zlib
+func decodeBlob(blob []byte) ([]byte, error) {
+	var rawSize uint64
+	m := message{buf: blob}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("bloco PBF vazio")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case field == 1 && wire == wireBytes:
+			return m.bytes()
+		case field == 2 && wire == wireVarint:
+			if rawSize, err = m.varint(); err != nil {
+				return nil, err
+			}
+		case field == 3 && wire == wireBytes:
+			compressed, err := m.bytes()
+			if err != nil {
+				return nil, err
+			}
+			if rawSize == 0 || rawSize > maxBlobSize {
+				rawSize = maxBlobSize
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				return nil, fmt.Errorf("erro ao descompactar bloco PBF: %v", err)
+			}
+			defer zr.Close()
+
+			var out bytes.Buffer
+			out.Grow(int(rawSize))
+			if _, err := io.Copy(&out, io.LimitReader(zr, int64(rawSize))); err != nil {
+				return nil, fmt.Errorf("erro ao descompactar bloco PBF: %v", err)
+			}
+			return out.Bytes(), nil
+		case field >= 4 && field <= 7:
+			return nil, fmt.Errorf("compressão do bloco PBF não suportada (apenas zlib)")
+		default:
+			if err := m.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// checkHeader recusa extratos que exigem recursos não suportados
+func checkHeader(data []byte) error {
+	m := message{buf: data}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if field != 4 || wire != wireBytes {
+			if err := m.skip(wire); err != nil {
+				return err
+			}
+			continue
+		}
+
+		feature, err := m.bytes()
+		if err != nil {
+			return err
+		}
+		if !supportedFeatures[string(feature)] {
+			return fmt.Errorf("extrato PBF exige o recurso não suportado %q", feature)
+		}
+	}
+}
+
+// block é um PrimitiveBlock com a tabela de strings e a conversão de coordenadas
+type block struct {
+	strings     []string
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+	// tagKey é o índice de withTag na tabela de strings; -1 se não aparece
+	// no bloco, 0 se todos os nós com tags interessam
+	tagKey int
+}
+
+func (b *block) coordinates(lon, lat int64) (float64, float64) {
+	return float64(b.lonOffset+b.granularity*lon) / 1e9, float64(b.latOffset+b.granularity*lat) / 1e9
+}
+
+func (b *block) tag(i uint64) (string, error) {
+	if i >= uint64(len(b.strings)) {
+		return "", fmt.Errorf("índice %d fora da tabela de strings do PBF", i)
+	}
+	return b.strings[i], nil
+}
+
+func decodeBlock(data []byte, withTag string, fn func(Node) error) error {
+	b := block{granularity: 100}
+	var groups [][]byte
+
+	m := message{buf: data}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case field == 1 && wire == wireBytes:
+			table, err := m.bytes()
+			if err != nil {
+				return err
+			}
+			if b.strings, err = decodeStringTable(table); err != nil {
+				return err
+			}
+		case field == 2 && wire == wireBytes:
+			group, err := m.bytes()
+			if err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		case field == 17 && wire == wireVarint:
+			v, err := m.varint()
+			if err != nil {
+				return err
+			}
+			b.granularity = int64(v)
+		case field == 19 && wire == wireVarint:
+			v, err := m.varint()
+			if err != nil {
+				return err
+			}
+			b.latOffset = int64(v)
+		case field == 20 && wire == wireVarint:
+			v, err := m.varint()
+			if err != nil {
+				return err
+			}
+			b.lonOffset = int64(v)
+		default:
+			if err := m.skip(wire); err != nil {
+				return err
+			}
+		}
+	}
+
+	if withTag != "" {
+		b.tagKey = -1
+		for i, s := range b.strings {
+			if i > 0 && s == withTag {
+				b.tagKey = i
+				break
+			}
+		}
+		if b.tagKey < 0 {
+			return nil
+		}
+	}
+
+	for _, group := range groups {
+		if err := b.decodeGroup(group, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeStringTable(data []byte) ([]string, error) {
+	var table []string
+	m := message{buf: data}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if field != 1 || wire != wireBytes {
+			if err := m.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		s, err := m.bytes()
+		if err != nil {
+			return nil, err
+		}
+		table = append(table, string(s))
+	}
+}
+
+// decodeGroup lê os nós de um PrimitiveGroup (Node ou DenseNodes)
+func (b *block) decodeGroup(data []byte, fn func(Node) error) error {
+	m := message{buf: data}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case field == 1 && wire == wireBytes:
+			node, err := m.bytes()
+			if err != nil {
+				return err
+			}
+			if err := b.decodeNode(node, fn); err != nil {
+				return err
+			}
+		case field == 2 && wire == wireBytes:
+			dense, err := m.bytes()
+			if err != nil {
+				return err
+			}
+			if err := b.decodeDense(dense, fn); err != nil {
+				return err
+			}
+		default:
+			if err := m.skip(wire); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (b *block) decodeNode(data []byte, fn func(Node) error) error {
+	var id, lat, lon int64
+	var keys, vals []uint64
+
+	m := message{buf: data}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case field == 1 && wire == wireVarint:
+			id, err = m.sint()
+		case field == 2 && wire == wireBytes, field == 3 && wire == wireBytes:
+			var packed []byte
+			if packed, err = m.bytes(); err == nil {
+				if field == 2 {
+					keys, err = packedVarints(packed)
+				} else {
+					vals, err = packedVarints(packed)
+				}
+			}
+		case field == 8 && wire == wireVarint:
+			lat, err = m.sint()
+		case field == 9 && wire == wireVarint:
+			lon, err = m.sint()
+		default:
+			err = m.skip(wire)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(keys) == 0 || len(keys) != len(vals) {
+		return nil
+	}
+	if b.tagKey > 0 && !containsKey(keys, uint64(b.tagKey)) {
+		return nil
+	}
+
+	node := Node{ID: id, Tags: make(map[string]string, len(keys))}
+	node.Lon, node.Lat = b.coordinates(lon, lat)
+	for i := range keys {
+		key, err := b.tag(keys[i])
+		if err != nil {
+			return err
+		}
+		value, err := b.tag(vals[i])
+		if err != nil {
+			return err
+		}
+		node.Tags[key] = value
+	}
+	return fn(node)
+}
+
+// decodeDense lê DenseNodes: ids e coordenadas em delta, e as tags de todos
+// os nós em keys_vals, cada nó terminado por 0
+func (b *block) decodeDense(data []byte, fn func(Node) error) error {
+	var ids, lats, lons, keysVals []uint64
+
+	m := message{buf: data}
+	for {
+		field, wire, err := m.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if wire != wireBytes || (field != 1 && field != 8 && field != 9 && field != 10) {
+			if err := m.skip(wire); err != nil {
+				return err
+			}
+			continue
+		}
+
+		packed, err := m.bytes()
+		if err != nil {
+			return err
+		}
+		values, err := packedVarints(packed)
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			ids = values
+		case 8:
+			lats = values
+		case 9:
+			lons = values
+		case 10:
+			keysVals = values
+		}
+	}
+
+	// Sem keys_vals, nenhum nó do grupo tem tags
+	if len(keysVals) == 0 {
+		return nil
+	}
+	if len(lats) != len(ids) || len(lons) != len(ids) {
+		return fmt.Errorf("DenseNodes com %d ids, %d latitudes e %d longitudes", len(ids), len(lats), len(lons))
+	}
+
+	var id, lat, lon int64
+	kv := 0
+	for i := range ids {
+		id += zigzag(ids[i])
+		lat += zigzag(lats[i])
+		lon += zigzag(lons[i])
+
+		start := kv
+		for kv < len(keysVals) && keysVals[kv] != 0 {
+			kv += 2
+		}
+		if kv > len(keysVals) {
+			return fmt.Errorf("keys_vals de DenseNodes truncado")
+		}
+		tags := keysVals[start:kv]
+		kv++ // 0 que termina as tags do nó
+
+		if len(tags) == 0 {
+			continue
+		}
+		if b.tagKey > 0 && !containsKeyPairs(tags, uint64(b.tagKey)) {
+			continue
+		}
+
+		node := Node{ID: id, Tags: make(map[string]string, len(tags)/2)}
+		node.Lon, node.Lat = b.coordinates(lon, lat)
+		for j := 0; j+1 < len(tags); j += 2 {
+			key, err := b.tag(tags[j])
+			if err != nil {
+				return err
+			}
+			value, err := b.tag(tags[j+1])
+			if err != nil {
+				return err
+			}
+			node.Tags[key] = value
+		}
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsKey(keys []uint64, key uint64) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeyPairs procura a chave em uma sequência key, value, key, value...
+func containsKeyPairs(tags []uint64, key uint64) bool {
+	for j := 0; j < len(tags); j += 2 {
+		if tags[j] == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package osm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pb monta mensagens protobuf para os extratos de teste
+type pb []byte
+
+func (p pb) key(field, wire int) pb {
+	return p.uvarint(uint64(field<<3 | wire))
+}
+
+func (p pb) uvarint(v uint64) pb {
+	return binary.AppendUvarint(p, v)
+}
+
+func (p pb) varint(field int, v uint64) pb {
+	return p.key(field, wireVarint).uvarint(v)
+}
+
+func (p pb) sint(field int, v int64) pb {
+	return p.varint(field, uint64(v<<1)^uint64(v>>63))
+}
+
+func (p pb) bytes(field int, b []byte) pb {
+	return append(p.key(field, wireBytes).uvarint(uint64(len(b))), b...)
+}
+
+func (p pb) str(field int, s string) pb {
+	return p.bytes(field, []byte(s))
+}
+
+func (p pb) packed(field int, values ...uint64) pb {
+	var inner pb
+	for _, v := range values {
+		inner = inner.uvarint(v)
+	}
+	return p.bytes(field, inner)
+}
+
+// deltas codifica valores absolutos como deltas em zigzag (DenseNodes)
+func deltas(values ...int64) []uint64 {
+	out := make([]uint64, len(values))
+	var prev int64
+	for i, v := range values {
+		d := v - prev
+		out[i] = uint64(d<<1) ^ uint64(d>>63)
+		prev = v
+	}
+	return out
+}
+
+// blob embala o conteúdo em BlobHeader + Blob, compactado com zlib
+func blob(t *testing.T, blobType string, data pb) []byte {
+	t.Helper()
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	body := pb(nil).varint(2, uint64(len(data))).bytes(3, compressed.Bytes())
+	return rawBlob(blobType, body)
+}
+
+// rawBlob embala um Blob já codificado
+func rawBlob(blobType string, body pb) []byte {
+	header := pb(nil).str(1, blobType).varint(3, uint64(len(body)))
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(header)))
+	return append(append(out, header...), body...)
+}
+
+func osmHeader(t *testing.T, features ...string) []byte {
+	var header pb
+	for _, f := range features {
+		header = header.str(4, f)
+	}
+	return blob(t, "OSMHeader", header)
+}
+
+// Tabela de strings do bloco de teste; o índice 0 é sempre vazio
+var testStrings = []string{"", "amenity", "hospital", "name", "Hospital São Paulo", "place", "city", "Campinas"}
+
+// Coordenadas em unidades da granularidade padrão (100 nanograus)
+func nano(deg float64) int64 { return int64(deg * 1e7) }
+
+// osmData monta um PrimitiveBlock com um grupo DenseNodes (dois nós com tags
+// e um sem) e um grupo com um Node simples
+func osmData(t *testing.T, keysVals []uint64) []byte {
+	var table pb
+	for _, s := range testStrings {
+		table = table.str(1, s)
+	}
+
+	dense := pb(nil).
+		packed(1, deltas(10, 11, 12)...).
+		packed(8, deltas(nano(-23.5), nano(-23.6), nano(-22.9))...).
+		packed(9, deltas(nano(-46.6), nano(-46.7), nano(-47.06))...).
+		packed(10, keysVals...)
+	node := pb(nil).sint(1, 20).packed(2, 1).packed(3, 2).sint(8, nano(-15.8)).sint(9, nano(-47.9))
+
+	block := pb(nil).
+		bytes(1, table).
+		bytes(2, pb(nil).bytes(2, dense)).
+		bytes(2, pb(nil).bytes(1, node))
+	return blob(t, "OSMData", block)
+}
+
+var denseKeysVals = []uint64{1, 2, 3, 4, 0, 0, 5, 6, 3, 7, 0}
+
+func extract(blobs ...[]byte) *bytes.Reader {
+	return bytes.NewReader(bytes.Join(blobs, nil))
+}
+
+func collect(r *bytes.Reader, withTag string) ([]Node, error) {
+	var nodes []Node
+	err := DecodeNodes(r, withTag, func(n Node) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	return nodes, err
+}
+
+func TestDecodeNodes(t *testing.T) {
+	hospital := Node{ID: 10, Lon: -46.6, Lat: -23.5, Tags: map[string]string{"amenity": "hospital", "name": "Hospital São Paulo"}}
+	campinas := Node{ID: 12, Lon: -47.06, Lat: -22.9, Tags: map[string]string{"place": "city", "name": "Campinas"}}
+	plain := Node{ID: 20, Lon: -47.9, Lat: -15.8, Tags: map[string]string{"amenity": "hospital"}}
+
+	tests := []struct {
+		name    string
+		withTag string
+		want    []Node
+	}{
+		{"todos os nós com tags", "", []Node{hospital, campinas, plain}},
+		{"filtro em DenseNodes", "place", []Node{campinas}},
+		{"filtro nos dois grupos", "amenity", []Node{hospital, plain}},
+		{"chave ausente do bloco", "shop", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := extract(osmHeader(t, "OsmSchema-V0.6", "DenseNodes"), osmData(t, denseKeysVals))
+			nodes, err := collect(r, tt.withTag)
+			if err != nil {
+				t.Fatalf("DecodeNodes: %v", err)
+			}
+			if !reflect.DeepEqual(nodes, tt.want) {
+				t.Errorf("nós = %+v\nesperado %+v", nodes, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeNodesUncompressedBlob(t *testing.T) {
+	var table pb
+	for _, s := range testStrings {
+		table = table.str(1, s)
+	}
+	node := pb(nil).sint(1, 7).packed(2, 5).packed(3, 6)
+	block := pb(nil).bytes(1, table).bytes(2, pb(nil).bytes(1, node)).varint(17, 1000)
+
+	nodes, err := collect(extract(rawBlob("OSMData", pb(nil).bytes(1, block))), "")
+	if err != nil {
+		t.Fatalf("DecodeNodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != 7 || nodes[0].Tags["place"] != "city" {
+		t.Errorf("nós = %+v, esperado o nó 7 com place=city", nodes)
+	}
+}
+
+func TestDecodeNodesCallbackError(t *testing.T) {
+	stop := errors.New("parar")
+	calls := 0
+	err := DecodeNodes(extract(osmData(t, denseKeysVals)), "", func(Node) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("DecodeNodes = %v após %d chamadas, esperado o erro de fn após 1", err, calls)
+	}
+}
+
+func TestDecodeNodesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+		want string
+	}{
+		{
+			name: "recurso obrigatório não suportado",
+			data: func(t *testing.T) []byte {
+				return bytes.Join([][]byte{osmHeader(t, "OsmSchema-V0.6", "HistoricalInformation"), osmData(t, denseKeysVals)}, nil)
+			},
+			want: `recurso não suportado "HistoricalInformation"`,
+		},
+		{
+			name: "keys_vals sem o par da chave",
+			data: func(t *testing.T) []byte { return osmData(t, []uint64{1, 2, 3}) },
+			want: "keys_vals de DenseNodes truncado",
+		},
+		{
+			name: "keys_vals sem terminadores",
+			data: func(t *testing.T) []byte { return osmData(t, []uint64{1, 2}) },
+			want: "keys_vals de DenseNodes truncado",
+		},
+		{
+			name: "tag fora da tabela de strings",
+			data: func(t *testing.T) []byte { return osmData(t, []uint64{1, 99, 0, 0, 0}) },
+			want: "índice 99 fora da tabela de strings",
+		},
+		{
+			name: "compressão lzma",
+			data: func(t *testing.T) []byte { return rawBlob("OSMData", pb(nil).bytes(4, []byte{1})) },
+			want: "apenas zlib",
+		},
+		{
+			name: "bloco cortado",
+			data: func(t *testing.T) []byte {
+				b := osmData(t, denseKeysVals)
+				return b[:len(b)-5]
+			},
+			want: "erro ao ler bloco PBF",
+		},
+		{
+			name: "cabeçalho de bloco grande demais",
+			data: func(t *testing.T) []byte { return binary.BigEndian.AppendUint32(nil, maxHeaderSize+1) },
+			want: "cabeçalho de bloco PBF com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := collect(bytes.NewReader(tt.data(t)), "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("DecodeNodes = %v, esperado erro com %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package osm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Tipos de campo do formato binário do protobuf usados pelo PBF
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("mensagem protobuf truncada")
+
+// message percorre os campos de uma mensagem protobuf codificada, sem esquema:
+// o PBF usa poucas mensagens, lidas campo a campo pelo número
+type message struct {
+	buf []byte
+	pos int
+}
+
+// next retorna o número e o tipo do próximo campo; io.EOF no fim da mensagem
+func (m *message) next() (int, int, error) {
+	if m.pos >= len(m.buf) {
+		return 0, 0, io.EOF
+	}
+	key, err := m.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(key >> 3), int(key & 7), nil
+}
+
+func (m *message) varint() (uint64, error) {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if m.pos >= len(m.buf) {
+			return 0, errTruncated
+		}
+		b := m.buf[m.pos]
+		m.pos++
+		value |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("varint inválido")
+}
+
+// sint lê um varint em zigzag (sint32/sint64)
+func (m *message) sint() (int64, error) {
+	v, err := m.varint()
+	return zigzag(v), err
+}
+
+// bytes lê um campo length-delimited (bytes, string, mensagem ou packed)
+func (m *message) bytes() ([]byte, error) {
+	n, err := m.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(m.buf)-m.pos) {
+		return nil, errTruncated
+	}
+	b := m.buf[m.pos : m.pos+int(n)]
+	m.pos += int(n)
+	return b, nil
+}
+
+// skip descarta o valor de um campo não usado
+func (m *message) skip(wire int) error {
+	switch wire {
+	case wireVarint:
+		_, err := m.varint()
+		return err
+	case wireBytes:
+		_, err := m.bytes()
+		return err
+	case wireFixed64:
+		m.pos += 8
+	case wireFixed32:
+		m.pos += 4
+	default:
+		return fmt.Errorf("tipo de campo protobuf %d não suportado", wire)
+	}
+	if m.pos > len(m.buf) {
+		return errTruncated
+	}
+	return nil
+}
+
+func zigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// packedVarints decodifica um campo packed de varints (uint32, int32, sint64...)
+func packedVarints(b []byte) ([]uint64, error) {
+	values := make([]uint64, 0, len(b))
+	m := message{buf: b}
+	for m.pos < len(b) {
+		v, err := m.varint()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
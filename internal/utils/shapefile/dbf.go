@@ -0,0 +1,145 @@
+package shapefile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// Field é uma coluna da tabela DBF
+type Field struct {
+	Name     string
+	Type     byte // C (texto), N/F (número), D (data AAAAMMDD), L (lógico)
+	Length   int
+	Decimals int
+}
+
+// dbfReader lê os registros de um arquivo dBASE III em sequência
+type dbfReader struct {
+	file       *os.File
+	r          *bufio.Reader
+	fields     []Field
+	numRecords int
+	recordLen  int
+	decode     func([]byte) string
+}
+
+// Códigos de página (byte 29 do cabeçalho) que indicam Latin-1/Windows-1252
+var latin1LanguageDrivers = map[byte]bool{0x03: true, 0x57: true, 0x58: true, 0x59: true}
+
+func openDBF(path, cpg string) (*dbfReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir .dbf: %v", err)
+	}
+
+	d := &dbfReader{file: file, r: bufio.NewReaderSize(file, 1<<16)}
+	if err := d.readHeader(cpg); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *dbfReader) readHeader(cpg string) error {
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		return fmt.Errorf("erro ao ler cabeçalho do .dbf: %v", err)
+	}
+
+	d.numRecords = int(binary.LittleEndian.Uint32(header[4:8]))
+	headerLen := int(binary.LittleEndian.Uint16(header[8:10]))
+	d.recordLen = int(binary.LittleEndian.Uint16(header[10:12]))
+	d.decode = chooseDecoder(cpg, header[29])
+
+	// Descritores de 32 bytes até o terminador 0x0D
+	read := 32
+	for {
+		b, err := d.r.Peek(1)
+		if err != nil {
+			return fmt.Errorf("erro ao ler colunas do .dbf: %v", err)
+		}
+		if b[0] == 0x0D {
+			break
+		}
+
+		descriptor := make([]byte, 32)
+		if _, err := io.ReadFull(d.r, descriptor); err != nil {
+			return fmt.Errorf("erro ao ler colunas do .dbf: %v", err)
+		}
+		read += 32
+
+		name := descriptor[:11]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		d.fields = append(d.fields, Field{
+			Name:     strings.TrimSpace(d.decode(name)),
+			Type:     descriptor[11],
+			Length:   int(descriptor[16]),
+			Decimals: int(descriptor[17]),
+		})
+	}
+
+	// Pula o terminador e eventuais bytes até o início dos registros
+	if headerLen < read+1 {
+		return fmt.Errorf("cabeçalho do .dbf inválido")
+	}
+	if _, err := d.r.Discard(headerLen - read); err != nil {
+		return fmt.Errorf("erro ao ler cabeçalho do .dbf: %v", err)
+	}
+
+	total := 1 // byte de exclusão
+	for _, f := range d.fields {
+		total += f.Length
+	}
+	if total != d.recordLen {
+		return fmt.Errorf(".dbf inválido: registros de %d bytes e colunas somando %d", d.recordLen, total)
+	}
+	return nil
+}
+
+// chooseDecoder usa o .cpg quando existe, senão o código de página do
+// cabeçalho; sem nenhum dos dois, detecta por valor (UTF-8 ou Latin-1)
+func chooseDecoder(cpg string, languageDriver byte) func([]byte) string {
+	switch cp := strings.ToUpper(strings.TrimSpace(cpg)); {
+	case cp == "UTF-8" || cp == "UTF8" || cp == "65001":
+		return func(b []byte) string { return string(b) }
+	case strings.Contains(cp, "8859") || strings.Contains(cp, "1252") || strings.Contains(cp, "LATIN"):
+		return utils.Latin1ToUTF8
+	}
+
+	if latin1LanguageDrivers[languageDriver] {
+		return utils.Latin1ToUTF8
+	}
+	return utils.EnsureUTF8
+}
+
+// next lê o próximo registro; os valores vêm sem os espaços de preenchimento
+func (d *dbfReader) next() (map[string]string, bool, error) {
+	record := make([]byte, d.recordLen)
+	if _, err := io.ReadFull(d.r, record); err != nil {
+		return nil, false, fmt.Errorf("erro ao ler registro do .dbf: %v", err)
+	}
+
+	deleted := record[0] == '*'
+	attributes := make(map[string]string, len(d.fields))
+	offset := 1
+	for _, f := range d.fields {
+		value := bytes.TrimRight(record[offset:offset+f.Length], " \x00")
+		attributes[f.Name] = strings.TrimSpace(d.decode(value))
+		offset += f.Length
+	}
+
+	return attributes, deleted, nil
+}
+
+func (d *dbfReader) close() error {
+	return d.file.Close()
+}
@@ -0,0 +1,99 @@
+package shapefile
+
+import "math"
+
+// Polygons agrupa os anéis no formato das coordenadas de um GeoJSON
+// MultiPolygon: polígonos → anéis → posições [x, y]. Anéis em sentido horário iniciam um
+// polígono; os anti-horários são buracos do anel externo que os contém. Se o
+// arquivo não segue a orientação da especificação, todos viram polígonos.
+func (p Polygon) Polygons() [][][][]float64 {
+	var outers []int
+	for i, part := range p.Parts {
+		if signedArea(part) < 0 {
+			outers = append(outers, i)
+		}
+	}
+	if len(outers) == 0 {
+		for i := range p.Parts {
+			outers = append(outers, i)
+		}
+	}
+
+	polygons := make([][][][]float64, len(outers))
+	isOuter := map[int]int{}
+	for n, i := range outers {
+		polygons[n] = [][][]float64{toCoordinates(p.Parts[i])}
+		isOuter[i] = n
+	}
+
+	for i, part := range p.Parts {
+		if _, ok := isOuter[i]; ok || len(part) == 0 {
+			continue
+		}
+		owner := len(outers) - 1
+		for n, o := range outers {
+			if containsPoint(p.Parts[o], part[0]) {
+				owner = n
+				break
+			}
+		}
+		polygons[owner] = append(polygons[owner], toCoordinates(part))
+	}
+
+	return polygons
+}
+
+// Centroid retorna o centroide do maior anel externo, usado como ponto de
+// referência quando uma camada de polígonos é importada como localizações.
+// Em polígonos muito côncavos o centroide pode cair fora da área.
+func (p Polygon) Centroid() Point {
+	best := -1
+	bestArea := 0.0
+	for i, part := range p.Parts {
+		if a := math.Abs(signedArea(part)); a > bestArea {
+			best, bestArea = i, a
+		}
+	}
+	if best < 0 {
+		return Point{X: (p.Box[0] + p.Box[2]) / 2, Y: (p.Box[1] + p.Box[3]) / 2}
+	}
+
+	ring := p.Parts[best]
+	area := signedArea(ring)
+	var cx, cy float64
+	for i := 0; i < len(ring)-1; i++ {
+		cross := ring[i].X*ring[i+1].Y - ring[i+1].X*ring[i].Y
+		cx += (ring[i].X + ring[i+1].X) * cross
+		cy += (ring[i].Y + ring[i+1].Y) * cross
+	}
+	return Point{X: cx / (6 * area), Y: cy / (6 * area)}
+}
+
+// signedArea é positiva para anéis anti-horários (fórmula do laço)
+func signedArea(ring []Point) float64 {
+	area := 0.0
+	for i := 0; i < len(ring)-1; i++ {
+		area += ring[i].X*ring[i+1].Y - ring[i+1].X*ring[i].Y
+	}
+	return area / 2
+}
+
+// containsPoint testa se o ponto está dentro do anel (ray casting)
+func containsPoint(ring []Point, pt Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) && pt.X < (b.X-a.X)*(pt.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func toCoordinates(ring []Point) [][]float64 {
+	coordinates := make([][]float64, len(ring))
+	for i, pt := range ring {
+		coordinates[i] = []float64{pt.X, pt.Y}
+	}
+	return coordinates
+}
@@ -0,0 +1,387 @@
+// Package shapefile lê shapefiles ESRI (.shp/.shx/.dbf) sem dependências
+// externas. Suporta camadas de pontos e polígonos (inclusive as variantes Z e
+// M, das quais só X e Y são lidos) e tabelas DBF em UTF-8 ou Latin-1.
+package shapefile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kaguyo/Geolocation-Brasil/internal/utils"
+)
+
+// ShapeType é o tipo de geometria de uma camada ou registro
+type ShapeType int32
+
+const (
+	TypeNull     ShapeType = 0
+	TypePoint    ShapeType = 1
+	TypePolyLine ShapeType = 3
+	TypePolygon  ShapeType = 5
+	TypePointZ   ShapeType = 11
+	TypePolygonZ ShapeType = 15
+	TypePointM   ShapeType = 21
+	TypePolygonM ShapeType = 25
+)
+
+// IsPoint indica camadas de pontos (Point, PointZ, PointM)
+func (t ShapeType) IsPoint() bool {
+	return t == TypePoint || t == TypePointZ || t == TypePointM
+}
+
+// IsPolygon indica camadas de polígonos (Polygon, PolygonZ, PolygonM)
+func (t ShapeType) IsPolygon() bool {
+	return t == TypePolygon || t == TypePolygonZ || t == TypePolygonM
+}
+
+var (
+	// ErrUnsupportedShape indica uma camada que não é de pontos nem de polígonos
+	ErrUnsupportedShape = errors.New("tipo de geometria não suportado")
+	// ErrProjected indica coordenadas projetadas (ex: UTM), que precisariam ser
+	// reprojetadas para latitude/longitude antes da importação
+	ErrProjected = errors.New("shapefile em coordenadas projetadas; reprojete para coordenadas geográficas (SIRGAS 2000 ou WGS 84)")
+)
+
+const (
+	shpFileCode   = 9994
+	shpHeaderSize = 100
+)
+
+// Shape é a geometria de um registro: Null, Point ou Polygon
+type Shape interface {
+	Type() ShapeType
+}
+
+// Null é um registro sem geometria
+type Null struct{}
+
+func (Null) Type() ShapeType { return TypeNull }
+
+// Point é um ponto; X é a longitude e Y a latitude em camadas geográficas
+type Point struct {
+	X, Y float64
+}
+
+func (Point) Type() ShapeType { return TypePoint }
+
+// Polygon é um polígono com um ou mais anéis. Pela especificação, anéis
+// externos estão em sentido horário e buracos em sentido anti-horário.
+type Polygon struct {
+	Box   [4]float64 // xmin, ymin, xmax, ymax
+	Parts [][]Point
+}
+
+func (Polygon) Type() ShapeType { return TypePolygon }
+
+// Record é um registro da camada: geometria e atributos do DBF
+type Record struct {
+	Number     int
+	Shape      Shape
+	Attributes map[string]string
+	Deleted    bool // marcado como removido no DBF
+}
+
+// Values retorna os atributos na ordem das colunas, para relatórios
+func (r *Record) Values(fields []Field) []string {
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = r.Attributes[f.Name]
+	}
+	return values
+}
+
+// Reader lê os registros de um shapefile em sequência
+type Reader struct {
+	shpFile   *os.File
+	shp       *bufio.Reader
+	dbf       *dbfReader
+	shapeType ShapeType
+	bbox      [4]float64
+	count     int
+	tmpDir    string
+	read      int
+	remaining int64 // bytes do .shp ainda não lidos
+}
+
+// Open abre um shapefile a partir do .shp (os demais arquivos devem ter o
+// mesmo nome base) ou de um .zip que contenha a camada, como os distribuídos
+// pelo IBGE. O .dbf é obrigatório; .shx, .cpg e .prj são usados se existirem.
+func Open(path string) (*Reader, error) {
+	tmpDir := ""
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		dir, shpPath, err := extractZip(path)
+		if err != nil {
+			return nil, err
+		}
+		tmpDir, path = dir, shpPath
+	}
+
+	r, err := open(path)
+	if err != nil {
+		if tmpDir != "" {
+			os.RemoveAll(tmpDir)
+		}
+		return nil, err
+	}
+	r.tmpDir = tmpDir
+	return r, nil
+}
+
+func open(shpPath string) (*Reader, error) {
+	base := strings.TrimSuffix(shpPath, filepath.Ext(shpPath))
+
+	if prj, err := readSidecar(base, ".prj"); err == nil {
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(prj)), "PROJCS") {
+			return nil, ErrProjected
+		}
+	}
+
+	shpFile, err := os.Open(shpPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir .shp: %v", err)
+	}
+
+	info, err := shpFile.Stat()
+	if err != nil {
+		shpFile.Close()
+		return nil, fmt.Errorf("erro ao abrir .shp: %v", err)
+	}
+
+	r := &Reader{shpFile: shpFile, shp: bufio.NewReaderSize(shpFile, 1<<16), count: -1, remaining: info.Size() - shpHeaderSize}
+	if err := r.readHeader(); err != nil {
+		shpFile.Close()
+		return nil, err
+	}
+
+	dbfPath, err := findSidecar(base, ".dbf")
+	if err != nil {
+		shpFile.Close()
+		return nil, err
+	}
+	cpg, _ := readSidecar(base, ".cpg")
+	r.dbf, err = openDBF(dbfPath, cpg)
+	if err != nil {
+		shpFile.Close()
+		return nil, err
+	}
+
+	// O .shx tem 8 bytes por registro após o cabeçalho
+	r.count = r.dbf.numRecords
+	if shxPath, err := findSidecar(base, ".shx"); err == nil {
+		if info, err := os.Stat(shxPath); err == nil && info.Size() >= shpHeaderSize {
+			if n := int((info.Size() - shpHeaderSize) / 8); n != r.dbf.numRecords {
+				r.Close()
+				return nil, fmt.Errorf("shapefile inconsistente: %d registros no .shx e %d no .dbf", n, r.dbf.numRecords)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Reader) readHeader() error {
+	header := make([]byte, shpHeaderSize)
+	if _, err := io.ReadFull(r.shp, header); err != nil {
+		return fmt.Errorf("erro ao ler cabeçalho do .shp: %v", err)
+	}
+
+	if code := binary.BigEndian.Uint32(header[0:4]); code != shpFileCode {
+		return fmt.Errorf("arquivo .shp inválido (código %d)", code)
+	}
+
+	r.shapeType = ShapeType(binary.LittleEndian.Uint32(header[32:36]))
+	if !r.shapeType.IsPoint() && !r.shapeType.IsPolygon() {
+		return fmt.Errorf("%w: %d", ErrUnsupportedShape, r.shapeType)
+	}
+
+	for i := range r.bbox {
+		r.bbox[i] = math.Float64frombits(binary.LittleEndian.Uint64(header[36+8*i:]))
+	}
+	return nil
+}
+
+// ShapeType retorna o tipo de geometria da camada
+func (r *Reader) ShapeType() ShapeType { return r.shapeType }
+
+// BBox retorna o retângulo envolvente da camada: xmin, ymin, xmax, ymax
+func (r *Reader) BBox() [4]float64 { return r.bbox }
+
+// Fields retorna as colunas do DBF
+func (r *Reader) Fields() []Field { return r.dbf.fields }
+
+// Len retorna o número de registros da camada
+func (r *Reader) Len() int { return r.count }
+
+// Next lê o próximo registro; retorna io.EOF ao final
+func (r *Reader) Next() (*Record, error) {
+	if r.read >= r.count {
+		return nil, io.EOF
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r.shp, header); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("fim inesperado do .shp após %d de %d registros", r.read, r.count)
+		}
+		return nil, fmt.Errorf("erro ao ler registro do .shp: %v", err)
+	}
+
+	r.remaining -= int64(len(header))
+
+	number := int(binary.BigEndian.Uint32(header[0:4]))
+	contentLength := int64(binary.BigEndian.Uint32(header[4:8])) * 2 // em palavras de 16 bits
+
+	// O tamanho vem do arquivo: só é alocado se couber no que resta do .shp
+	if contentLength > r.remaining {
+		return nil, fmt.Errorf("registro %d do .shp declara %d bytes, mas restam %d no arquivo", number, contentLength, r.remaining)
+	}
+	r.remaining -= contentLength
+
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(r.shp, content); err != nil {
+		return nil, fmt.Errorf("erro ao ler registro %d do .shp: %v", number, err)
+	}
+
+	shape, err := parseShape(content)
+	if err != nil {
+		return nil, fmt.Errorf("registro %d: %v", number, err)
+	}
+
+	attributes, deleted, err := r.dbf.next()
+	if err != nil {
+		return nil, fmt.Errorf("registro %d: %v", number, err)
+	}
+
+	r.read++
+	return &Record{Number: number, Shape: shape, Attributes: attributes, Deleted: deleted}, nil
+}
+
+// Close fecha os arquivos e remove a extração temporária de um .zip
+func (r *Reader) Close() error {
+	err := r.shpFile.Close()
+	if r.dbf != nil {
+		if dbfErr := r.dbf.close(); err == nil {
+			err = dbfErr
+		}
+	}
+	if r.tmpDir != "" {
+		os.RemoveAll(r.tmpDir)
+	}
+	return err
+}
+
+// parseShape decodifica o conteúdo de um registro do .shp
+func parseShape(content []byte) (Shape, error) {
+	if len(content) < 4 {
+		return nil, fmt.Errorf("registro vazio")
+	}
+
+	shapeType := ShapeType(binary.LittleEndian.Uint32(content[0:4]))
+	data := content[4:]
+
+	switch {
+	case shapeType == TypeNull:
+		return Null{}, nil
+
+	case shapeType.IsPoint():
+		if len(data) < 16 {
+			return nil, fmt.Errorf("ponto truncado")
+		}
+		return Point{X: readFloat(data, 0), Y: readFloat(data, 8)}, nil
+
+	case shapeType.IsPolygon():
+		// Box (32) + NumParts (4) + NumPoints (4) + Parts (4 cada) + Points (16 cada);
+		// nas variantes Z e M os arrays de Z e M vêm depois e são ignorados
+		if len(data) < 40 {
+			return nil, fmt.Errorf("polígono truncado")
+		}
+		polygon := Polygon{}
+		for i := range polygon.Box {
+			polygon.Box[i] = readFloat(data, 8*i)
+		}
+
+		numParts := int(binary.LittleEndian.Uint32(data[32:36]))
+		numPoints := int(binary.LittleEndian.Uint32(data[36:40]))
+		pointsStart := 40 + 4*numParts
+		if numParts < 0 || numPoints < 0 || pointsStart+16*numPoints > len(data) {
+			return nil, fmt.Errorf("polígono truncado (%d partes, %d pontos)", numParts, numPoints)
+		}
+
+		starts := make([]int, numParts+1)
+		for i := 0; i < numParts; i++ {
+			starts[i] = int(binary.LittleEndian.Uint32(data[40+4*i:]))
+		}
+		starts[numParts] = numPoints
+
+		for i := 0; i < numParts; i++ {
+			if starts[i] < 0 || starts[i] > starts[i+1] || starts[i+1] > numPoints {
+				return nil, fmt.Errorf("índice de parte inválido")
+			}
+			part := make([]Point, 0, starts[i+1]-starts[i])
+			for j := starts[i]; j < starts[i+1]; j++ {
+				offset := pointsStart + 16*j
+				part = append(part, Point{X: readFloat(data, offset), Y: readFloat(data, offset+8)})
+			}
+			polygon.Parts = append(polygon.Parts, part)
+		}
+		return polygon, nil
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnsupportedShape, shapeType)
+}
+
+func readFloat(data []byte, offset int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(data[offset:]))
+}
+
+// findSidecar localiza base+ext sem diferenciar maiúsculas na extensão
+func findSidecar(base, ext string) (string, error) {
+	for _, candidate := range []string{base + ext, base + strings.ToUpper(ext)} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("arquivo %s não encontrado junto ao .shp", ext)
+}
+
+func readSidecar(base, ext string) (string, error) {
+	path, err := findSidecar(base, ext)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// extractZip extrai o zip para um diretório temporário e retorna o primeiro .shp
+func extractZip(zipPath string) (string, string, error) {
+	dir, err := os.MkdirTemp("", "shapefile-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	files, err := utils.UnzipFile(zipPath, dir, utils.UnzipOptions{})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+
+	for _, f := range files {
+		if strings.EqualFold(filepath.Ext(f), ".shp") {
+			return dir, f, nil
+		}
+	}
+
+	os.RemoveAll(dir)
+	return "", "", fmt.Errorf("nenhum arquivo .shp encontrado em %s", zipPath)
+}
@@ -0,0 +1,366 @@
+package shapefile
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// As camadas em testdata são geradas por testdata/generate.go
+
+// readAll lê todos os registros da camada
+func readAll(t *testing.T, r *Reader) []*Record {
+	t.Helper()
+	var records []*Record
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+// copyLayer copia os arquivos da camada para um diretório temporário, onde o
+// teste pode alterá-los; retorna o caminho do .shp
+func copyLayer(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	matches, err := filepath.Glob(filepath.Join("testdata", name+".*"))
+	if err != nil || len(matches) == 0 {
+		t.Fatalf("camada %s não encontrada em testdata", name)
+	}
+	for _, src := range matches {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(src)), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, name+".shp")
+}
+
+// patchFile aplica fn ao conteúdo do arquivo
+func patchFile(t *testing.T, path string, fn func([]byte) []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, fn(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadPoints(t *testing.T) {
+	r, err := Open(filepath.Join("testdata", "pontos.shp"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+
+	if r.ShapeType() != TypePoint || r.Len() != 2 {
+		t.Fatalf("camada = tipo %d, %d registros; esperado pontos com 2", r.ShapeType(), r.Len())
+	}
+	wantFields := []Field{{Name: "NOME", Type: 'C', Length: 20}, {Name: "CODIGO", Type: 'N', Length: 7}}
+	if !reflect.DeepEqual(r.Fields(), wantFields) {
+		t.Errorf("Fields = %+v, esperado %+v", r.Fields(), wantFields)
+	}
+	if want := [4]float64{-49.2648, -23.5505, -46.6333, -16.6869}; r.BBox() != want {
+		t.Errorf("BBox = %v, esperado %v", r.BBox(), want)
+	}
+
+	records := readAll(t, r)
+	want := []struct {
+		point Point
+		nome  string
+		cod   string
+	}{
+		{Point{X: -46.6333, Y: -23.5505}, "São Paulo", "3550308"},
+		{Point{X: -49.2648, Y: -16.6869}, "Goiânia", "5208707"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("%d registros, esperado %d", len(records), len(want))
+	}
+	for i, w := range want {
+		record := records[i]
+		if record.Number != i+1 || record.Shape != w.point || record.Deleted {
+			t.Errorf("registro %d = %+v, esperado ponto %+v", i+1, record, w.point)
+		}
+		if got := record.Values(r.Fields()); !reflect.DeepEqual(got, []string{w.nome, w.cod}) {
+			t.Errorf("registro %d: atributos = %q, esperado [%q %q]", i+1, got, w.nome, w.cod)
+		}
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next após o último registro = %v, esperado io.EOF", err)
+	}
+}
+
+func TestReadPolygons(t *testing.T) {
+	r, err := Open(filepath.Join("testdata", "municipios.shp"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+
+	if !r.ShapeType().IsPolygon() {
+		t.Fatalf("tipo = %d, esperado polígono", r.ShapeType())
+	}
+
+	records := readAll(t, r)
+	if len(records) != 2 {
+		t.Fatalf("%d registros, esperado 2", len(records))
+	}
+
+	polygon, ok := records[0].Shape.(Polygon)
+	if !ok {
+		t.Fatalf("registro 1 = %T, esperado Polygon", records[0].Shape)
+	}
+	if want := [4]float64{-48, -21.4, -47.6, -21}; polygon.Box != want {
+		t.Errorf("Box = %v, esperado %v", polygon.Box, want)
+	}
+	if len(polygon.Parts) != 2 || len(polygon.Parts[0]) != 5 || len(polygon.Parts[1]) != 5 {
+		t.Fatalf("partes = %v, esperado anel externo e buraco com 5 pontos cada", polygon.Parts)
+	}
+
+	// O buraco (anti-horário) fica no mesmo polígono do anel externo
+	polygons := polygon.Polygons()
+	if len(polygons) != 1 || len(polygons[0]) != 2 {
+		t.Fatalf("Polygons = %v, esperado um polígono com buraco", polygons)
+	}
+	if got := polygons[0][1][0]; !reflect.DeepEqual(got, []float64{-47.9, -21.3}) {
+		t.Errorf("primeira posição do buraco = %v", got)
+	}
+	if c := polygon.Centroid(); c.X < -48 || c.X > -47.6 || c.Y < -21.4 || c.Y > -21 {
+		t.Errorf("Centroid = %+v, fora do polígono", c)
+	}
+	if got := records[0].Attributes["NM_MUN"]; got != "Ribeirão Preto" {
+		t.Errorf("NM_MUN = %q, esperado Latin-1 convertido pelo .cpg", got)
+	}
+
+	if _, ok := records[1].Shape.(Null); !ok || !records[1].Deleted {
+		t.Errorf("registro 2 = %+v, esperado nulo e removido", records[1])
+	}
+	if got := records[1].Attributes["NM_MUN"]; got != "São Simão" {
+		t.Errorf("NM_MUN = %q, esperado \"São Simão\"", got)
+	}
+}
+
+func TestOpenZip(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "pontos.zip")
+	out, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	for _, ext := range []string{".shp", ".shx", ".dbf"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "pontos"+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create("BR_Pontos/pontos" + strings.ToUpper(ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	r, err := Open(zipPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	tmpDir := r.tmpDir
+	if records := readAll(t, r); len(records) != 2 {
+		t.Errorf("%d registros, esperado 2", len(records))
+	}
+	r.Close()
+
+	if _, err := os.Stat(tmpDir); !os.IsNotExist(err) {
+		t.Errorf("extração temporária %s não foi removida", tmpDir)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		layer string
+		setup func(t *testing.T, shp string)
+		want  string
+		is    error
+	}{
+		{
+			name:  "projetado",
+			layer: "pontos",
+			setup: func(t *testing.T, shp string) {
+				prj := `PROJCS["SIRGAS 2000 / UTM zone 23S",GEOGCS["SIRGAS 2000"]]`
+				os.WriteFile(strings.TrimSuffix(shp, ".shp")+".prj", []byte(prj), 0o644)
+			},
+			is: ErrProjected,
+		},
+		{
+			name:  "sem dbf",
+			layer: "pontos",
+			setup: func(t *testing.T, shp string) { os.Remove(strings.TrimSuffix(shp, ".shp") + ".dbf") },
+			want:  ".dbf não encontrado",
+		},
+		{
+			name:  "código de arquivo",
+			layer: "pontos",
+			setup: func(t *testing.T, shp string) {
+				patchFile(t, shp, func(b []byte) []byte { b[3] = 0; return b })
+			},
+			want: "arquivo .shp inválido",
+		},
+		{
+			name:  "tipo não suportado",
+			layer: "pontos",
+			setup: func(t *testing.T, shp string) {
+				patchFile(t, shp, func(b []byte) []byte { b[32] = byte(TypePolyLine); return b })
+			},
+			is: ErrUnsupportedShape,
+		},
+		{
+			name:  "shx inconsistente",
+			layer: "municipios",
+			setup: func(t *testing.T, shp string) {
+				patchFile(t, strings.TrimSuffix(shp, ".shp")+".shx", func(b []byte) []byte { return b[:len(b)-8] })
+			},
+			want: "1 registros no .shx e 2 no .dbf",
+		},
+		{
+			name:  "colunas do dbf",
+			layer: "pontos",
+			setup: func(t *testing.T, shp string) {
+				patchFile(t, strings.TrimSuffix(shp, ".shp")+".dbf", func(b []byte) []byte { b[10]++; return b })
+			},
+			want: "colunas somando",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shp := copyLayer(t, tt.layer)
+			tt.setup(t, shp)
+
+			r, err := Open(shp)
+			if err == nil {
+				r.Close()
+				t.Fatal("Open sem erro")
+			}
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("erro = %v, esperado %v", err, tt.is)
+			}
+			if tt.want != "" && !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %v, esperado conter %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRejectsBadRecords(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch func([]byte) []byte
+		want  string
+	}{
+		{
+			// Um tamanho de registro absurdo não pode virar uma alocação de 8 GiB
+			name: "tamanho maior que o arquivo",
+			patch: func(b []byte) []byte {
+				binary.BigEndian.PutUint32(b[104:108], 0xFFFFFFFF)
+				return b
+			},
+			want: "declara 8589934590 bytes, mas restam",
+		},
+		{
+			name: "tamanho passa do fim",
+			patch: func(b []byte) []byte {
+				binary.BigEndian.PutUint32(b[104:108], binary.BigEndian.Uint32(b[104:108])+100)
+				return b
+			},
+			want: "mas restam",
+		},
+		{
+			name:  "arquivo truncado",
+			patch: func(b []byte) []byte { return b[:100] },
+			want:  "fim inesperado do .shp após 0 de 2 registros",
+		},
+		{
+			name: "ponto truncado",
+			patch: func(b []byte) []byte {
+				binary.BigEndian.PutUint32(b[104:108], 4) // 8 bytes: tipo e metade do X
+				return b
+			},
+			want: "ponto truncado",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shp := copyLayer(t, "pontos")
+			patchFile(t, shp, tt.patch)
+
+			r, err := Open(shp)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer r.Close()
+
+			_, err = r.Next()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Next = %v, esperado erro com %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseShapeInvalid(t *testing.T) {
+	polygon := func(numParts, numPoints uint32, starts ...uint32) []byte {
+		content := make([]byte, 4+40+4*len(starts)+16*int(numPoints))
+		binary.LittleEndian.PutUint32(content[0:], uint32(TypePolygon))
+		binary.LittleEndian.PutUint32(content[36:], numParts)
+		binary.LittleEndian.PutUint32(content[40:], numPoints)
+		for i, s := range starts {
+			binary.LittleEndian.PutUint32(content[44+4*i:], s)
+		}
+		return content
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"vazio", nil, "registro vazio"},
+		{"polígono truncado", polygon(1, 4, 0)[:40], "polígono truncado"},
+		{"mais pontos que o registro", polygon(1, 4, 0)[:60], "polígono truncado (1 partes, 4 pontos)"},
+		{"partes demais", polygon(0xFFFFFFFF, 0), "polígono truncado"},
+		{"índice de parte", polygon(2, 3, 0, 5), "índice de parte inválido"},
+		{"linha", []byte{byte(TypePolyLine), 0, 0, 0}, "tipo de geometria não suportado"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := parseShape(tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseShape = %v, %v; esperado erro com %q", shape, err, tt.want)
+			}
+		})
+	}
+}
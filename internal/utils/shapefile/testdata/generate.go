@@ -0,0 +1,205 @@
+//go:build ignore
+
+// Gera as camadas de teste do pacote shapefile:
+//
+//	go run testdata/generate.go
+//
+// pontos: dois pontos, DBF em Latin-1 indicado pelo código de página 0x57
+// municipios: um polígono com buraco e um registro nulo removido, DBF em
+// Latin-1 indicado pelo .cpg
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+type point struct{ x, y float64 }
+
+type field struct {
+	name   string
+	kind   byte
+	length int
+}
+
+func main() {
+	dir := filepath.Dir(os.Args[0])
+	if _, err := os.Stat("testdata"); err == nil {
+		dir = "testdata"
+	}
+
+	writeLayer(filepath.Join(dir, "pontos"), 1,
+		[][]byte{pointContent(-46.6333, -23.5505), pointContent(-49.2648, -16.6869)})
+	writeDBF(filepath.Join(dir, "pontos.dbf"), 0x57,
+		[]field{{"NOME", 'C', 20}, {"CODIGO", 'N', 7}},
+		[][]string{{"São Paulo", "3550308"}, {"Goiânia", "5208707"}},
+		[]bool{false, false})
+
+	outer := []point{{-48, -21.4}, {-48, -21}, {-47.6, -21}, {-47.6, -21.4}, {-48, -21.4}}
+	hole := []point{{-47.9, -21.3}, {-47.7, -21.3}, {-47.7, -21.1}, {-47.9, -21.1}, {-47.9, -21.3}}
+	writeLayer(filepath.Join(dir, "municipios"), 5,
+		[][]byte{polygonContent(outer, hole), nullContent()})
+	writeDBF(filepath.Join(dir, "municipios.dbf"), 0,
+		[]field{{"CD_MUN", 'C', 7}, {"NM_MUN", 'C', 30}},
+		[][]string{{"3543402", "Ribeirão Preto"}, {"3550902", "São Simão"}},
+		[]bool{false, true})
+	must(os.WriteFile(filepath.Join(dir, "municipios.cpg"), []byte("ISO-8859-1"), 0o644))
+}
+
+func pointContent(x, y float64) []byte {
+	buf := new(bytes.Buffer)
+	le(buf, int32(1))
+	le(buf, x)
+	le(buf, y)
+	return buf.Bytes()
+}
+
+func nullContent() []byte {
+	buf := new(bytes.Buffer)
+	le(buf, int32(0))
+	return buf.Bytes()
+}
+
+func polygonContent(parts ...[]point) []byte {
+	xmin, ymin, xmax, ymax := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	total := 0
+	for _, part := range parts {
+		for _, p := range part {
+			xmin, ymin = math.Min(xmin, p.x), math.Min(ymin, p.y)
+			xmax, ymax = math.Max(xmax, p.x), math.Max(ymax, p.y)
+		}
+		total += len(part)
+	}
+
+	buf := new(bytes.Buffer)
+	le(buf, int32(5))
+	le(buf, [4]float64{xmin, ymin, xmax, ymax})
+	le(buf, int32(len(parts)))
+	le(buf, int32(total))
+	start := 0
+	for _, part := range parts {
+		le(buf, int32(start))
+		start += len(part)
+	}
+	for _, part := range parts {
+		for _, p := range part {
+			le(buf, p.x)
+			le(buf, p.y)
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeLayer grava o .shp e o .shx com os registros já codificados
+func writeLayer(base string, shapeType int32, records [][]byte) {
+	shp, shx := new(bytes.Buffer), new(bytes.Buffer)
+	offset := 100
+	for i, content := range records {
+		be(shx, int32(offset/2))
+		be(shx, int32(len(content)/2))
+		be(shp, int32(i+1))
+		be(shp, int32(len(content)/2))
+		shp.Write(content)
+		offset += 8 + len(content)
+	}
+
+	bbox := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, content := range records {
+		if t := binary.LittleEndian.Uint32(content); t == 1 {
+			x, y := readFloat(content[4:]), readFloat(content[12:])
+			bbox = [4]float64{math.Min(bbox[0], x), math.Min(bbox[1], y), math.Max(bbox[2], x), math.Max(bbox[3], y)}
+		} else if t == 5 {
+			for i := 0; i < 2; i++ {
+				bbox[i] = math.Min(bbox[i], readFloat(content[4+8*i:]))
+				bbox[i+2] = math.Max(bbox[i+2], readFloat(content[20+8*i:]))
+			}
+		}
+	}
+
+	must(os.WriteFile(base+".shp", append(header(shapeType, bbox, 100+shp.Len()), shp.Bytes()...), 0o644))
+	must(os.WriteFile(base+".shx", append(header(shapeType, bbox, 100+shx.Len()), shx.Bytes()...), 0o644))
+}
+
+func header(shapeType int32, bbox [4]float64, length int) []byte {
+	buf := new(bytes.Buffer)
+	be(buf, int32(9994))
+	buf.Write(make([]byte, 20))
+	be(buf, int32(length/2))
+	le(buf, int32(1000))
+	le(buf, shapeType)
+	le(buf, bbox)
+	buf.Write(make([]byte, 32)) // zmin, zmax, mmin, mmax
+	return buf.Bytes()
+}
+
+// writeDBF grava uma tabela dBASE III com os textos convertidos para Latin-1
+func writeDBF(path string, languageDriver byte, fields []field, rows [][]string, deleted []bool) {
+	recordLen := 1
+	for _, f := range fields {
+		recordLen += f.length
+	}
+	headerLen := 32 + 32*len(fields) + 1
+
+	buf := new(bytes.Buffer)
+	buf.Write([]byte{0x03, 126, 10, 18})
+	le(buf, uint32(len(rows)))
+	le(buf, uint16(headerLen))
+	le(buf, uint16(recordLen))
+	reserved := make([]byte, 20)
+	reserved[17] = languageDriver // byte 29 do cabeçalho
+	buf.Write(reserved)
+
+	for _, f := range fields {
+		descriptor := make([]byte, 32)
+		copy(descriptor, f.name)
+		descriptor[11] = f.kind
+		descriptor[16] = byte(f.length)
+		buf.Write(descriptor)
+	}
+	buf.WriteByte(0x0D)
+
+	for i, row := range rows {
+		if deleted[i] {
+			buf.WriteByte('*')
+		} else {
+			buf.WriteByte(' ')
+		}
+		for j, f := range fields {
+			value := latin1(row[j])
+			cell := bytes.Repeat([]byte{' '}, f.length)
+			if f.kind == 'N' {
+				copy(cell[f.length-len(value):], value)
+			} else {
+				copy(cell, value)
+			}
+			buf.Write(cell)
+		}
+	}
+	buf.WriteByte(0x1A)
+	must(os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
+func latin1(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		out = append(out, byte(r))
+	}
+	return out
+}
+
+func readFloat(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func le(buf *bytes.Buffer, v interface{}) { must(binary.Write(buf, binary.LittleEndian, v)) }
+func be(buf *bytes.Buffer, v interface{}) { must(binary.Write(buf, binary.BigEndian, v)) }
+
+func must(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,72 +2,296 @@ package utils
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
-// DownloadFile downloads a file from a URL and saves it to the specified path
-func DownloadFile(url, filepath string) error {
-	resp, err := http.Get(url)
+// Limites padrão de extração: suficientes para os dumps do GeoNames (BR.txt tem
+// dezenas de MB e comprime ~5x) e baixos o bastante para barrar zip bombs
+const (
+	DefaultMaxUncompressedBytes int64 = 1 << 30 // 1 GiB
+	DefaultMaxCompressionRatio        = 100.0
+)
+
+var (
+	// ErrUnsafeZipEntry indica uma entrada que escaparia do diretório de destino
+	ErrUnsafeZipEntry = errors.New("entrada de zip insegura")
+	// ErrZipTooLarge indica que o conteúdo descompactado excede os limites configurados
+	ErrZipTooLarge = errors.New("conteúdo do zip excede o limite permitido")
+)
+
+// UnzipOptions controla a extração de um zip
+type UnzipOptions struct {
+	// MaxUncompressedBytes limita o total descompactado (0 = DefaultMaxUncompressedBytes)
+	MaxUncompressedBytes int64
+	// MaxCompressionRatio limita a razão descompactado/compactado por entrada (0 = DefaultMaxCompressionRatio)
+	MaxCompressionRatio float64
+	// Entries, se informado, extrai apenas as entradas com esses nomes (ex: "BR.txt")
+	Entries []string
+}
+
+func (o UnzipOptions) withDefaults() UnzipOptions {
+	if o.MaxUncompressedBytes <= 0 {
+		o.MaxUncompressedBytes = DefaultMaxUncompressedBytes
+	}
+	if o.MaxCompressionRatio <= 0 {
+		o.MaxCompressionRatio = DefaultMaxCompressionRatio
+	}
+	return o
+}
+
+// UnzipFile extrai o zip para destDir e retorna os caminhos dos arquivos gerados.
+// Nomes absolutos, com "..", links simbólicos e entradas que resolveriam fora de
+// destDir são rejeitados (zip-slip); tamanho total e razão de compressão são
+// verificados tanto pelo cabeçalho quanto durante a cópia, já que o cabeçalho
+// pode mentir.
+func UnzipFile(zipPath, destDir string, opts UnzipOptions) ([]string, error) {
+	opts = opts.withDefaults()
+
+	r, err := zip.OpenReader(zipPath)
 	if err != nil {
-		return fmt.Errorf("erro ao baixar arquivo: %w", err)
+		return nil, err
+	}
+	defer r.Close()
+
+	destDir, err = filepath.Abs(destDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de destino: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range opts.Entries {
+		wanted[name] = false
+	}
+
+	var extracted []string
+	var total int64
+
+	for _, f := range r.File {
+		if len(wanted) > 0 {
+			if _, ok := wanted[f.Name]; !ok {
+				continue
+			}
+			wanted[f.Name] = true
+		}
+
+		target, err := safeZipPath(destDir, f.Name)
+		if err != nil {
+			return extracted, err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return extracted, err
+			}
+			continue
+		}
+
+		if !f.Mode().IsRegular() {
+			return extracted, fmt.Errorf("%w: %s não é um arquivo regular", ErrUnsafeZipEntry, f.Name)
+		}
+
+		if err := checkZipEntrySize(f, total, opts); err != nil {
+			return extracted, err
+		}
+
+		written, err := extractZipEntry(f, target, entryLimit(f, total, opts))
+		total += written
+		if err != nil {
+			return extracted, err
+		}
+
+		extracted = append(extracted, target)
+	}
+
+	for name, found := range wanted {
+		if !found {
+			return extracted, fmt.Errorf("entrada %s não encontrada em %s", name, zipPath)
+		}
+	}
+
+	return extracted, nil
+}
+
+// safeZipPath valida o nome da entrada e retorna o caminho final dentro de destDir
+func safeZipPath(destDir, name string) (string, error) {
+	if name == "" || strings.ContainsRune(name, 0) || strings.Contains(name, "\\") ||
+		path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeZipEntry, name)
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: %q", ErrUnsafeZipEntry, name)
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("erro ao baixar arquivo: status %d", resp.StatusCode)
+	target := filepath.Join(destDir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(destDir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeZipEntry, name)
 	}
 
-	out, err := os.Create(filepath)
+	return target, nil
+}
+
+// checkZipEntrySize verifica os tamanhos declarados no cabeçalho da entrada
+func checkZipEntrySize(f *zip.File, total int64, opts UnzipOptions) error {
+	size := int64(f.UncompressedSize64)
+	if size < 0 || total+size > opts.MaxUncompressedBytes {
+		return fmt.Errorf("%w: %s (%d bytes)", ErrZipTooLarge, f.Name, f.UncompressedSize64)
+	}
+
+	if f.CompressedSize64 > 0 && float64(f.UncompressedSize64)/float64(f.CompressedSize64) > opts.MaxCompressionRatio {
+		return fmt.Errorf("%w: %s tem razão de compressão acima de %.0f", ErrZipTooLarge, f.Name, opts.MaxCompressionRatio)
+	}
+
+	return nil
+}
+
+// entryLimit é o máximo de bytes que a entrada pode produzir de fato
+func entryLimit(f *zip.File, total int64, opts UnzipOptions) int64 {
+	limit := opts.MaxUncompressedBytes - total
+	if byRatio := int64(float64(f.CompressedSize64) * opts.MaxCompressionRatio); f.CompressedSize64 > 0 && byRatio < limit {
+		limit = byRatio
+	}
+	return limit
+}
+
+// extractZipEntry grava a entrada em target com no máximo limit bytes; em caso
+// de erro o arquivo parcial é removido
+func extractZipEntry(f *zip.File, target string, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	rc, err := f.Open()
 	if err != nil {
-		return fmt.Errorf("erro ao criar arquivo: %w", err)
+		return 0, err
+	}
+	defer rc.Close()
+
+	perm := f.Mode().Perm() & 0755
+	if perm == 0 {
+		perm = 0644
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
 	if err != nil {
-		return fmt.Errorf("erro ao salvar arquivo: %w", err)
+		return 0, err
 	}
 
-	return nil
+	written, err := io.Copy(out, &limitedReader{r: rc, remaining: limit, name: f.Name})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return written, err
+	}
+
+	return written, nil
 }
 
-func UnzipFile(zipPath string) error {
-	r, err := zip.OpenReader(zipPath)
+// limitedReader retorna ErrZipTooLarge ao ultrapassar o limite, ao contrário de
+// io.LimitReader que apenas trunca silenciosamente
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	name      string
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Permite detectar EOF exatamente no limite
+		var probe [1]byte
+		if n, _ := l.r.Read(probe[:]); n > 0 {
+			return 0, fmt.Errorf("%w: %s", ErrZipTooLarge, l.name)
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// zipEntryReader lê uma entrada do zip e fecha também o arquivo do zip no Close
+type zipEntryReader struct {
+	io.ReadCloser
+	reader  io.Reader
+	archive *zip.ReadCloser
+}
+
+func (z *zipEntryReader) Read(p []byte) (int, error) {
+	return z.reader.Read(p)
+}
+
+func (z *zipEntryReader) Close() error {
+	err := z.ReadCloser.Close()
+	if closeErr := z.archive.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// OpenZipEntry abre uma entrada do zip para leitura em streaming, sem extraí-la,
+// com os mesmos limites de tamanho e razão de compressão de UnzipFile
+func OpenZipEntry(zipPath, entryName string) (io.ReadCloser, error) {
+	archive, err := zip.OpenReader(zipPath)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("erro ao abrir zip: %w", err)
 	}
-	defer r.Close()
 
-	for _, f := range r.File {
-		// Create directory if needed
-		dir := filepath.Dir(f.Name)
-		if dir != "." {
-			os.MkdirAll(dir, 0755)
+	opts := UnzipOptions{}.withDefaults()
+
+	for _, f := range archive.File {
+		if f.Name != entryName {
+			continue
 		}
 
-		outFile, err := os.Create(f.Name)
-		if err != nil {
-			return err
+		if err := checkZipEntrySize(f, 0, opts); err != nil {
+			archive.Close()
+			return nil, err
 		}
 
 		rc, err := f.Open()
 		if err != nil {
-			return err
+			archive.Close()
+			return nil, fmt.Errorf("erro ao abrir %s no zip: %w", entryName, err)
 		}
 
-		_, err = io.Copy(outFile, rc)
+		limited := &limitedReader{r: rc, remaining: entryLimit(f, 0, opts), name: f.Name}
+		return &zipEntryReader{ReadCloser: rc, reader: limited, archive: archive}, nil
+	}
 
-		outFile.Close()
-		rc.Close()
+	archive.Close()
+	return nil, fmt.Errorf("entrada %s não encontrada em %s", entryName, zipPath)
+}
 
-		if err != nil {
-			return err
-		}
+// OpenDataset abre um arquivo de dados para leitura. Se for um .zip do GeoNames
+// (ex: BR.zip), lê diretamente a entrada .txt de mesmo nome (BR.txt).
+func OpenDataset(path string) (io.ReadCloser, error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		base := filepath.Base(path)
+		entry := strings.TrimSuffix(base, filepath.Ext(base)) + ".txt"
+		return OpenZipEntry(path, entry)
 	}
 
-	return nil
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	return file, nil
 }
@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// zipEntry descreve uma entrada do zip de teste. Com declaredSize, os dados
+// são gravados já compactados (CreateRaw) com esse tamanho descompactado no
+// cabeçalho, que pode mentir.
+type zipEntry struct {
+	name         string
+	data         []byte
+	mode         os.FileMode
+	declaredSize int64
+}
+
+// buildZip monta o zip em memória e o grava em um diretório temporário
+func buildZip(t *testing.T, entries ...zipEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.mode != 0 {
+			header.SetMode(e.mode)
+		}
+
+		if e.declaredSize == 0 {
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(e.data)
+			continue
+		}
+
+		var compressed bytes.Buffer
+		fw, _ := flate.NewWriter(&compressed, flate.BestCompression)
+		fw.Write(e.data)
+		fw.Close()
+		header.CRC32 = crc32.ChecksumIEEE(e.data)
+		header.CompressedSize64 = uint64(compressed.Len())
+		header.UncompressedSize64 = uint64(e.declaredSize)
+		w, err := zw.CreateRaw(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(compressed.Bytes())
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "teste.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// listFiles retorna os arquivos sob root, relativos a ele
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+// sizeRejected aceita o erro dos limites ou o do próprio archive/zip, que
+// também recusa ler além do tamanho declarado no cabeçalho
+func sizeRejected(err error) bool {
+	return errors.Is(err, ErrZipTooLarge) || errors.Is(err, zip.ErrFormat)
+}
+
+func TestUnzipFile(t *testing.T) {
+	zipPath := buildZip(t,
+		zipEntry{name: "BR.txt", data: []byte("3448439\tSão Paulo\n")},
+		zipEntry{name: "docs/"},
+		zipEntry{name: "docs/readme.txt", data: []byte("leia-me")},
+	)
+	dest := filepath.Join(t.TempDir(), "destino")
+
+	files, err := UnzipFile(zipPath, dest, UnzipOptions{})
+	if err != nil {
+		t.Fatalf("UnzipFile: %v", err)
+	}
+	want := []string{filepath.Join(dest, "BR.txt"), filepath.Join(dest, "docs", "readme.txt")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("arquivos = %v, esperado %v", files, want)
+	}
+	if data, _ := os.ReadFile(want[1]); string(data) != "leia-me" {
+		t.Errorf("conteúdo = %q", data)
+	}
+}
+
+func TestUnzipFileEntries(t *testing.T) {
+	zipPath := buildZip(t,
+		zipEntry{name: "readme.txt", data: []byte("leia-me")},
+		zipEntry{name: "BR.txt", data: []byte("dados")},
+		// Fora do filtro, uma entrada insegura nem é considerada
+		zipEntry{name: "../fora.txt", data: []byte("x")},
+	)
+
+	dest := t.TempDir()
+	files, err := UnzipFile(zipPath, dest, UnzipOptions{Entries: []string{"BR.txt"}})
+	if err != nil {
+		t.Fatalf("UnzipFile: %v", err)
+	}
+	if got := listFiles(t, dest); !reflect.DeepEqual(got, []string{"BR.txt"}) || len(files) != 1 {
+		t.Errorf("extraídos = %v (%v), esperado só BR.txt", got, files)
+	}
+
+	_, err = UnzipFile(zipPath, t.TempDir(), UnzipOptions{Entries: []string{"BR.txt", "allCountries.txt"}})
+	if err == nil || !strings.Contains(err.Error(), "entrada allCountries.txt não encontrada") {
+		t.Errorf("UnzipFile com entrada ausente = %v", err)
+	}
+}
+
+func TestUnzipFileRejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry zipEntry
+	}{
+		{"pai", zipEntry{name: "../fora.txt", data: []byte("x")}},
+		{"pai no meio", zipEntry{name: "dados/../../fora.txt", data: []byte("x")}},
+		{"diretório pai", zipEntry{name: "../"}},
+		{"absoluto", zipEntry{name: "/tmp/fora.txt", data: []byte("x")}},
+		{"barra invertida", zipEntry{name: `..\fora.txt`, data: []byte("x")}},
+		{"caminho do Windows", zipEntry{name: `C:\Windows\fora.txt`, data: []byte("x")}},
+		{"nulo", zipEntry{name: "fora\x00.txt", data: []byte("x")}},
+		{"link simbólico", zipEntry{name: "link", data: []byte("/etc/passwd"), mode: os.ModeSymlink | 0o777}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A entrada segura vem antes, para conferir que a extração para
+			// na insegura sem gravar nada fora do destino
+			zipPath := buildZip(t, zipEntry{name: "ok.txt", data: []byte("ok")}, tt.entry)
+			root := t.TempDir()
+			dest := filepath.Join(root, "a", "b")
+
+			_, err := UnzipFile(zipPath, dest, UnzipOptions{})
+			if !errors.Is(err, ErrUnsafeZipEntry) {
+				t.Fatalf("UnzipFile = %v, esperado ErrUnsafeZipEntry", err)
+			}
+			if got := listFiles(t, root); !reflect.DeepEqual(got, []string{"a/b/ok.txt"}) {
+				t.Errorf("arquivos gravados = %v, esperado só a/b/ok.txt", got)
+			}
+			if _, err := os.Lstat("/tmp/fora.txt"); err == nil {
+				t.Errorf("/tmp/fora.txt foi criado")
+			}
+		})
+	}
+}
+
+func TestUnzipFileSizeLimits(t *testing.T) {
+	zeros := make([]byte, 1<<20) // comprime mais de 1000x
+
+	tests := []struct {
+		name  string
+		entry zipEntry
+		opts  UnzipOptions
+	}{
+		{
+			name:  "razão de compressão declarada",
+			entry: zipEntry{name: "bomba.txt", data: zeros},
+		},
+		{
+			name:  "razão real acima do limite com cabeçalho mentindo",
+			entry: zipEntry{name: "bomba.txt", data: zeros, declaredSize: 1000},
+		},
+		{
+			name:  "total declarado acima do limite",
+			entry: zipEntry{name: "grande.txt", data: bytes.Repeat([]byte("abc"), 100)},
+			opts:  UnzipOptions{MaxUncompressedBytes: 100},
+		},
+		{
+			name:  "tamanho real maior que o declarado",
+			entry: zipEntry{name: "mentira.txt", data: bytes.Repeat([]byte("abcdefgh"), 100), declaredSize: 10},
+			opts:  UnzipOptions{MaxUncompressedBytes: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zipPath := buildZip(t, tt.entry)
+			dest := t.TempDir()
+
+			_, err := UnzipFile(zipPath, dest, tt.opts)
+			if !sizeRejected(err) {
+				t.Fatalf("UnzipFile = %v, esperado ErrZipTooLarge", err)
+			}
+			if got := listFiles(t, dest); len(got) != 0 {
+				t.Errorf("arquivo parcial deixado para trás: %v", got)
+			}
+		})
+	}
+
+	// Várias entradas dentro do limite individual somam acima do total
+	zipPath := buildZip(t,
+		zipEntry{name: "a.txt", data: bytes.Repeat([]byte("a1b2"), 20)},
+		zipEntry{name: "b.txt", data: bytes.Repeat([]byte("c3d4"), 20)},
+	)
+	if _, err := UnzipFile(zipPath, t.TempDir(), UnzipOptions{MaxUncompressedBytes: 100}); !errors.Is(err, ErrZipTooLarge) {
+		t.Errorf("UnzipFile com total acima do limite = %v", err)
+	}
+}
+
+func TestOpenZipEntry(t *testing.T) {
+	zipPath := buildZip(t,
+		zipEntry{name: "readme.txt", data: []byte("leia-me")},
+		zipEntry{name: "BR.txt", data: []byte("dados")},
+		zipEntry{name: "bomba.txt", data: make([]byte, 1<<20)},
+		zipEntry{name: "mentira.txt", data: make([]byte, 1<<20), declaredSize: 1000},
+	)
+
+	rc, err := OpenZipEntry(zipPath, "BR.txt")
+	if err != nil {
+		t.Fatalf("OpenZipEntry: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	if err != nil || string(data) != "dados" {
+		t.Errorf("BR.txt = %q, %v", data, err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	if _, err := OpenZipEntry(zipPath, "bomba.txt"); !errors.Is(err, ErrZipTooLarge) {
+		t.Errorf("OpenZipEntry(bomba.txt) = %v, esperado ErrZipTooLarge", err)
+	}
+
+	rc, err = OpenZipEntry(zipPath, "mentira.txt")
+	if err != nil {
+		t.Fatalf("OpenZipEntry(mentira.txt): %v", err)
+	}
+	if _, err := io.Copy(io.Discard, rc); !sizeRejected(err) {
+		t.Errorf("leitura de mentira.txt = %v, esperado ErrZipTooLarge", err)
+	}
+	rc.Close()
+
+	if _, err := OpenZipEntry(zipPath, "ausente.txt"); err == nil || !strings.Contains(err.Error(), "não encontrada") {
+		t.Errorf("OpenZipEntry(ausente.txt) = %v", err)
+	}
+}
+
+func TestOpenDatasetZip(t *testing.T) {
+	zipPath := buildZip(t, zipEntry{name: "readme.txt", data: []byte("leia-me")}, zipEntry{name: "BR.txt", data: []byte("dados")})
+	brZip := filepath.Join(filepath.Dir(zipPath), "BR.zip")
+	if err := os.Rename(zipPath, brZip); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := OpenDataset(brZip)
+	if err != nil {
+		t.Fatalf("OpenDataset: %v", err)
+	}
+	defer rc.Close()
+	if data, _ := io.ReadAll(rc); string(data) != "dados" {
+		t.Errorf("OpenDataset leu %q, esperado a entrada BR.txt", data)
+	}
+}